@@ -0,0 +1,303 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/user"
+	"text/tabwriter"
+	"time"
+
+	"urlshortener/internal/storage"
+	"urlshortener/internal/storage/sqlite"
+	"urlshortener/lib/apikey"
+)
+
+var migrateCmd = &command{
+	name:    "migrate",
+	summary: "Bring the storage schema up to date",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			// opening the storage migrates it
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			fmt.Fprintf(e.stdout, "%s is up to date\n", e.cfg.StoragePath)
+			return nil
+		}
+	},
+}
+
+var backupCmd = &command{
+	name:    "backup",
+	args:    "<file>",
+	summary: "Write a copy of the storage to a new file, the server may keep running",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			if _, err := os.Stat(args[0]); err == nil {
+				return fmt.Errorf("%s already exists", args[0])
+			}
+
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			if err := st.Backup(args[0]); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(e.stdout, "backed up %s to %s\n", e.cfg.StoragePath, args[0])
+			return nil
+		}
+	},
+}
+
+var restoreCmd = &command{
+	name:    "restore",
+	args:    "<file>",
+	summary: "Replace the storage with a backup, stop the server first",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+
+			if err := sqlite.Restore(args[0], e.cfg.StoragePath); err != nil {
+				return err
+			}
+
+			// backups of older versions get the current schema
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			// the restore goes to the audit log of the restored storage,
+			// its entries before are the ones of the backup
+			entry := cliEntry(storage.AuditRestore, storage.AuditEntityStorage)
+			entry.After, _ = json.Marshal(map[string]string{"backup": args[0]})
+			if err := st.AppendAudit(entry); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(e.stdout, "restored %s from %s\n", e.cfg.StoragePath, args[0])
+			return nil
+		}
+	},
+}
+
+var vacuumCmd = &command{
+	name:    "vacuum",
+	summary: "Rebuild the storage file to return the space of deleted rows",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			before := fileSize(e.cfg.StoragePath)
+			if err := st.Vacuum(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(e.stdout, "vacuumed %s: %d -> %d bytes\n", e.cfg.StoragePath, before, fileSize(e.cfg.StoragePath))
+			return nil
+		}
+	},
+}
+
+var purgeExpiredCmd = &command{
+	name:    "purge-expired",
+	summary: "Remove deleted links past the alias quarantine and expired idempotency keys",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			purged, err := st.PurgeExpired(time.Now(), e.cfg.URL.IdempotencyWindow, cliEntry("", ""))
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(e.stdout, "purged %d deleted links and %d idempotency keys\n", purged.Links, purged.IdempotencyKeys)
+			return nil
+		}
+	},
+}
+
+var createAPIKeyCmd = &command{
+	name:    "create-api-key",
+	summary: "Create a key for the X-API-Key header and print it",
+	setup: func(fs *flag.FlagSet) action {
+		owner := fs.String("owner", "", "user the key acts as (default http_server.user)")
+		name := fs.String("name", "", "name telling the keys of the user apart")
+
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			if *owner == "" {
+				*owner = e.cfg.HTTPServer.User
+			}
+			if *owner == "" {
+				return errors.New("-owner is required without http_server.user")
+			}
+
+			key, err := apikey.Generate()
+			if err != nil {
+				return err
+			}
+
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			id, err := st.SaveAPIKey(storage.APIKey{
+				Owner: *owner,
+				Name:  *name,
+				Hash:  apikey.Hash(key),
+			})
+			if err != nil {
+				return err
+			}
+
+			// only the key goes to stdout, so it can be captured by scripts
+			fmt.Fprintln(e.stdout, key)
+			fmt.Fprintf(e.stderr, "created api key %d for %s, it is not shown again\n", id, *owner)
+			return nil
+		}
+	},
+}
+
+var checkConfigCmd = &command{
+	name:        "check-config",
+	summary:     "Validate the config without starting the server",
+	unvalidated: true,
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			errs := checkConfig(e.cfg)
+			for _, err := range errs {
+				fmt.Fprintf(e.stderr, "  %v\n", err)
+			}
+			if len(errs) > 0 {
+				return fmt.Errorf("config has %d errors", len(errs))
+			}
+
+			fmt.Fprintln(e.stdout, "config is valid")
+			return nil
+		}
+	},
+}
+
+var statsCmd = &command{
+	name:    "stats",
+	summary: "Print row counts and the size of the storage",
+	setup: func(fs *flag.FlagSet) action {
+		asJSON := fs.Bool("json", false, "print JSON")
+
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			st, err := openStorage(e.cfg)
+			if err != nil {
+				return err
+			}
+			defer st.Close()
+
+			totals, err := st.Totals()
+			if err != nil {
+				return err
+			}
+			size := fileSize(e.cfg.StoragePath)
+
+			if *asJSON {
+				enc := json.NewEncoder(e.stdout)
+				enc.SetIndent("", "  ")
+				return enc.Encode(struct {
+					storage.Totals
+					SizeBytes int64 `json:"size_bytes"`
+				}{totals, size})
+			}
+
+			tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+			for _, row := range []struct {
+				name  string
+				value int64
+			}{
+				{"links", totals.Links},
+				{"deleted links", totals.DeletedLinks},
+				{"clicks", totals.Clicks},
+				{"domains", totals.Domains},
+				{"campaigns", totals.Campaigns},
+				{"webhooks", totals.Webhooks},
+				{"pending deliveries", totals.PendingDeliveries},
+				{"dead deliveries", totals.DeadDeliveries},
+				{"audit entries", totals.AuditEntries},
+				{"idempotency keys", totals.IdempotencyKeys},
+				{"api keys", totals.APIKeys},
+				{"size, bytes", size},
+			} {
+				fmt.Fprintf(tw, "%s\t%d\n", row.name, row.value)
+			}
+
+			return tw.Flush()
+		}
+	},
+}
+
+// fileSize is the size of the file at path, 0 if it can't be read.
+func fileSize(path string) int64 {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return fi.Size()
+}
+
+// cliEntry returns an audit entry of a change made by the admin command,
+// the actor is the OS user running it.
+func cliEntry(action string, entity string) storage.AuditEntry {
+	actor := "cli"
+	if u, err := user.Current(); err == nil {
+		actor = "cli:" + u.Username
+	}
+
+	return storage.AuditEntry{Actor: actor, Action: action, Entity: entity}
+}
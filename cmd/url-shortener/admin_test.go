@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/config"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+// writeConfig writes a config with the storage in a temp dir,
+// extra is appended to it.
+func writeConfig(t *testing.T, extra string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yml")
+	cfg := `env: "local"
+storage_path: "` + filepath.Join(dir, "storage.db") + `"
+app_secret: "secret"
+http_server:
+  address: "localhost:8082"
+  user: "user"
+  password: "password"
+url:
+  alias_quarantine: 1ns
+` + extra
+	require.NoError(t, os.WriteFile(path, []byte(cfg), 0o600))
+
+	return path
+}
+
+// urlShortener runs the command line and returns stdout, stderr and the exit code.
+func urlShortener(args ...string) (string, string, int) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+
+	return stdout.String(), stderr.String(), code
+}
+
+func TestAdminCommands(t *testing.T) {
+	configPath := writeConfig(t, "")
+	cfg := config.MustLoadPath(configPath)
+
+	out, _, code := urlShortener("--config", configPath, "migrate")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "is up to date")
+
+	st, err := openStorage(cfg)
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "kept", URL: "https://example.com/kept", Owner: "user"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "gone", URL: "https://example.com/gone"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.DeleteURL("", "gone", storage.AuditEntry{}))
+	require.NoError(t, st.Close())
+
+	// the flag is accepted after the command too
+	out, _, code = urlShortener("stats", "-json", "--config", configPath)
+	require.Equal(t, 0, code)
+	var totals struct {
+		storage.Totals
+		SizeBytes int64 `json:"size_bytes"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &totals))
+	assert.EqualValues(t, 1, totals.Links)
+	assert.EqualValues(t, 1, totals.DeletedLinks)
+	assert.Positive(t, totals.SizeBytes)
+
+	backup := filepath.Join(t.TempDir(), "backup.db")
+	_, _, code = urlShortener("--config", configPath, "backup", backup)
+	require.Equal(t, 0, code)
+	_, stderr, code := urlShortener("--config", configPath, "backup", backup)
+	require.Equal(t, 1, code)
+	assert.Contains(t, stderr, "already exists")
+
+	out, _, code = urlShortener("--config", configPath, "purge-expired")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "purged 1 deleted links and 0 idempotency keys\n", out)
+
+	out, _, code = urlShortener("--config", configPath, "vacuum")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "vacuumed")
+
+	out, _, code = urlShortener("--config", configPath, "stats")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, "deleted links       0")
+
+	st, err = openStorage(cfg)
+	require.NoError(t, err)
+	entries, err := st.ListAudit(storage.AuditFilter{Action: storage.AuditPurge})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "gone", entries[0].Alias)
+	assert.True(t, strings.HasPrefix(entries[0].Actor, "cli"))
+	require.NoError(t, st.Close())
+
+	// the tombstone purged above is back with the backup
+	out, _, code = urlShortener("--config", configPath, "restore", backup)
+	require.Equal(t, 0, code, out)
+	st, err = openStorage(cfg)
+	require.NoError(t, err)
+	_, err = st.GetURL("", "gone")
+	assert.ErrorIs(t, err, storage.ErrURLDeleted)
+	entries, err = st.ListAudit(storage.AuditFilter{Entity: storage.AuditEntityStorage})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, storage.AuditRestore, entries[0].Action)
+	assert.Contains(t, string(entries[0].After), "backup.db")
+	verification, err := st.VerifyAudit()
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+	require.NoError(t, st.Close())
+
+	_, stderr, code = urlShortener("--config", configPath, "restore", configPath)
+	require.Equal(t, 1, code)
+	assert.NotEmpty(t, stderr)
+
+	key, stderr, code := urlShortener("--config", configPath, "create-api-key", "-name", "ci")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stderr, "for user")
+	key = strings.TrimSpace(key)
+
+	st, err = openStorage(cfg)
+	require.NoError(t, err)
+	defer st.Close()
+	router, err := newRouter(slogdiscard.NewDiscardLogger(), cfg, st)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		key  string
+		want int
+	}{
+		{key, http.StatusOK},
+		{"us_wrong", http.StatusUnauthorized},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/url/kept", nil)
+		req.Header.Set(auth.Header, tc.key)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, tc.want, rr.Code, tc.key)
+	}
+}
+
+func TestCheckConfig(t *testing.T) {
+	out, _, code := urlShortener("--config", writeConfig(t, ""), "check-config")
+	require.Equal(t, 0, code)
+	assert.Equal(t, "config is valid\n", out)
+
+	bad := writeConfig(t, `  default_redirect: 303
+  query_merge: replace
+pages:
+  root_template: /nonexistent/root.html
+`)
+	_, stderr, code := urlShortener("--config", bad, "check-config")
+	require.Equal(t, 1, code)
+	assert.Contains(t, stderr, "url.default_redirect")
+	assert.Contains(t, stderr, "url.query_merge")
+	assert.Contains(t, stderr, "pages.root_template")
+	assert.Contains(t, stderr, "config has 3 errors")
+}
+
+func TestUsage(t *testing.T) {
+	_, stderr, code := urlShortener("help")
+	require.Equal(t, 0, code)
+	for _, cmd := range commands {
+		assert.Contains(t, stderr, cmd.name)
+	}
+
+	_, stderr, code = urlShortener("help", "create-api-key")
+	require.Equal(t, 0, code)
+	assert.Contains(t, stderr, "-owner")
+
+	_, _, code = urlShortener("unknown")
+	assert.Equal(t, 2, code)
+
+	_, _, code = urlShortener("--config", writeConfig(t, ""), "backup")
+	assert.Equal(t, 2, code)
+}
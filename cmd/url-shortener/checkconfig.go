@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"urlshortener/internal/config"
+)
+
+// checkConfig returns the problems the server would only run into
+// at startup or on the first request.
+func checkConfig(cfg *config.Config) []error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(slices.Contains([]string{envLocal, envDev, envProd}, cfg.Env),
+		"env must be one of: %s %s %s", envLocal, envDev, envProd)
+
+	if cfg.StoragePath == "" {
+		errs = append(errs, errors.New("storage_path is required"))
+	} else if fi, err := os.Stat(filepath.Dir(cfg.StoragePath)); err != nil || !fi.IsDir() {
+		errs = append(errs, fmt.Errorf("storage_path: directory %s does not exist", filepath.Dir(cfg.StoragePath)))
+	}
+
+	check(cfg.HTTPServer.User != "", "http_server.user is required")
+	check(cfg.HTTPServer.Password != "", "http_server.password is required")
+	_, _, err := net.SplitHostPort(cfg.HTTPServer.Addres)
+	check(err == nil, "http_server.address: %v", err)
+
+	check(slices.Contains([]int{
+		http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect,
+	}, cfg.URL.DefaultRedirect), "url.default_redirect must be one of: 301 302 307 308")
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	check(http.StatusText(cfg.URL.NotYetActiveStatus) != "",
+		"url.not_yet_active_status %d is not an HTTP status", cfg.URL.NotYetActiveStatus)
+	if cfg.URL.BaseURL != "" {
+		u, err := url.Parse(cfg.URL.BaseURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"url.base_url must be an absolute http(s) URL")
+	}
+
+	for _, tmpl := range []struct{ name, path string }{
+		{"pages.not_found_template", cfg.Pages.NotFoundTemplate},
+		{"pages.root_template", cfg.Pages.RootTemplate},
+	} {
+		if tmpl.path == "" {
+			continue
+		}
+		_, err := template.ParseFiles(tmpl.path)
+		check(err == nil, "%s: %v", tmpl.name, err)
+	}
+
+	check(cfg.Webhooks.MaxAttempts >= 1, "webhooks.max_attempts must be at least 1")
+	check(cfg.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+
+	if cfg.GRPC.Address != "" {
+		_, _, err := net.SplitHostPort(cfg.GRPC.Address)
+		check(err == nil, "grpc.address: %v", err)
+	}
+
+	return errs
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/api/client"
+)
+
+// TestClient runs the Go client against the routes of the server.
+func TestClient(t *testing.T) {
+	srv := httptest.NewServer(newTestRouter(t))
+	defer srv.Close()
+
+	c, err := client.New(srv.URL, client.Options{Auth: client.BasicAuth("user", "password")})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	alias, err := c.Save(ctx, client.SaveRequest{URL: "https://example.com", Alias: "guide", Password: "secret"})
+	require.NoError(t, err)
+	assert.Equal(t, "guide", alias)
+
+	_, err = c.Save(ctx, client.SaveRequest{URL: "https://example.com", Alias: "guide"})
+	assert.ErrorIs(t, err, client.ErrURLExists)
+
+	_, err = c.Save(ctx, client.SaveRequest{URL: "not a url"})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.ErrorIs(t, err, client.ErrValidation)
+	require.Len(t, apiErr.Fields, 1)
+	assert.Equal(t, "url", apiErr.Fields[0].Field)
+
+	link, err := c.Get(ctx, "", "guide")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", link.URL)
+	assert.True(t, link.Protected)
+	assert.False(t, link.CreatedAt.IsZero())
+
+	link, err = c.Update(ctx, "", "guide", client.UpdateRequest{
+		URL:      client.Ptr("https://example.org"),
+		Password: client.Ptr(""),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.org", link.URL)
+	assert.False(t, link.Protected)
+
+	results := c.Batch(ctx, []client.SaveRequest{
+		{URL: "https://example.com/1", Alias: "one"},
+		{URL: "https://example.com/2", Alias: "guide"},
+		{URL: "https://example.com/3"},
+	})
+	require.Len(t, results, 3)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "one", results[0].Alias)
+	assert.ErrorIs(t, results[1].Err, client.ErrURLExists)
+	assert.NoError(t, results[2].Err)
+
+	page, err := c.List(ctx, client.ListOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Links, 2)
+	assert.Equal(t, "guide", page.Links[0].Alias)
+	require.NotZero(t, page.NextAfter)
+
+	page, err = c.List(ctx, client.ListOptions{After: page.NextAfter, Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Links, 1)
+	assert.Zero(t, page.NextAfter)
+
+	stats, err := c.Stats(ctx, "", "guide")
+	require.NoError(t, err)
+	assert.Zero(t, stats.Clicks)
+
+	require.NoError(t, c.Delete(ctx, "", "guide"))
+
+	_, err = c.Get(ctx, "", "guide")
+	assert.ErrorIs(t, err, client.ErrURLNotFound)
+	assert.ErrorIs(t, c.Delete(ctx, "", "guide"), client.ErrURLNotFound)
+
+	bad, err := client.New(srv.URL, client.Options{Auth: client.BasicAuth("user", "wrong")})
+	require.NoError(t, err)
+	_, err = bad.Get(ctx, "", "one")
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"urlshortener/internal/config"
+)
+
+// Maintenance tasks are subcommands of the server binary. They run
+// against the configured storage without starting the server:
+//
+//	url-shortener [--config file] [command] [flags] [args]
+//
+// Without a command the server is started.
+
+// errUsage makes run print the usage of the command and exit with 2.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	// setup registers the flags of the command and returns its action,
+	// which sees the parsed values.
+	setup func(fs *flag.FlagSet) action
+	// unvalidated commands get the config even if Validate fails
+	unvalidated bool
+}
+
+type action func(e *env, args []string) error
+
+// env is what commands run with.
+type env struct {
+	stdout io.Writer
+	stderr io.Writer
+	cfg    *config.Config
+}
+
+// commands are listed by help in this order.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		serveCmd, migrateCmd, backupCmd, restoreCmd, vacuumCmd,
+		purgeExpiredCmd, createAPIKeyCmd, checkConfigCmd, statsCmd,
+	}
+}
+
+var serveCmd = &command{
+	name:    "serve",
+	summary: "Run the server (default)",
+	setup: func(fs *flag.FlagSet) action {
+		return func(e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+			serve(e.cfg)
+			return nil
+		}
+	},
+}
+
+// run executes the command line and returns the exit code:
+// 0 on success, 1 on errors and 2 on invalid usage.
+func run(args []string, stdout, stderr io.Writer) int {
+	var configPath string
+
+	root := flag.NewFlagSet("url-shortener", flag.ContinueOnError)
+	root.SetOutput(stderr)
+	configFlag(root, &configPath)
+	root.Usage = func() { usage(stderr) }
+
+	if err := root.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	name := root.Arg(0)
+	switch name {
+	case "":
+		name = serveCmd.name
+	case "help":
+		if cmd := findCommand(root.Arg(1)); cmd != nil {
+			commandUsage(stderr, cmd)
+		} else {
+			usage(stderr)
+		}
+		return 0
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "url-shortener: unknown command %q\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("url-shortener "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag(fs, &configPath)
+	act := cmd.setup(fs)
+	fs.Usage = func() { commandUsage(stderr, cmd) }
+
+	var cmdArgs []string
+	if len(root.Args()) > 0 {
+		var err error
+		if cmdArgs, err = parseInterleaved(fs, root.Args()[1:]); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return 0
+			}
+			return 2
+		}
+	}
+
+	e := &env{
+		stdout: stdout,
+		stderr: stderr,
+	}
+	if cmd.unvalidated {
+		e.cfg = config.MustReadPath(configPath)
+	} else {
+		e.cfg = config.MustLoadPath(configPath)
+	}
+
+	if err := act(e, cmdArgs); err != nil {
+		if errors.Is(err, errUsage) {
+			commandUsage(stderr, cmd)
+			return 2
+		}
+		fmt.Fprintf(stderr, "url-shortener: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// configFlag is accepted before and after the command name.
+func configFlag(fs *flag.FlagSet, configPath *string) {
+	fs.StringVar(configPath, "config", *configPath, "config file (default $CONFIG_PATH)")
+}
+
+// parseInterleaved parses flags placed before, between and after
+// the positional arguments, which it returns.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: url-shortener [--config file] [command] [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-14s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "url-shortener help <command>" for the flags of a command.`)
+}
+
+func commandUsage(w io.Writer, cmd *command) {
+	fmt.Fprintf(w, "Usage: url-shortener %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+
+	var configPath string
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	configFlag(fs, &configPath)
+	cmd.setup(fs)
+	fs.PrintDefaults()
+}
@@ -2,17 +2,42 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"html/template"
 	"log/slog"
 	"net/http"
 	"os"
 
+	grpcapp "urlshortener/internal/app/grpc"
 	ssogrpc "urlshortener/internal/clients/auth/grpc"
 	"urlshortener/internal/config"
+	grpcshortener "urlshortener/internal/grpc/shortener"
+	auditList "urlshortener/internal/http-server/handlers/audit/list"
+	auditVerify "urlshortener/internal/http-server/handlers/audit/verify"
+	campaignSave "urlshortener/internal/http-server/handlers/campaign/save"
+	domainSave "urlshortener/internal/http-server/handlers/domain/save"
+	"urlshortener/internal/http-server/handlers/openapi"
 	delete "urlshortener/internal/http-server/handlers/url/delete"
+	get "urlshortener/internal/http-server/handlers/url/get"
+	list "urlshortener/internal/http-server/handlers/url/list"
+	purge "urlshortener/internal/http-server/handlers/url/purge"
+	qr "urlshortener/internal/http-server/handlers/url/qr"
 	redirect "urlshortener/internal/http-server/handlers/url/redirect"
+	restore "urlshortener/internal/http-server/handlers/url/restore"
+	rules "urlshortener/internal/http-server/handlers/url/rules"
 	save "urlshortener/internal/http-server/handlers/url/save"
+	stats "urlshortener/internal/http-server/handlers/url/stats"
+	update "urlshortener/internal/http-server/handlers/url/update"
+	webhookDelete "urlshortener/internal/http-server/handlers/webhook/delete"
+	webhookDeliveries "urlshortener/internal/http-server/handlers/webhook/deliveries"
+	webhookList "urlshortener/internal/http-server/handlers/webhook/list"
+	webhookSave "urlshortener/internal/http-server/handlers/webhook/save"
 	"urlshortener/internal/storage/sqlite"
+	"urlshortener/internal/webhook"
+	resp "urlshortener/lib/api/response"
 
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/http-server/middleware/idempotency"
 	mwLogger "urlshortener/internal/http-server/middleware/logger"
 
 	"github.com/go-chi/chi/v5"
@@ -26,9 +51,12 @@ const (
 )
 
 func main() {
-	// TODO: init config: cleanenv
-	cfg := config.MustLoad()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
+// serve runs the HTTP server (and the gRPC one, if configured)
+// until it fails.
+func serve(cfg *config.Config) {
 	// TODO: init logger: slog
 	log := setupLogger(cfg.Env)
 	log.Info("starting urlshortener", slog.String("env", cfg.Env))
@@ -48,7 +76,7 @@ func main() {
 	ssoClient.IsAdmin(context.Background(), 1)
 
 	// TODO: init storage: sqlite
-	storage, err := sqlite.New(cfg.StoragePath)
+	storage, err := openStorage(cfg)
 	if err != nil {
 		log.Error("failed to init storage", slog.Any("error", err))
 		os.Exit(1)
@@ -56,31 +84,47 @@ func main() {
 
 	_ = storage
 
-	// TODO: init router: chi, chi render
-	router := chi.NewRouter()
+	// Sends link events queued by the storage to webhooks
+	go webhook.New(log, storage, webhook.Options{
+		PollInterval: cfg.Webhooks.PollInterval,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		Backoff:      cfg.Webhooks.Backoff,
+		MaxBackoff:   cfg.Webhooks.MaxBackoff,
+		AllowPrivate: cfg.Webhooks.AllowPrivate,
+	}).Run(context.Background())
 
-	// TODO: add middleware
-	// Assigns a unique ID to each request for tracking
-	router.Use(middleware.RequestID)
+	// Same links over gRPC, disabled without an address
+	if cfg.GRPC.Address != "" {
+		grpcApp := grpcapp.New(log, storage, grpcapp.Options{
+			Address:    cfg.GRPC.Address,
+			Reflection: cfg.GRPC.Reflection,
+			Credentials: map[string]string{
+				cfg.HTTPServer.User: cfg.HTTPServer.Password,
+			},
+			Shortener: grpcshortener.Options{
+				Dedup:         cfg.URL.Dedup,
+				StripTracking: cfg.URL.StripTracking,
+				DefaultDomain: cfg.URL.DefaultDomain,
+			},
+		})
 
-	// Logs all incoming requests (handler-level logging)
-	router.Use(mwLogger.New(log))
-
-	// Recovers from panics to prevent app-wide crashes
-	router.Use(middleware.Recoverer)
-
-	// Enables clean URL routing (e.g., /resource/{id})
-	router.Use(middleware.URLFormat)
+		// Like the HTTP server below, a gRPC server that can't serve
+		// stops the whole process
+		go func() {
+			if err := grpcApp.Run(); err != nil {
+				log.Error("failed to start grpc server", slog.Any("error", err))
+				os.Exit(1)
+			}
+		}()
+	}
 
-	router.Get("/{alias}", redirect.New(log, storage))
-	// Enables BasicAuth
-	router.Route("/url", func(r chi.Router) {
-		r.Use(middleware.BasicAuth("url-shortener", map[string]string{
-			cfg.HTTPServer.User: cfg.HTTPServer.Password,
-		}))
-		r.Post("/", save.New(log, storage))
-		r.Delete("/{alias}", delete.New(log, storage))
-	})
+	// TODO: init router: chi, chi render
+	router, err := newRouter(log, cfg, storage)
+	if err != nil {
+		log.Error("failed to init router", slog.Any("error", err))
+		os.Exit(1)
+	}
 
 	// TODO: run server: main
 
@@ -104,6 +148,173 @@ func main() {
 
 }
 
+// newRouter registers the HTTP API, every route has to be described
+// in the OpenAPI document too.
+func newRouter(log *slog.Logger, cfg *config.Config, storage *sqlite.Storage) (chi.Router, error) {
+	router := chi.NewRouter()
+
+	// TODO: add middleware
+	// Assigns a unique ID to each request for tracking
+	router.Use(middleware.RequestID)
+
+	// Logs all incoming requests (handler-level logging)
+	router.Use(mwLogger.New(log))
+
+	// Recovers from panics to prevent app-wide crashes
+	router.Use(middleware.Recoverer)
+
+	// Enables clean URL routing (e.g., /resource/{id})
+	router.Use(middleware.URLFormat)
+
+	// Errors as problem details or the usual JSON, by Accept or config
+	router.Use(resp.Negotiate(cfg.HTTPServer.ProblemDetails))
+
+	// Contract of this API, keep it in sync with the routes below.
+	// URLFormat routes /openapi.json here with the extension stripped
+	router.Get("/openapi", openapi.New(log))
+	router.Get("/docs", openapi.NewUI(log, "/openapi.json"))
+	router.Get("/docs/*", openapi.NewAssets("/docs/").ServeHTTP)
+
+	redirectOpts := redirect.Options{
+		DefaultStatus:   cfg.URL.DefaultRedirect,
+		PermanentMaxAge: cfg.URL.PermanentCacheMaxAge,
+		QueryMerge:      redirect.QueryMerge(cfg.URL.QueryMerge),
+
+		UnlockSecret:           []byte(cfg.AppSecret),
+		UnlockTTL:              cfg.URL.PasswordUnlockTTL,
+		PasswordAttempts:       cfg.URL.PasswordAttempts,
+		PasswordAttemptsWindow: cfg.URL.PasswordAttemptsWindow,
+
+		NotYetActiveStatus:  cfg.URL.NotYetActiveStatus,
+		NotYetActiveMessage: cfg.URL.NotYetActiveMessage,
+
+		VariantTTL:       cfg.URL.VariantTTL,
+		PreviewByDefault: cfg.URL.PreviewByDefault,
+
+		DefaultDomain: cfg.URL.DefaultDomain,
+
+		NotFoundURL: cfg.Pages.NotFoundURL,
+		RootURL:     cfg.Pages.RootURL,
+	}
+	var err error
+	if cfg.Pages.NotFoundTemplate != "" {
+		redirectOpts.NotFoundPage, err = template.ParseFiles(cfg.Pages.NotFoundTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("load not found template: %w", err)
+		}
+	}
+	if cfg.Pages.RootTemplate != "" {
+		redirectOpts.RootPage, err = template.ParseFiles(cfg.Pages.RootTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("load root template: %w", err)
+		}
+	}
+	redirectHandler := redirect.New(log, storage, redirectOpts)
+	// Homepage redirect or landing page, custom domains may have their own
+	router.Get("/", redirect.NewRoot(log, storage, redirectOpts))
+	// Browsers get the branded 404 page, API clients JSON
+	router.NotFound(redirect.NewNotFound(log, storage, redirectOpts))
+	router.Get("/{alias}", redirectHandler)
+	// Shows where the link leads instead of redirecting
+	router.Get("/{alias}+", redirect.NewPreview(log, storage, redirectOpts))
+	// Rest of the path is passed to the destination of passthrough links
+	router.Get("/{alias}/*", redirectHandler)
+	// POST submits the password of protected links
+	// and keeps the method for 307/308 links
+	router.Post("/{alias}", redirectHandler)
+	router.Post("/{alias}/*", redirectHandler)
+
+	// One cache for both API prefixes
+	qrHandler := qr.New(log, storage, qr.Options{
+		BaseURL:       cfg.URL.BaseURL,
+		CacheSize:     cfg.URL.QRCacheSize,
+		CacheBytes:    cfg.URL.QRCacheBytes,
+		DefaultDomain: cfg.URL.DefaultDomain,
+	})
+
+	// BasicAuth of the configured user or an API key in X-API-Key
+	userAuth := auth.New(log, "url-shortener", map[string]string{
+		cfg.HTTPServer.User: cfg.HTTPServer.Password,
+	}, storage)
+
+	// Management API, served under /api/v1 and, for existing clients,
+	// without a prefix
+	api := func(r chi.Router) {
+		r.Route("/url", func(r chi.Router) {
+			r.Use(userAuth)
+			// Retries with the same Idempotency-Key don't create another link
+			r.With(idempotency.New(log, storage, idempotency.Options{
+				Window: cfg.URL.IdempotencyWindow,
+			})).Post("/", save.New(log, storage, save.Options{
+				Dedup:         cfg.URL.Dedup,
+				StripTracking: cfg.URL.StripTracking,
+				DefaultDomain: cfg.URL.DefaultDomain,
+			}))
+			r.Get("/", list.New(log, storage, list.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+			r.Get("/{alias}", get.New(log, storage, get.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+			r.Patch("/{alias}", update.New(log, storage, update.Options{
+				StripTracking: cfg.URL.StripTracking,
+				DefaultDomain: cfg.URL.DefaultDomain,
+			}))
+			r.Delete("/{alias}", delete.New(log, storage, delete.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+			r.Get("/{alias}/stats", stats.New(log, storage, stats.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+			r.Put("/{alias}/rules", rules.New(log, storage, rules.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+			r.Get("/{alias}/qr", qrHandler)
+		})
+
+		// Default query params shared by links of a campaign
+		r.Route("/campaign", func(r chi.Router) {
+			r.Use(userAuth)
+			r.Put("/{name}", campaignSave.New(log, storage))
+		})
+
+		// Custom short domains with their own aliases
+		r.Route("/domain", func(r chi.Router) {
+			r.Use(userAuth)
+			r.Put("/{name}", domainSave.New(log, storage, domainSave.Options{
+				DefaultDomain: cfg.URL.DefaultDomain,
+			}))
+		})
+
+		// Link lifecycle events for downstream systems
+		r.Route("/webhook", func(r chi.Router) {
+			r.Use(userAuth)
+			r.Post("/", webhookSave.New(log, storage))
+			r.Get("/", webhookList.New(log, storage))
+			r.Delete("/{id}", webhookDelete.New(log, storage))
+			r.Get("/{id}/deliveries", webhookDeliveries.New(log, storage))
+		})
+
+		// Admin operations and audit log, disabled without admin password
+		if cfg.HTTPServer.AdminPassword != "" {
+			r.Route("/admin", func(r chi.Router) {
+				// API keys act as users, never as the admin
+				r.Use(auth.New(log, "url-shortener-admin", map[string]string{
+					cfg.HTTPServer.AdminUser: cfg.HTTPServer.AdminPassword,
+				}, nil))
+				r.Post("/url/{alias}/restore", restore.New(log, storage, restore.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+				r.Delete("/url/{alias}", purge.New(log, storage, purge.Options{DefaultDomain: cfg.URL.DefaultDomain}))
+				// Who changed what, with a hash chain to detect tampering
+				r.Get("/audit", auditList.New(log, storage))
+				r.Get("/audit/verify", auditVerify.New(log, storage))
+			})
+		}
+	}
+	api(router)
+	router.Route("/api/v1", api)
+
+	return router, nil
+}
+
+// openStorage opens the configured storage, migrating it to the current schema.
+func openStorage(cfg *config.Config) (*sqlite.Storage, error) {
+	return sqlite.New(cfg.StoragePath, sqlite.Options{
+		AliasQuarantine: cfg.URL.AliasQuarantine,
+		ClickThresholds: cfg.Webhooks.ClickThresholds,
+		LegacyOwner:     cfg.HTTPServer.User,
+	})
+}
+
 func setupLogger(env string) *slog.Logger {
 	var log *slog.Logger
 
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/config"
+	auditList "urlshortener/internal/http-server/handlers/audit/list"
+	auditVerify "urlshortener/internal/http-server/handlers/audit/verify"
+	campaignSave "urlshortener/internal/http-server/handlers/campaign/save"
+	domainSave "urlshortener/internal/http-server/handlers/domain/save"
+	"urlshortener/internal/http-server/handlers/openapi"
+	get "urlshortener/internal/http-server/handlers/url/get"
+	list "urlshortener/internal/http-server/handlers/url/list"
+	rules "urlshortener/internal/http-server/handlers/url/rules"
+	save "urlshortener/internal/http-server/handlers/url/save"
+	stats "urlshortener/internal/http-server/handlers/url/stats"
+	update "urlshortener/internal/http-server/handlers/url/update"
+	webhookDeliveries "urlshortener/internal/http-server/handlers/webhook/deliveries"
+	webhookList "urlshortener/internal/http-server/handlers/webhook/list"
+	webhookSave "urlshortener/internal/http-server/handlers/webhook/save"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	"urlshortener/internal/storage/sqlite"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+// schemaTypes maps the component schemas of the OpenAPI document
+// to the Go types encoded or decoded by the handlers.
+var schemaTypes = map[string]any{
+	"Response":            resp.Response{},
+	"Problem":             resp.Problem{},
+	"FieldError":          resp.FieldError{},
+	"SaveRequest":         save.Request{},
+	"SaveResponse":        save.Response{},
+	"Variant":             save.Variant{},
+	"UpdateRequest":       update.Request{},
+	"Link":                get.Link{},
+	"LinkResponse":        get.Response{},
+	"LinkListResponse":    list.Response{},
+	"StatsResponse":       stats.Response{},
+	"RulesRequest":        rules.Request{},
+	"Rule":                rules.Rule{},
+	"CampaignRequest":     campaignSave.Request{},
+	"DomainRequest":       domainSave.Request{},
+	"WebhookRequest":      webhookSave.Request{},
+	"WebhookSaveResponse": webhookSave.Response{},
+	"Webhook":             storage.Webhook{},
+	"WebhookListResponse": webhookList.Response{},
+	"Delivery":            storage.Delivery{},
+	"DeliveriesResponse":  webhookDeliveries.Response{},
+	"AuditEntry":          storage.AuditEntry{},
+	"AuditListResponse":   auditList.Response{},
+	"AuditVerifyResponse": auditVerify.Response{},
+}
+
+// spec is the part of the OpenAPI document the tests look at.
+type spec struct {
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]schema `json:"schemas"`
+	} `json:"components"`
+}
+
+type schema struct {
+	Ref        string            `json:"$ref"`
+	Type       string            `json:"type"`
+	Required   []string          `json:"required"`
+	Properties map[string]schema `json:"properties"`
+	Items      *schema           `json:"items"`
+	Enum       []any             `json:"enum"`
+	AllOf      []schema          `json:"allOf"`
+}
+
+func loadSpec(t *testing.T) spec {
+	t.Helper()
+
+	var s spec
+	require.NoError(t, json.Unmarshal(openapi.Spec, &s))
+
+	return s
+}
+
+func newTestRouter(t *testing.T) chi.Router {
+	t.Helper()
+
+	st, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.Options{})
+	require.NoError(t, err)
+
+	cfg := &config.Config{}
+	cfg.HTTPServer.User = "user"
+	cfg.HTTPServer.Password = "password"
+	// admin routes are only registered with a password
+	cfg.HTTPServer.AdminUser = "admin"
+	cfg.HTTPServer.AdminPassword = "admin"
+
+	router, err := newRouter(slogdiscard.NewDiscardLogger(), cfg, st)
+	require.NoError(t, err)
+
+	return router
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	router := newTestRouter(t)
+
+	var routes []string
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		routes = append(routes, method+" "+specPath(route))
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, ops := range loadSpec(t).Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	slices.Sort(routes)
+	slices.Sort(documented)
+	assert.Equal(t, routes, documented, "routes and the OpenAPI document differ")
+}
+
+// TestReservedAliases keeps the static routes out of the way of links:
+// an alias equal to the first segment of a route would be shadowed.
+func TestReservedAliases(t *testing.T) {
+	router := newTestRouter(t)
+
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		first, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if first != "" && !strings.HasPrefix(first, "{") {
+			assert.True(t, links.Reserved(first), "alias %s of %s %s is not reserved", first, method, route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// specPath converts a chi route to an OpenAPI path.
+func specPath(route string) string {
+	switch {
+	case route == "/openapi":
+		// URLFormat strips the extension before routing
+		return "/openapi.json"
+	case route == "/":
+		return route
+	}
+
+	route = strings.TrimSuffix(route, "/")
+	if strings.HasSuffix(route, "/*") {
+		route = strings.TrimSuffix(route, "*") + "{path}"
+	}
+
+	return route
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	s := loadSpec(t)
+
+	for name := range s.Components.Schemas {
+		assert.Contains(t, schemaTypes, name, "schema %s has no Go type", name)
+	}
+
+	for name, v := range schemaTypes {
+		t.Run(name, func(t *testing.T) {
+			sch, ok := s.Components.Schemas[name]
+			require.True(t, ok, "schema %s is not documented", name)
+
+			props, required := flatten(t, s, sch)
+			fields := jsonFields(reflect.TypeOf(v))
+			// only decoded requests are validated, required fields
+			// of responses are what the handlers always set
+			decoded := slices.ContainsFunc(keys(fields), func(k string) bool {
+				return fields[k].Tag.Get("validate") != ""
+			})
+
+			assert.ElementsMatch(t, keys(fields), keys(props), "properties")
+
+			for jsonName, f := range fields {
+				p, ok := props[jsonName]
+				if !ok {
+					continue
+				}
+				if p.Ref != "" {
+					p = s.Components.Schemas[strings.TrimPrefix(p.Ref, "#/components/schemas/")]
+				}
+
+				rules := validateRules(f)
+				if decoded {
+					assert.Equal(t, slices.Contains(rules, "required"), slices.Contains(required, jsonName),
+						"%s: required", jsonName)
+				}
+				if oneof, ok := ruleParam(rules, "oneof"); ok {
+					enum := p.Enum
+					if p.Items != nil {
+						enum = p.Items.Enum
+					}
+					assert.Equal(t, strings.Fields(oneof), enumStrings(enum), "%s: enum", jsonName)
+				}
+				if typ := jsonType(f.Type); typ != "" {
+					assert.Equal(t, typ, p.Type, "%s: type", jsonName)
+				}
+			}
+		})
+	}
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	router := newTestRouter(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, openapi.Spec, rr.Body.Bytes())
+
+	req = httptest.NewRequest(http.MethodGet, "/docs", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `url: "/openapi.json"`)
+	assert.NotContains(t, rr.Body.String(), "https://")
+
+	// the page loads Swagger UI from the server itself
+	for path, contentType := range map[string]string{
+		"/docs/swagger-ui-bundle.js": "text/javascript; charset=utf-8",
+		"/docs/swagger-ui.css":       "text/css; charset=utf-8",
+	} {
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, contentType, rr.Header().Get("Content-Type"), path)
+		assert.NotEmpty(t, rr.Body.Bytes(), path)
+	}
+
+	for _, path := range []string{"/docs/missing.js", "/docs/"} {
+		req = httptest.NewRequest(http.MethodGet, path, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code, path)
+	}
+}
+
+// flatten merges allOf parts into the properties and required fields.
+func flatten(t *testing.T, s spec, sch schema) (map[string]schema, []string) {
+	t.Helper()
+
+	if sch.Ref != "" {
+		ref, ok := s.Components.Schemas[strings.TrimPrefix(sch.Ref, "#/components/schemas/")]
+		require.True(t, ok, "unknown $ref %s", sch.Ref)
+		return flatten(t, s, ref)
+	}
+
+	props := map[string]schema{}
+	required := slices.Clone(sch.Required)
+	for k, v := range sch.Properties {
+		props[k] = v
+	}
+	for _, part := range sch.AllOf {
+		p, r := flatten(t, s, part)
+		for k, v := range p {
+			props[k] = v
+		}
+		required = append(required, r...)
+	}
+
+	return props, required
+}
+
+// jsonFields returns the fields encoding/json uses, embedded structs flattened.
+func jsonFields(typ reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			for k, v := range jsonFields(f.Type) {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f
+	}
+
+	return fields
+}
+
+// validateRules returns the validator rules of the field itself,
+// the ones after dive apply to the elements.
+func validateRules(f reflect.StructField) []string {
+	tag := f.Tag.Get("validate")
+	if tag == "" {
+		return nil
+	}
+
+	rules := strings.Split(tag, ",")
+	if i := slices.Index(rules, "dive"); i >= 0 {
+		// oneof of the elements is documented as the enum of the items
+		for _, r := range rules[i+1:] {
+			if strings.HasPrefix(r, "oneof=") {
+				return append(rules[:i], r)
+			}
+		}
+		return rules[:i]
+	}
+
+	return rules
+}
+
+func ruleParam(rules []string, name string) (string, bool) {
+	for _, r := range rules {
+		if v, ok := strings.CutPrefix(r, name+"="); ok {
+			return v, true
+		}
+	}
+
+	return "", false
+}
+
+// jsonType returns the OpenAPI type of t, empty for types encoded their own way.
+func jsonType(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.PkgPath() != "" && t.Kind() == reflect.Struct {
+		if t.String() == "time.Time" {
+			return "string"
+		}
+		return "object"
+	}
+	if t == reflect.TypeFor[json.RawMessage]() {
+		return ""
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Slice:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	}
+
+	return ""
+}
+
+func enumStrings(enum []any) []string {
+	out := make([]string, 0, len(enum))
+	for _, v := range enum {
+		out = append(out, fmt.Sprint(v))
+	}
+
+	return out
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+
+	return out
+}
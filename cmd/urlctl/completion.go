@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+var completionCmd = &command{
+	name:    "completion",
+	args:    "<bash|zsh|fish>",
+	summary: "Print the shell completion script, e.g. source <(urlctl completion bash).",
+	setup: func(fs *flag.FlagSet) action {
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+
+			var names []string
+			for _, cmd := range commands {
+				names = append(names, cmd.name)
+			}
+			// commands taking an alias complete the aliases of the default page
+			aliasCmds := "get rm stats qr"
+
+			var script string
+			switch args[0] {
+			case "bash":
+				script = fmt.Sprintf(bashCompletion, strings.Join(names, " "), aliasCmds)
+			case "zsh":
+				script = "autoload -U +X bashcompinit && bashcompinit\n" +
+					fmt.Sprintf(bashCompletion, strings.Join(names, " "), aliasCmds)
+			case "fish":
+				script = fmt.Sprintf(fishCompletion, strings.Join(names, " "), aliasCmds)
+			default:
+				return errUsage
+			}
+
+			_, err := fmt.Fprint(e.stdout, script)
+			return err
+		}
+	},
+}
+
+const bashCompletion = `# urlctl completion for bash
+_urlctl() {
+    local cur prev cmd i
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    case "$prev" in
+        -o) COMPREPLY=($(compgen -W "table json plain" -- "$cur")); return ;;
+        -format) COMPREPLY=($(compgen -W "png svg jsonl csv" -- "$cur")); return ;;
+        -config|-file) COMPREPLY=($(compgen -f -- "$cur")); return ;;
+    esac
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case "${COMP_WORDS[i]}" in
+            -o|-profile|-config) ((i++)) ;;
+            -*) ;;
+            *) cmd="${COMP_WORDS[i]}"; break ;;
+        esac
+    done
+
+    if [[ -z "$cmd" ]]; then
+        COMPREPLY=($(compgen -W "%s help" -- "$cur"))
+        return
+    fi
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "$(urlctl help "$cmd" 2>&1 | sed -n 's/^  \(-[a-z-]*\).*/\1/p')" -- "$cur"))
+        return
+    fi
+    case " %s " in
+        *" $cmd "*) COMPREPLY=($(compgen -W "$(urlctl -o plain ls 2>/dev/null)" -- "$cur")) ;;
+        *) case "$cmd" in
+               import) COMPREPLY=($(compgen -f -- "$cur")) ;;
+               completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+           esac ;;
+    esac
+}
+complete -F _urlctl urlctl
+`
+
+const fishCompletion = `# urlctl completion for fish
+set -l urlctl_commands %s help
+complete -c urlctl -f
+complete -c urlctl -n "not __fish_seen_subcommand_from $urlctl_commands" -a "$urlctl_commands"
+complete -c urlctl -o o -x -a "table json plain" -d "output format"
+complete -c urlctl -o profile -x -d "profile of the config file"
+complete -c urlctl -o config -r -F -d "config file"
+complete -c urlctl -n "__fish_seen_subcommand_from %s" -a "(urlctl -o plain ls 2>/dev/null)"
+complete -c urlctl -n "__fish_seen_subcommand_from import" -F
+complete -c urlctl -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/ilyakaznacheev/cleanenv"
+
+	"urlshortener/lib/api/client"
+)
+
+// Config is the config file of urlctl, e.g. ~/.config/urlctl/config.yml:
+//
+//	default_profile: prod
+//	profiles:
+//	  prod:
+//	    server: https://sho.rt
+//	    user: ops
+//	    password: secret
+//	  staging:
+//	    server: https://staging.sho.rt
+//	    api_key: key
+//
+// URLCTL_SERVER, URLCTL_USER, URLCTL_PASSWORD, URLCTL_TOKEN, URLCTL_API_KEY
+// and URLCTL_DOMAIN override the fields of the selected profile, so urlctl
+// also works without the file.
+type Config struct {
+	// DefaultProfile is used without -profile and URLCTL_PROFILE.
+	DefaultProfile string             `yaml:"default_profile"`
+	Profiles       map[string]Profile `yaml:"profiles"`
+}
+
+// Profile is a server with credentials. One of user and password,
+// token or api_key is used, in the reverse order.
+type Profile struct {
+	// Server is the base URL, e.g. https://sho.rt
+	Server   string `yaml:"server"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	// Token is sent as a bearer token, for a gateway in front of the
+	// server. The server itself doesn't accept tokens.
+	Token  string `yaml:"token"`
+	APIKey string `yaml:"api_key"`
+	// Domain is the custom short domain of commands without -domain.
+	Domain string `yaml:"domain"`
+}
+
+const defaultConfigHint = "~/.config/urlctl/config.yml"
+
+// loadProfile reads the profile selected by name, $URLCTL_PROFILE or the
+// default one from the config file at path, $URLCTL_CONFIG or the default
+// location. A missing file is only an error if it was asked for.
+func loadProfile(path string, name string, getenv func(string) string) (Profile, error) {
+	explicit := true
+	if path == "" {
+		path = getenv("URLCTL_CONFIG")
+	}
+	if path == "" {
+		explicit = false
+		dir, err := os.UserConfigDir()
+		if err == nil {
+			path = filepath.Join(dir, "urlctl", "config.yml")
+		}
+	}
+
+	var cfg Config
+	if path != "" {
+		_, err := os.Stat(path)
+		switch {
+		case err == nil:
+			if err := cleanenv.ReadConfig(path, &cfg); err != nil {
+				return Profile{}, fmt.Errorf("read config %s: %w", path, err)
+			}
+		case !errors.Is(err, os.ErrNotExist) || explicit:
+			return Profile{}, fmt.Errorf("read config: %w", err)
+		}
+	}
+
+	if name == "" {
+		name = getenv("URLCTL_PROFILE")
+	}
+	if name == "" {
+		name = cfg.DefaultProfile
+	}
+
+	prof, ok := cfg.Profiles[name]
+	if name != "" && !ok {
+		return Profile{}, fmt.Errorf("profile %q is not in %s, profiles: %v", name, path, profileNames(cfg))
+	}
+
+	override := func(dst *string, key string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	override(&prof.Server, "URLCTL_SERVER")
+	override(&prof.User, "URLCTL_USER")
+	override(&prof.Password, "URLCTL_PASSWORD")
+	override(&prof.Token, "URLCTL_TOKEN")
+	override(&prof.APIKey, "URLCTL_API_KEY")
+	override(&prof.Domain, "URLCTL_DOMAIN")
+
+	if prof.Server == "" {
+		return Profile{}, errors.New("no server: set server of a profile in " + defaultConfigHint + " or URLCTL_SERVER")
+	}
+
+	return prof, nil
+}
+
+func (p Profile) auth() client.Auth {
+	switch {
+	case p.APIKey != "":
+		return client.APIKey(p.APIKey)
+	case p.Token != "":
+		return client.BearerToken(p.Token)
+	case p.User != "":
+		return client.BasicAuth(p.User, p.Password)
+	}
+
+	return nil
+}
+
+func profileNames(cfg Config) []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
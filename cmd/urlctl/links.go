@@ -0,0 +1,368 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+
+	"urlshortener/lib/api/client"
+)
+
+var shortenCmd = &command{
+	name:    "shorten",
+	args:    "<url>",
+	summary: "Create a short link and print it.",
+	setup: func(fs *flag.FlagSet) action {
+		var (
+			req                     client.SaveRequest
+			activeFrom, activeUntil string
+		)
+		fs.StringVar(&req.Alias, "alias", "", "alias, random if empty")
+		fs.StringVar(&req.Domain, "domain", "", "custom short domain (default domain of the profile)")
+		fs.StringVar(&req.Title, "title", "", "title shown when the link is unfurled")
+		fs.StringVar(&req.Description, "description", "", "description shown when the link is unfurled")
+		fs.StringVar(&req.Campaign, "campaign", "", "campaign sharing default params")
+		fs.StringVar(&req.Password, "password", "", "password visitors have to enter")
+		fs.Int64Var(&req.MaxClicks, "max-clicks", 0, "number of redirects before the link expires")
+		fs.BoolVar(&req.Burn, "burn", false, "delete the link after the first redirect")
+		fs.IntVar(&req.RedirectType, "redirect-type", 0, "301, 302, 307 or 308 (default of the server)")
+		fs.StringVar(&activeFrom, "active-from", "", "RFC 3339 time the link starts redirecting")
+		fs.StringVar(&activeUntil, "active-until", "", "RFC 3339 time the link stops redirecting")
+		fs.StringVar(&req.IdempotencyKey, "idempotency-key", "", "key making a repeated command return the same link")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			req.URL = args[0]
+
+			var err error
+			if req.ActiveFrom, err = parseTime(activeFrom); err != nil {
+				return fmt.Errorf("active-from: %w", err)
+			}
+			if req.ActiveUntil, err = parseTime(activeUntil); err != nil {
+				return fmt.Errorf("active-until: %w", err)
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+			req.Domain = e.domain(req.Domain)
+
+			alias, err := c.Save(ctx, req)
+			if err != nil {
+				return err
+			}
+
+			short := e.shortURL(req.Domain, alias)
+
+			return e.out().print(
+				map[string]string{"alias": alias, "short_url": short, "url": req.URL},
+				[]string{"ALIAS", "SHORT URL", "URL"},
+				[][]string{{alias, short, req.URL}},
+				[]string{short},
+			)
+		}
+	},
+}
+
+var getCmd = &command{
+	name:    "get",
+	args:    "<alias>",
+	summary: "Show a link with its rules and variants.",
+	setup: func(fs *flag.FlagSet) action {
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			link, err := c.Get(ctx, e.domain(*domain), args[0])
+			if err != nil {
+				return err
+			}
+
+			rows := [][]string{
+				{"Alias", link.Alias},
+				{"Short URL", e.shortURL(link.Domain, link.Alias)},
+				{"URL", link.URL},
+				{"Owner", link.Owner},
+				{"Clicks", clicks(link)},
+				{"Created", formatTime(link.CreatedAt)},
+			}
+			optional := [][]string{
+				{"Title", link.Title},
+				{"Description", link.Description},
+				{"Campaign", link.Campaign},
+				{"Active from", formatTime(link.ActiveFrom)},
+				{"Active until", formatTime(link.ActiveUntil)},
+				{"Pending URL", link.PendingURL},
+				{"Fallback URL", link.FallbackURL},
+			}
+			if link.RedirectType != 0 {
+				optional = append(optional, []string{"Redirect", strconv.Itoa(link.RedirectType)})
+			}
+			if link.Protected {
+				optional = append(optional, []string{"Protected", "yes"})
+			}
+			if link.Burn {
+				optional = append(optional, []string{"Burn", "yes"})
+			}
+			for _, row := range optional {
+				if row[1] != "" {
+					rows = append(rows, row)
+				}
+			}
+			for _, k := range sortedKeys(link.Params) {
+				rows = append(rows, []string{"Param " + k, link.Params[k]})
+			}
+			for _, r := range link.Rules {
+				rows = append(rows, []string{"Rule", ruleString(r)})
+			}
+			for _, v := range link.Variants {
+				rows = append(rows, []string{"Variant " + v.Name, fmt.Sprintf("%s (weight %d)", v.URL, v.Weight)})
+			}
+
+			return e.out().print(link, nil, rows, []string{link.URL})
+		}
+	},
+}
+
+var rmCmd = &command{
+	name:    "rm",
+	args:    "<alias>...",
+	summary: "Delete links. Their aliases stay reserved during the quarantine.",
+	setup: func(fs *flag.FlagSet) action {
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) == 0 {
+				return errUsage
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			var (
+				deleted []string
+				failed  int
+			)
+			for _, alias := range args {
+				if err := c.Delete(ctx, e.domain(*domain), alias); err != nil {
+					fmt.Fprintf(e.stderr, "urlctl: %s: %v\n", alias, err)
+					failed++
+					continue
+				}
+				deleted = append(deleted, alias)
+			}
+
+			rows := make([][]string, 0, len(deleted))
+			for _, alias := range deleted {
+				rows = append(rows, []string{alias, "deleted"})
+			}
+			if err := e.out().print(map[string][]string{"deleted": nonNil(deleted)}, nil, rows, deleted); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d links not deleted", failed, len(args))
+			}
+
+			return nil
+		}
+	},
+}
+
+var lsCmd = &command{
+	name:    "ls",
+	summary: "List your links, oldest first.",
+	setup: func(fs *flag.FlagSet) action {
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+		limit := fs.Int("limit", 100, "page size, up to 1000")
+		after := fs.Int64("after", 0, "next_after of the previous page")
+		all := fs.Bool("all", false, "list every page")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			opts := client.ListOptions{Domain: e.domain(*domain), After: *after, Limit: *limit}
+
+			var page client.ListPage
+			err = eachPage(ctx, c, opts, func(p client.ListPage) bool {
+				page.Links = append(page.Links, p.Links...)
+				page.NextAfter = p.NextAfter
+				return *all
+			})
+			if err != nil {
+				return err
+			}
+			if page.Links == nil {
+				page.Links = []client.Link{}
+			}
+
+			rows := make([][]string, 0, len(page.Links))
+			lines := make([]string, 0, len(page.Links))
+			for _, l := range page.Links {
+				rows = append(rows, []string{l.Alias, l.URL, clicks(l), formatTime(l.CreatedAt)})
+				lines = append(lines, l.Alias)
+			}
+
+			if err := e.out().print(page, []string{"ALIAS", "URL", "CLICKS", "CREATED"}, rows, lines); err != nil {
+				return err
+			}
+			if page.NextAfter != 0 && e.output == outputTable {
+				fmt.Fprintf(e.stderr, "more links: urlctl ls -after %d\n", page.NextAfter)
+			}
+
+			return nil
+		}
+	},
+}
+
+var statsCmd = &command{
+	name:    "stats",
+	args:    "<alias>",
+	summary: "Show click statistics of a link.",
+	setup: func(fs *flag.FlagSet) action {
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			stats, err := c.Stats(ctx, e.domain(*domain), args[0])
+			if err != nil {
+				return err
+			}
+
+			rows := [][]string{
+				{"Clicks", strconv.FormatInt(stats.Clicks, 10)},
+				{"First click", formatTime(stats.FirstClickAt)},
+				{"Last click", formatTime(stats.LastClickAt)},
+			}
+			for _, name := range sortedKeys(stats.Variants) {
+				rows = append(rows, []string{"Variant " + name, strconv.FormatInt(stats.Variants[name], 10)})
+			}
+
+			return e.out().print(stats, nil, rows, []string{strconv.FormatInt(stats.Clicks, 10)})
+		}
+	},
+}
+
+// domain returns the domain flag or the default domain of the profile.
+func (e *env) domain(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	return e.prof.Domain
+}
+
+// shortURL is the link on the server of the profile, or on its custom domain.
+func (e *env) shortURL(domain, alias string) string {
+	u, err := url.Parse(e.prof.Server)
+	if err != nil {
+		return alias
+	}
+	if domain != "" {
+		u.Host = domain
+		u.Path = ""
+	}
+
+	return u.JoinPath(alias).String()
+}
+
+// eachPage calls fn with the pages of links from opts on,
+// until fn returns false or the last page.
+func eachPage(ctx context.Context, c *client.Client, opts client.ListOptions, fn func(client.ListPage) bool) error {
+	for {
+		page, err := c.List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		if !fn(page) || page.NextAfter == 0 {
+			return nil
+		}
+		opts.After = page.NextAfter
+	}
+}
+
+func clicks(l client.Link) string {
+	s := strconv.FormatInt(l.Clicks, 10)
+	if l.MaxClicks > 0 {
+		s += "/" + strconv.FormatInt(l.MaxClicks, 10)
+	}
+
+	return s
+}
+
+func ruleString(r client.Rule) string {
+	s := ""
+	for _, cond := range []struct{ k, v string }{
+		{"os", r.OS}, {"device", r.Device}, {"visitor", r.Visitor}, {"language", r.Language},
+	} {
+		if cond.v != "" {
+			s += cond.k + "=" + cond.v + " "
+		}
+	}
+
+	return s + "-> " + r.URL
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.New("expected RFC 3339 time, e.g. 2025-01-02T15:04:05Z")
+	}
+
+	return t, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+
+	return s
+}
@@ -0,0 +1,237 @@
+// Command urlctl manages short links from a terminal.
+//
+//	urlctl [-profile name] [-config file] [-o table|json|plain] <command> [flags] [args]
+//
+// The server and credentials come from a profile of the config file,
+// see config.go. Run "urlctl help" for the commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"urlshortener/lib/api/client"
+)
+
+// errUsage makes run print the usage of the command and exit with 2.
+var errUsage = errors.New("usage")
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	// setup registers the flags of the command and returns its action,
+	// which sees the parsed values.
+	setup func(fs *flag.FlagSet) action
+}
+
+type action func(ctx context.Context, e *env, args []string) error
+
+// commands are listed by help and completion in this order.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		shortenCmd, getCmd, rmCmd, lsCmd, statsCmd, importCmd, exportCmd, qrCmd, completionCmd,
+	}
+}
+
+// env is what commands run with.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+	getenv func(string) string
+
+	configPath string
+	profile    string
+	output     string
+
+	// loaded by client()
+	prof Profile
+	cl   *client.Client
+}
+
+// client returns the client of the selected profile.
+func (e *env) client() (*client.Client, error) {
+	if e.cl != nil {
+		return e.cl, nil
+	}
+
+	prof, err := loadProfile(e.configPath, e.profile, e.getenv)
+	if err != nil {
+		return nil, err
+	}
+
+	cl, err := client.New(prof.Server, client.Options{
+		Auth:      prof.auth(),
+		UserAgent: "urlctl",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.prof, e.cl = prof, cl
+
+	return cl, nil
+}
+
+// out returns the printer of the selected output format.
+func (e *env) out() printer {
+	return printer{w: e.stdout, format: e.output}
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	os.Exit(run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
+
+// run executes the command line and returns the exit code:
+// 0 on success, 1 on errors and 2 on invalid usage.
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr, getenv: getenv}
+
+	root := flag.NewFlagSet("urlctl", flag.ContinueOnError)
+	root.SetOutput(stderr)
+	globalFlags(root, e)
+	root.Usage = func() { usage(stderr) }
+
+	if err := root.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	name := root.Arg(0)
+	if name == "" || name == "help" {
+		if cmd := findCommand(root.Arg(1)); cmd != nil {
+			commandUsage(stderr, cmd)
+		} else {
+			usage(stderr)
+		}
+		if name == "" {
+			return 2
+		}
+		return 0
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "urlctl: unknown command %q\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("urlctl "+cmd.name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	globalFlags(fs, e)
+	act := cmd.setup(fs)
+	fs.Usage = func() { commandUsage(stderr, cmd) }
+
+	cmdArgs, err := parseInterleaved(fs, root.Args()[1:])
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if !slices.Contains(outputFormats, e.output) {
+		fmt.Fprintf(stderr, "urlctl: output must be one of: %s\n", strings.Join(outputFormats, " "))
+		return 2
+	}
+
+	if err := act(ctx, e, cmdArgs); err != nil {
+		if errors.Is(err, errUsage) {
+			commandUsage(stderr, cmd)
+			return 2
+		}
+		printError(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// globalFlags are accepted before and after the command name.
+func globalFlags(fs *flag.FlagSet, e *env) {
+	fs.StringVar(&e.configPath, "config", e.configPath, "config file (default $URLCTL_CONFIG or "+defaultConfigHint+")")
+	fs.StringVar(&e.profile, "profile", e.profile, "profile of the config file (default $URLCTL_PROFILE or default_profile)")
+	if e.output == "" {
+		e.output = outputTable
+	}
+	fs.StringVar(&e.output, "o", e.output, "output format: table, json or plain")
+}
+
+// parseInterleaved parses flags placed before, between and after
+// the positional arguments, which it returns.
+func parseInterleaved(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		if args[0] == "--" {
+			return append(positional, args[1:]...), nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: urlctl [-profile name] [-config file] [-o table|json|plain] <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "urlctl help <command>" for the flags of a command.`)
+}
+
+func commandUsage(w io.Writer, cmd *command) {
+	fmt.Fprintf(w, "Usage: urlctl %s [flags] %s\n\n%s\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	globalFlags(fs, &env{})
+	cmd.setup(fs)
+	fs.PrintDefaults()
+}
+
+// printError prints err with the failed fields of validation errors.
+func printError(w io.Writer, err error) {
+	fmt.Fprintf(w, "urlctl: %v\n", err)
+
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		for _, f := range apiErr.Fields {
+			fmt.Fprintf(w, "  %s: %s\n", f.Field, f.Message)
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	urlDelete "urlshortener/internal/http-server/handlers/url/delete"
+	"urlshortener/internal/http-server/handlers/url/get"
+	"urlshortener/internal/http-server/handlers/url/list"
+	"urlshortener/internal/http-server/handlers/url/qr"
+	"urlshortener/internal/http-server/handlers/url/save"
+	"urlshortener/internal/http-server/handlers/url/stats"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage/sqlite"
+	"urlshortener/lib/api/client"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+// newServer serves the link routes urlctl uses.
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	st, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), sqlite.Options{})
+	require.NoError(t, err)
+
+	log := slogdiscard.NewDiscardLogger()
+
+	router := chi.NewRouter()
+	router.Use(resp.Negotiate(false))
+	router.Route("/api/v1/url", func(r chi.Router) {
+		r.Use(auth.New(log, "url-shortener", map[string]string{"user": "password"}, nil))
+		r.Post("/", save.New(log, st, save.Options{}))
+		r.Get("/", list.New(log, st, list.Options{}))
+		r.Get("/{alias}", get.New(log, st, get.Options{}))
+		r.Delete("/{alias}", urlDelete.New(log, st, urlDelete.Options{}))
+		r.Get("/{alias}/stats", stats.New(log, st, stats.Options{}))
+		r.Get("/{alias}/qr", qr.New(log, st, qr.Options{}))
+	})
+
+	srv := httptest.NewServer(router)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// emptyConfig keeps the tests away from the config file of the user.
+func emptyConfig(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("profiles: {}\n"), 0o600))
+
+	return path
+}
+
+type result struct {
+	code   int
+	stdout string
+	stderr string
+}
+
+// urlctl runs the command line with the environment.
+func urlctl(t *testing.T, environ map[string]string, stdin string, args ...string) result {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, strings.NewReader(stdin), &stdout, &stderr,
+		func(k string) string { return environ[k] })
+
+	return result{code: code, stdout: stdout.String(), stderr: stderr.String()}
+}
+
+func TestCommands(t *testing.T) {
+	srv := newServer(t)
+	dir := t.TempDir()
+
+	environ := map[string]string{
+		"URLCTL_CONFIG":   emptyConfig(t),
+		"URLCTL_SERVER":   srv.URL,
+		"URLCTL_USER":     "user",
+		"URLCTL_PASSWORD": "password",
+	}
+
+	res := urlctl(t, environ, "", "-o", "plain", "shorten", "-alias", "guide", "https://example.com/docs")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, srv.URL+"/guide\n", res.stdout)
+
+	// flags after the arguments, global flags after the command
+	res = urlctl(t, environ, "", "shorten", "https://example.com/blog", "-alias", "blog", "-o", "json")
+	require.Equal(t, 0, res.code, res.stderr)
+	var short map[string]string
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &short))
+	assert.Equal(t, "blog", short["alias"])
+
+	res = urlctl(t, environ, "", "shorten", "-alias", "guide", "https://example.com/other")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "alias_exists")
+
+	res = urlctl(t, environ, "", "shorten", "not a url")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "  url: ")
+
+	res = urlctl(t, environ, "", "get", "guide")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stdout, "https://example.com/docs")
+	assert.Contains(t, res.stdout, "Short URL")
+
+	res = urlctl(t, environ, "", "-o", "json", "get", "guide")
+	require.Equal(t, 0, res.code, res.stderr)
+	var link client.Link
+	require.NoError(t, json.Unmarshal([]byte(res.stdout), &link))
+	assert.Equal(t, "https://example.com/docs", link.URL)
+
+	res = urlctl(t, environ, "", "ls")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Regexp(t, `(?m)^ALIAS\s+URL\s+CLICKS\s+CREATED$`, res.stdout)
+	assert.Regexp(t, `(?m)^guide\s+https://example.com/docs\s+0\s`, res.stdout)
+
+	res = urlctl(t, environ, "", "ls", "-o", "plain", "-limit", "1", "-all")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "guide\nblog\n", res.stdout)
+
+	res = urlctl(t, environ, "", "-o", "plain", "stats", "guide")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "0\n", res.stdout)
+
+	qrFile := filepath.Join(dir, "guide.svg")
+	res = urlctl(t, environ, "", "qr", "-format", "svg", "-file", qrFile, "guide")
+	require.Equal(t, 0, res.code, res.stderr)
+	image, err := os.ReadFile(qrFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(image), "<svg")
+
+	exportFile := filepath.Join(dir, "links.csv")
+	res = urlctl(t, environ, "", "export", "-file", exportFile)
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Contains(t, res.stderr, "exported 2 links")
+
+	res = urlctl(t, environ, "", "export")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Len(t, strings.Split(strings.TrimSpace(res.stdout), "\n"), 2)
+
+	res = urlctl(t, environ, "", "-o", "plain", "rm", "guide", "missing")
+	assert.Equal(t, 1, res.code)
+	assert.Equal(t, "guide\n", res.stdout)
+	assert.Contains(t, res.stderr, "missing: ")
+
+	// guide is back, blog still exists
+	res = urlctl(t, environ, "", "import", exportFile)
+	assert.Equal(t, 1, res.code)
+	assert.Regexp(t, `(?m)^2\s+blog\s+.*alias_exists`, res.stdout)
+	assert.Contains(t, res.stderr, "1 of 2 links not imported")
+
+	res = urlctl(t, environ, `{"url": "https://example.com/new", "alias": "new"}`+"\n", "-o", "plain", "import", "-")
+	require.Equal(t, 0, res.code, res.stderr)
+	assert.Equal(t, "new\n", res.stdout)
+
+	res = urlctl(t, environ, "", "get", "gone")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.stderr, "not_found")
+}
+
+func TestUsage(t *testing.T) {
+	cases := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "No command", wantCode: 2, wantErr: "Commands:"},
+		{name: "Help", args: []string{"help"}, wantCode: 0, wantErr: "Commands:"},
+		{name: "Help of a command", args: []string{"help", "ls"}, wantCode: 0, wantErr: "-all"},
+		{name: "Unknown command", args: []string{"mv"}, wantCode: 2, wantErr: `unknown command "mv"`},
+		{name: "Missing argument", args: []string{"get"}, wantCode: 2, wantErr: "Usage: urlctl get"},
+		{name: "Unknown flag", args: []string{"ls", "-x"}, wantCode: 2, wantErr: "-x"},
+		{name: "Unknown output", args: []string{"-o", "xml", "ls"}, wantCode: 2, wantErr: "output must be"},
+		{name: "No server", args: []string{"ls"}, wantCode: 1, wantErr: "no server"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			res := urlctl(t, map[string]string{"URLCTL_CONFIG": emptyConfig(t)}, "", tc.args...)
+			assert.Equal(t, tc.wantCode, res.code)
+			assert.Contains(t, res.stderr, tc.wantErr)
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+default_profile: prod
+profiles:
+  prod:
+    server: https://sho.rt
+    user: ops
+    password: secret
+  staging:
+    server: https://staging.sho.rt
+    api_key: key
+    domain: go.example.com
+`), 0o600))
+
+	cases := []struct {
+		name    string
+		path    string
+		profile string
+		env     map[string]string
+		want    Profile
+		wantErr string
+	}{
+		{
+			name: "Default profile",
+			path: path,
+			want: Profile{Server: "https://sho.rt", User: "ops", Password: "secret"},
+		},
+		{
+			name:    "Selected profile",
+			path:    path,
+			profile: "staging",
+			want:    Profile{Server: "https://staging.sho.rt", APIKey: "key", Domain: "go.example.com"},
+		},
+		{
+			name: "Profile from env",
+			env:  map[string]string{"URLCTL_CONFIG": path, "URLCTL_PROFILE": "staging", "URLCTL_DOMAIN": "x.example.com"},
+			want: Profile{Server: "https://staging.sho.rt", APIKey: "key", Domain: "x.example.com"},
+		},
+		{
+			name:    "Unknown profile",
+			path:    path,
+			profile: "dev",
+			wantErr: `profile "dev" is not in`,
+		},
+		{
+			name:    "Missing file",
+			path:    path + ".missing",
+			wantErr: "read config",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			prof, err := loadProfile(tc.path, tc.profile, func(k string) string { return tc.env[k] })
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, prof)
+		})
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+
+			res := urlctl(t, nil, "", "completion", shell)
+			require.Equal(t, 0, res.code, res.stderr)
+			for _, cmd := range commands {
+				assert.Contains(t, res.stdout, cmd.name)
+			}
+		})
+	}
+}
+
+func TestProfileAuth(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization")+r.Header.Get(client.APIKeyHeader))
+		w.Write([]byte(`{"status":"OK"}`))
+	}))
+	defer srv.Close()
+
+	for _, env := range []map[string]string{
+		{"URLCTL_USER": "user", "URLCTL_PASSWORD": "password"},
+		{"URLCTL_TOKEN": "token"},
+		{"URLCTL_API_KEY": "key"},
+	} {
+		env["URLCTL_SERVER"] = srv.URL
+		env["URLCTL_CONFIG"] = emptyConfig(t)
+		res := urlctl(t, env, "", "rm", "abc")
+		require.Equal(t, 0, res.code, res.stderr)
+	}
+
+	assert.Equal(t, []string{"Basic dXNlcjpwYXNzd29yZA==", "Bearer token", "key"}, got)
+}
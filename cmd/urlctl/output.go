@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputPlain = "plain"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputPlain}
+
+// printer writes the result of a command in the selected format:
+// aligned columns for people, JSON for programs and plain values,
+// one per line, for shell pipelines.
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// print writes v as JSON, the rows as a table or the lines as is.
+func (p printer) print(v any, header []string, rows [][]string, lines []string) error {
+	switch p.format {
+	case outputJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputPlain:
+		for _, l := range lines {
+			if _, err := fmt.Fprintln(p.w, l); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(tw, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// formatTime is empty for zero times, the local time otherwise.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Local().Format(time.DateTime)
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+
+	"urlshortener/lib/api/client"
+)
+
+var qrCmd = &command{
+	name:    "qr",
+	args:    "<alias>",
+	summary: "Save the QR code of a link.",
+	setup: func(fs *flag.FlagSet) action {
+		var opts client.QROptions
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+		file := fs.String("file", "", "file to write, - for stdout (default <alias>.<format>)")
+		fs.StringVar(&opts.Format, "format", "png", "png or svg")
+		fs.IntVar(&opts.Size, "size", 0, "width and height in pixels, 32-2048 (default of the server)")
+		fs.StringVar(&opts.Level, "level", "", "error correction level: L, M, Q or H")
+		fs.IntVar(&opts.Margin, "margin", 0, "quiet zone in modules, -1 for none (default of the server)")
+		fs.StringVar(&opts.FG, "fg", "", "foreground hex color")
+		fs.StringVar(&opts.BG, "bg", "", "background hex color")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+			alias := args[0]
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			image, err := c.QR(ctx, e.domain(*domain), alias, opts)
+			if err != nil {
+				return err
+			}
+
+			if *file == "-" {
+				_, err := e.stdout.Write(image)
+				return err
+			}
+			if *file == "" {
+				*file = alias + "." + opts.Format
+			}
+			if err := os.WriteFile(*file, image, 0o644); err != nil {
+				return err
+			}
+
+			return e.out().print(map[string]string{"file": *file}, nil, [][]string{{"Saved", *file}}, []string{*file})
+		}
+	},
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"urlshortener/lib/api/client"
+)
+
+const (
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+)
+
+// csvColumns are written by export and read by import, which ignores
+// the columns it can't set (clicks, created_at) and accepts any order.
+var csvColumns = []string{"alias", "domain", "url", "title", "description", "campaign", "clicks", "created_at"}
+
+// importBatch is the number of links sent to Batch at once.
+const importBatch = 100
+
+var importCmd = &command{
+	name:    "import",
+	args:    "<file|->",
+	summary: "Create links from a JSON lines or CSV file, e.g. written by export.",
+	setup: func(fs *flag.FlagSet) action {
+		format := fs.String("format", "", "jsonl or csv (default by the file extension, jsonl for stdin)")
+		domain := fs.String("domain", "", "custom short domain of rows without one (default domain of the profile)")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 1 {
+				return errUsage
+			}
+
+			r := e.stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				r = f
+			}
+
+			reqs, err := readLinks(r, fileFormat(*format, args[0]))
+			if err != nil {
+				return err
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+			for i := range reqs {
+				if reqs[i].Domain == "" {
+					reqs[i].Domain = e.domain(*domain)
+				}
+			}
+
+			type result struct {
+				Line  int    `json:"line"`
+				Alias string `json:"alias,omitempty"`
+				Error string `json:"error,omitempty"`
+			}
+
+			var (
+				results []result
+				rows    [][]string
+				lines   []string
+				failed  int
+			)
+			for start := 0; start < len(reqs); start += importBatch {
+				batch := reqs[start:min(start+importBatch, len(reqs))]
+				for i, res := range c.Batch(ctx, batch) {
+					r := result{Line: start + i + 1, Alias: res.Alias}
+					status := "created"
+					if res.Err != nil {
+						r.Alias, r.Error, status = batch[i].Alias, res.Err.Error(), res.Err.Error()
+						failed++
+					} else {
+						lines = append(lines, res.Alias)
+					}
+					results = append(results, r)
+					rows = append(rows, []string{strconv.Itoa(r.Line), r.Alias, status})
+				}
+				if ctx.Err() != nil {
+					break
+				}
+			}
+
+			if err := e.out().print(nonNil(results), []string{"LINE", "ALIAS", "RESULT"}, rows, lines); err != nil {
+				return err
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d links not imported", failed, len(reqs))
+			}
+
+			return nil
+		}
+	},
+}
+
+var exportCmd = &command{
+	name:    "export",
+	summary: "Write your links as JSON lines or CSV. Rules, variants and passwords are not exported.",
+	setup: func(fs *flag.FlagSet) action {
+		format := fs.String("format", "", "jsonl or csv (default by the file extension, jsonl for stdout)")
+		domain := fs.String("domain", "", "custom short domain (default domain of the profile)")
+		file := fs.String("file", "-", "file to write, - for stdout")
+
+		return func(ctx context.Context, e *env, args []string) error {
+			if len(args) != 0 {
+				return errUsage
+			}
+
+			c, err := e.client()
+			if err != nil {
+				return err
+			}
+
+			w := e.stdout
+			if *file != "-" {
+				f, err := os.Create(*file)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			lw, err := newLinkWriter(w, fileFormat(*format, *file))
+			if err != nil {
+				return err
+			}
+
+			var (
+				n        int
+				writeErr error
+			)
+			err = eachPage(ctx, c, client.ListOptions{Domain: e.domain(*domain), Limit: 1000}, func(p client.ListPage) bool {
+				for _, l := range p.Links {
+					if writeErr = lw.write(l); writeErr != nil {
+						return false
+					}
+					n++
+				}
+				return true
+			})
+			if err != nil {
+				return err
+			}
+			if writeErr != nil {
+				return writeErr
+			}
+			if err := lw.flush(); err != nil {
+				return err
+			}
+
+			if *file != "-" {
+				fmt.Fprintf(e.stderr, "exported %d links to %s\n", n, *file)
+			}
+
+			return nil
+		}
+	},
+}
+
+// fileFormat is the format flag or the format of the file extension.
+func fileFormat(flagValue, file string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if strings.EqualFold(filepath.Ext(file), ".csv") {
+		return formatCSV
+	}
+
+	return formatJSONL
+}
+
+// readLinks reads one link per JSON line or CSV row.
+func readLinks(r io.Reader, format string) ([]client.SaveRequest, error) {
+	var reqs []client.SaveRequest
+
+	switch format {
+	case formatJSONL:
+		sc := bufio.NewScanner(r)
+		sc.Buffer(make([]byte, 0, 64<<10), 1<<20)
+		for line := 1; sc.Scan(); line++ {
+			if strings.TrimSpace(sc.Text()) == "" {
+				continue
+			}
+			var req client.SaveRequest
+			if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			reqs = append(reqs, req)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	case formatCSV:
+		cr := csv.NewReader(r)
+		header, err := cr.Read()
+		if err != nil {
+			return nil, fmt.Errorf("read csv header: %w", err)
+		}
+		col := map[string]int{}
+		for i, name := range header {
+			col[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+		if _, ok := col["url"]; !ok {
+			return nil, errors.New("csv has no url column")
+		}
+		get := func(row []string, name string) string {
+			if i, ok := col[name]; ok && i < len(row) {
+				return row[i]
+			}
+			return ""
+		}
+		for {
+			row, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			reqs = append(reqs, client.SaveRequest{
+				URL:         get(row, "url"),
+				Alias:       get(row, "alias"),
+				Domain:      get(row, "domain"),
+				Title:       get(row, "title"),
+				Description: get(row, "description"),
+				Campaign:    get(row, "campaign"),
+			})
+		}
+	default:
+		return nil, fmt.Errorf("format must be %s or %s", formatJSONL, formatCSV)
+	}
+
+	return reqs, nil
+}
+
+// linkWriter writes links in an export format.
+type linkWriter struct {
+	enc *json.Encoder
+	csv *csv.Writer
+}
+
+func newLinkWriter(w io.Writer, format string) (*linkWriter, error) {
+	switch format {
+	case formatJSONL:
+		return &linkWriter{enc: json.NewEncoder(w)}, nil
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &linkWriter{csv: cw}, nil
+	}
+
+	return nil, fmt.Errorf("format must be %s or %s", formatJSONL, formatCSV)
+}
+
+func (lw *linkWriter) write(l client.Link) error {
+	if lw.enc != nil {
+		return lw.enc.Encode(l)
+	}
+
+	created := ""
+	if !l.CreatedAt.IsZero() {
+		created = l.CreatedAt.UTC().Format(time.RFC3339)
+	}
+
+	return lw.csv.Write([]string{l.Alias, l.Domain, l.URL, l.Title, l.Description, l.Campaign,
+		strconv.FormatInt(l.Clicks, 10), created})
+}
+
+func (lw *linkWriter) flush() error {
+	if lw.csv == nil {
+		return nil
+	}
+	lw.csv.Flush()
+
+	return lw.csv.Error()
+}
@@ -0,0 +1,107 @@
+package grpcapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+
+	grpcshortener "urlshortener/internal/grpc/shortener"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+type App struct {
+	log        *slog.Logger
+	gRPCServer *grpc.Server
+	address    string
+}
+
+// Options configures the gRPC server.
+type Options struct {
+	Address string
+	// Reflection lets grpcurl and similar tools discover the API.
+	Reflection bool
+	// Credentials are user/password pairs accepted with Basic auth,
+	// API keys of the storage are accepted too.
+	Credentials map[string]string
+	Shortener   grpcshortener.Options
+}
+
+// Storage is what the gRPC API needs from the storage: links and API keys.
+type Storage interface {
+	grpcshortener.Storage
+	grpcshortener.KeyStore
+}
+
+func New(log *slog.Logger, storage Storage, opts Options) *App {
+	recoveryOpts := []recovery.Option{
+		recovery.WithRecoveryHandler(func(p any) (err error) {
+			log.Error("recovered from panic", slog.Any("panic", p))
+
+			return status.Error(codes.Internal, "internal error")
+		}),
+	}
+
+	// payloads are not logged, save requests carry link passwords
+	logOpts := []grpclog.Option{
+		grpclog.WithLogOnEvents(grpclog.StartCall, grpclog.FinishCall),
+	}
+
+	gRPCServer := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		recovery.UnaryServerInterceptor(recoveryOpts...),
+		grpclog.UnaryServerInterceptor(InterceptorLogger(log), logOpts...),
+		auth.UnaryServerInterceptor(grpcshortener.AuthFunc(opts.Credentials, storage)),
+	))
+
+	grpcshortener.Register(gRPCServer, log, storage, opts.Shortener)
+
+	if opts.Reflection {
+		reflection.Register(gRPCServer)
+	}
+
+	return &App{
+		log:        log,
+		gRPCServer: gRPCServer,
+		address:    opts.Address,
+	}
+}
+
+// Run serves until Stop is called.
+func (a *App) Run() error {
+	const op = "grpcapp.Run"
+
+	l, err := net.Listen("tcp", a.address)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	a.log.Info("grpc server started", slog.String("address", l.Addr().String()))
+
+	if err := a.gRPCServer.Serve(l); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Stop finishes the calls in flight and stops the server.
+func (a *App) Stop() {
+	const op = "grpcapp.Stop"
+
+	a.log.With(slog.String("op", op)).Info("stopping grpc server", slog.String("address", a.address))
+
+	a.gRPCServer.GracefulStop()
+}
+
+func InterceptorLogger(l *slog.Logger) grpclog.Logger {
+	return grpclog.LoggerFunc(func(ctx context.Context, lvl grpclog.Level, msg string, fields ...any) {
+		l.Log(ctx, slog.Level(lvl), msg, fields...)
+	})
+}
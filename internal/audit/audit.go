@@ -0,0 +1,111 @@
+// Package audit builds audit log entries for mutations made over HTTP.
+package audit
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+)
+
+// NewEntry describes a mutation made by the request: the authenticated caller is
+// the actor. before and after are snapshots marshaled to JSON, nil if
+// there is none.
+func NewEntry(r *http.Request, action, entity, domain, alias string, before, after any) storage.AuditEntry {
+	actor := auth.Principal(r.Context())
+
+	return storage.AuditEntry{
+		Actor:     actor,
+		Action:    action,
+		Entity:    entity,
+		Domain:    domain,
+		Alias:     alias,
+		Before:    Snapshot(before),
+		After:     Snapshot(after),
+		IP:        clientIP(r),
+		RequestID: middleware.GetReqID(r.Context()),
+	}
+}
+
+// Snapshot marshals v for Before and After of an entry, nil if v is nil.
+func Snapshot(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil || string(b) == "null" {
+		return nil
+	}
+
+	return b
+}
+
+// Link is the snapshot of a link kept in the audit log. Secrets are
+// replaced with flags.
+type Link struct {
+	URL          string            `json:"url"`
+	Owner        string            `json:"owner,omitempty"`
+	RedirectType int               `json:"redirect_type,omitempty"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Campaign     string            `json:"campaign,omitempty"`
+	Protected    bool              `json:"protected,omitempty"`
+	MaxClicks    int64             `json:"max_clicks,omitempty"`
+	Burn         bool              `json:"burn,omitempty"`
+	ActiveFrom   *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil  *time.Time        `json:"active_until,omitempty"`
+	PendingURL   string            `json:"pending_url,omitempty"`
+	FallbackURL  string            `json:"fallback_url,omitempty"`
+	Rules        []storage.Rule    `json:"rules,omitempty"`
+	Variants     []storage.Variant `json:"variants,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Image        string            `json:"image,omitempty"`
+}
+
+// NewLink returns the audit snapshot of u.
+func NewLink(u storage.URL) Link {
+	return Link{
+		URL:          u.URL,
+		Owner:        u.Owner,
+		RedirectType: u.RedirectType,
+		Passthrough:  u.Passthrough,
+		Params:       u.Params,
+		Campaign:     u.Campaign,
+		Protected:    u.PasswordHash != "",
+		MaxClicks:    u.MaxClicks,
+		Burn:         u.Burn,
+		ActiveFrom:   timePtr(u.ActiveFrom),
+		ActiveUntil:  timePtr(u.ActiveUntil),
+		PendingURL:   u.PendingURL,
+		FallbackURL:  u.FallbackURL,
+		Rules:        u.Rules,
+		Variants:     u.Variants,
+		Title:        u.Title,
+		Description:  u.Description,
+		Image:        u.Image,
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
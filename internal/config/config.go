@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
@@ -12,8 +15,12 @@ type Config struct {
 	Env         string `yaml:"env" env-default:"local"`
 	StoragePath string `yaml:"storage_path" env-requered:"True"`
 	HTTPServer  `yaml:"http_server"`
-	Clients     ClientsConfig `yaml:"clients"`
-	AppSecret   string        `yaml:"app_secret" env-required:"true" env:"APP_SECRET"`
+	Clients     ClientsConfig  `yaml:"clients"`
+	AppSecret   string         `yaml:"app_secret" env-required:"true" env:"APP_SECRET"`
+	URL         URLConfig      `yaml:"url"`
+	Pages       PagesConfig    `yaml:"pages"`
+	Webhooks    WebhooksConfig `yaml:"webhooks"`
+	GRPC        GRPCConfig     `yaml:"grpc"`
 }
 
 type HTTPServer struct {
@@ -22,6 +29,95 @@ type HTTPServer struct {
 	Idletimeout time.Duration `yaml:"idle_timeout " env-default:"60s"`
 	User        string        `yaml:"user" env-requered:"true"`
 	Password    string        `yaml:"password" env-requered:"true" env:"HTTP_SERVER_PASSWORD"`
+	// AdminUser and AdminPassword protect /admin, it is disabled if AdminPassword is empty
+	AdminUser     string `yaml:"admin_user" env-default:"admin"`
+	AdminPassword string `yaml:"admin_password" env:"HTTP_SERVER_ADMIN_PASSWORD"`
+	// ProblemDetails makes errors RFC 7807 problem details (application/problem+json)
+	// for clients that don't ask for a format with Accept
+	ProblemDetails bool `yaml:"problem_details" env:"HTTP_SERVER_PROBLEM_DETAILS"`
+}
+
+// PagesConfig is what browsers get on unknown aliases and on the root.
+// Custom domains may override the URLs. API clients always get JSON 404.
+type PagesConfig struct {
+	// NotFoundURL is where browsers are redirected on unknown aliases
+	NotFoundURL string `yaml:"not_found_url"`
+	// NotFoundTemplate is an html/template file rendered if NotFoundURL is empty,
+	// a built-in page is used if both are empty
+	NotFoundTemplate string `yaml:"not_found_template"`
+	// RootURL is where / redirects (homepage)
+	RootURL string `yaml:"root_url"`
+	// RootTemplate is a landing page rendered on / if RootURL is empty, 404 if both are empty
+	RootTemplate string `yaml:"root_template"`
+}
+
+type URLConfig struct {
+	// Dedup returns the existing alias instead of creating a new one
+	// when the same user shortens the same (normalized) destination.
+	// Links with any other setting (alias, password, params, ...) are always new
+	Dedup bool `yaml:"dedup" env-default:"false"`
+	// StripTracking ignores utm_*, fbclid, gclid, ... when comparing destinations
+	StripTracking bool `yaml:"strip_tracking" env-default:"false"`
+	// DefaultRedirect is the status for links without their own redirect type: 301, 302, 307 or 308
+	DefaultRedirect int `yaml:"default_redirect" env-default:"302"`
+	// PermanentCacheMaxAge is how long browsers may cache 301/308 redirects
+	PermanentCacheMaxAge time.Duration `yaml:"permanent_cache_max_age" env-default:"24h"`
+	// QueryMerge resolves query keys present both in the request and in the
+	// destination of a passthrough link: override, keep or append
+	QueryMerge string `yaml:"query_merge" env-default:"override"`
+	// PasswordUnlockTTL is how long a correct link password is remembered (signed cookie)
+	PasswordUnlockTTL time.Duration `yaml:"password_unlock_ttl" env-default:"24h"`
+	// PasswordAttempts wrong passwords are allowed per link and IP within PasswordAttemptsWindow
+	PasswordAttempts       int           `yaml:"password_attempts" env-default:"5"`
+	PasswordAttemptsWindow time.Duration `yaml:"password_attempts_window" env-default:"15m"`
+	// NotYetActive* is returned before the activation window of a link without pending_url
+	NotYetActiveStatus  int    `yaml:"not_yet_active_status" env-default:"404"`
+	NotYetActiveMessage string `yaml:"not_yet_active_message" env-default:"link is not active yet"`
+	// VariantTTL is how long a visitor stays on the same A/B variant (cookie)
+	VariantTTL time.Duration `yaml:"variant_ttl" env-default:"720h"`
+	// PreviewByDefault shows the preview page (/{alias}+) instead of redirecting
+	PreviewByDefault bool `yaml:"preview_by_default" env-default:"false"`
+	// BaseURL is the public address short links are served on, e.g. https://sho.rt.
+	// Taken from the request if empty
+	BaseURL string `yaml:"base_url" env:"BASE_URL"`
+	// QRCacheSize is the number of rendered QR codes kept in memory
+	QRCacheSize int `yaml:"qr_cache_size" env-default:"1024"`
+	// QRCacheBytes bounds the total size of the rendered QR codes kept in memory
+	QRCacheBytes int64 `yaml:"qr_cache_bytes" env-default:"33554432"`
+	// DefaultDomain is the main short domain. Requests to it and to hosts nobody
+	// registered use the default alias namespace, custom domains have their own
+	DefaultDomain string `yaml:"default_domain" env:"DEFAULT_DOMAIN"`
+	// AliasQuarantine is how long the alias of a deleted link can't be reused,
+	// the link returns 410 Gone meanwhile
+	AliasQuarantine time.Duration `yaml:"alias_quarantine" env-default:"720h"`
+	// IdempotencyWindow is how long responses to POST /url with an
+	// Idempotency-Key are kept and replayed to retries
+	IdempotencyWindow time.Duration `yaml:"idempotency_window" env-default:"24h"`
+}
+
+// WebhooksConfig configures delivery of link events to webhooks.
+type WebhooksConfig struct {
+	// ClickThresholds are click counts that send link.clicks_threshold
+	ClickThresholds []int64 `yaml:"click_thresholds" env-default:"100,1000,10000"`
+	// PollInterval is how often the outbox is checked for due deliveries
+	PollInterval time.Duration `yaml:"poll_interval" env-default:"5s"`
+	// Timeout limits a single delivery attempt
+	Timeout time.Duration `yaml:"timeout" env-default:"10s"`
+	// MaxAttempts failed attempts make the delivery dead, retries are
+	// Backoff apart, doubling every time up to MaxBackoff
+	MaxAttempts int           `yaml:"max_attempts" env-default:"8"`
+	Backoff     time.Duration `yaml:"backoff" env-default:"30s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env-default:"6h"`
+	// AllowPrivate lets webhooks reach loopback, link-local and private addresses
+	AllowPrivate bool `yaml:"allow_private" env-default:"false"`
+}
+
+// GRPCConfig configures the gRPC API, it takes the HTTP API credentials.
+type GRPCConfig struct {
+	// Address to listen on, the gRPC API is disabled if it is empty
+	Address string `yaml:"address" env:"GRPC_ADDRESS"`
+	// Reflection lets grpcurl and similar tools discover the API
+	Reflection bool `yaml:"reflection" env-default:"true"`
 }
 
 type Client struct {
@@ -34,13 +130,36 @@ type ClientsConfig struct {
 	SSO Client `yaml:"sso"`
 }
 
-// MustLoad loads configuration from file into Config struct.
+// MustLoad loads configuration from the file in CONFIG_PATH into Config struct.
 // Panics if config cannot be loaded (missing path, invalid file or parsing error).
 func MustLoad() *Config {
-	// Get config path from environment variable (required)
-	configPath := os.Getenv("CONFIG_PATH")
+	return MustLoadPath("")
+}
+
+// QueryMerges are the values of URLConfig.QueryMerge, see redirect.QueryMerge.
+var QueryMerges = []string{"override", "keep", "append"}
+
+// MustLoadPath is MustLoad with the path of the config file given by
+// the --config flag, CONFIG_PATH is used if it is empty.
+// Exits if the config is not valid, see Validate.
+func MustLoadPath(configPath string) *Config {
+	cfg := MustReadPath(configPath)
+
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid config: %s", err)
+	}
+
+	return cfg
+}
+
+// MustReadPath reads the config file like MustLoadPath without
+// validating it, for check-config to report all the problems.
+func MustReadPath(configPath string) *Config {
 	if configPath == "" {
-		log.Fatal("CONFIG_PATH is not set")
+		configPath = os.Getenv("CONFIG_PATH")
+	}
+	if configPath == "" {
+		log.Fatal("CONFIG_PATH is not set and --config is not given")
 	}
 
 	// Verify config file exists before attempting to read
@@ -56,5 +175,14 @@ func MustLoad() *Config {
 	}
 
 	return &cfg
+}
+
+// Validate rejects values the server would otherwise silently replace
+// with defaults.
+func (c *Config) Validate() error {
+	if !slices.Contains(QueryMerges, c.URL.QueryMerge) {
+		return fmt.Errorf("url.query_merge must be one of: %s", strings.Join(QueryMerges, " "))
+	}
 
+	return nil
 }
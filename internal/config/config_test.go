@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for _, mode := range QueryMerges {
+		cfg := &Config{URL: URLConfig{QueryMerge: mode}}
+		assert.NoError(t, cfg.Validate(), mode)
+	}
+
+	for _, mode := range []string{"", "replace", "Override"} {
+		cfg := &Config{URL: URLConfig{QueryMerge: mode}}
+		assert.ErrorContains(t, cfg.Validate(), "url.query_merge", mode)
+	}
+}
+
+func TestMustReadPath_QueryMergeDefault(t *testing.T) {
+	t.Setenv("APP_SECRET", "secret")
+
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte("storage_path: ./storage.db\n"), 0o600))
+
+	cfg := MustReadPath(path)
+	assert.Equal(t, "override", cfg.URL.QueryMerge)
+	assert.NoError(t, cfg.Validate())
+}
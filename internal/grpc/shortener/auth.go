@@ -0,0 +1,76 @@
+package shortener
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"urlshortener/internal/storage"
+	"urlshortener/lib/apikey"
+)
+
+type userKey struct{}
+
+// APIKeyMetadata carries API keys, the same keys the HTTP API takes in X-API-Key.
+const APIKeyMetadata = "x-api-key"
+
+// KeyStore finds API keys by their hash.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=KeyStore
+type KeyStore interface {
+	GetAPIKey(hash string) (storage.APIKey, error)
+}
+
+// AuthFunc checks "x-api-key" metadata against the stored keys or
+// "authorization: Basic ..." against the credentials, the same ones
+// the HTTP API accepts. Keys act as the user they were created for.
+func AuthFunc(credentials map[string]string, keys KeyStore) auth.AuthFunc {
+	return func(ctx context.Context) (context.Context, error) {
+		if values := metadata.ValueFromIncomingContext(ctx, APIKeyMetadata); len(values) > 0 && keys != nil {
+			k, err := keys.GetAPIKey(apikey.Hash(values[0]))
+			if errors.Is(err, storage.ErrAPIKeyNotFound) {
+				return nil, status.Error(codes.Unauthenticated, "invalid api key")
+			}
+			if err != nil {
+				return nil, status.Error(codes.Internal, "internal error")
+			}
+
+			return context.WithValue(ctx, userKey{}, k.Owner), nil
+		}
+
+		token, err := auth.AuthFromMD(ctx, "basic")
+		if err != nil {
+			return nil, err
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		user, password, ok := strings.Cut(string(raw), ":")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		expected, ok := credentials[user]
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+
+		return context.WithValue(ctx, userKey{}, user), nil
+	}
+}
+
+// userFromContext returns the authenticated caller.
+func userFromContext(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+
+	return user
+}
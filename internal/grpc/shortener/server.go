@@ -0,0 +1,359 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	shortenerv1 "urlshortener/gen/go/shortener"
+	"urlshortener/internal/audit"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/urlnorm"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// Storage is what the gRPC API needs from the storage,
+// the same methods the HTTP handlers use.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=Storage
+type Storage interface {
+	SaveURL(u storage.URL, e storage.AuditEntry) (int64, error)
+	GetAliasByNormalized(domain string, normalized string, owner string) (string, error)
+	GetDomain(name string) (storage.Domain, error)
+	GetURL(domain string, alias string) (storage.URL, error)
+	DeleteURL(domain string, alias string, e storage.AuditEntry) error
+	ListURLs(owner string, domain string, afterID int64, limit int) ([]storage.URL, error)
+	GetStats(domain string, alias string) (storage.Stats, error)
+}
+
+// Options configures the gRPC API, they are the HTTP save handler ones.
+type Options = links.Options
+
+type serverAPI struct {
+	shortenerv1.UnimplementedShortenerServer
+	log     *slog.Logger
+	storage Storage
+	links   *links.Service
+	opts    Options
+}
+
+// Register adds the Shortener service to the gRPC server.
+func Register(gRPCServer *grpc.Server, log *slog.Logger, storage Storage, opts Options) {
+	shortenerv1.RegisterShortenerServer(gRPCServer, &serverAPI{
+		log:     log,
+		storage: storage,
+		links:   links.New(log, storage, opts),
+		opts:    opts,
+	})
+}
+
+func (s *serverAPI) Save(ctx context.Context, req *shortenerv1.SaveRequest) (*shortenerv1.SaveResponse, error) {
+	const op = "grpc.shortener.Save"
+
+	log := s.log.With(slog.String("op", op))
+
+	if err := validateSave(req); err != nil {
+		return nil, err
+	}
+
+	link := storage.URL{
+		Domain:       req.GetDomain(),
+		Alias:        req.GetAlias(),
+		URL:          req.GetUrl(),
+		Owner:        userFromContext(ctx),
+		RedirectType: int(req.GetRedirectType()),
+		Passthrough:  req.GetPassthrough(),
+		Params:       req.GetParams(),
+		Campaign:     req.GetCampaign(),
+		MaxClicks:    req.GetMaxClicks(),
+		Burn:         req.GetBurn(),
+		ActiveFrom:   fromTimestamp(req.GetActiveFrom()),
+		ActiveUntil:  fromTimestamp(req.GetActiveUntil()),
+		PendingURL:   req.GetPendingUrl(),
+		FallbackURL:  req.GetFallbackUrl(),
+		Title:        req.GetTitle(),
+		Description:  req.GetDescription(),
+		Image:        req.GetImage(),
+	}
+
+	alias, err := s.links.Save(link, req.GetPassword(), s.auditEntry(ctx))
+	switch {
+	case errors.Is(err, links.ErrInvalidURL):
+		return nil, status.Error(codes.InvalidArgument, "invalid url")
+	case errors.Is(err, links.ErrReservedAlias):
+		return nil, status.Error(codes.InvalidArgument, "alias is reserved")
+	case errors.Is(err, links.ErrDomainNotRegistered):
+		return nil, status.Error(codes.FailedPrecondition, "domain is not registered")
+	case errors.Is(err, links.ErrDomainForbidden):
+		return nil, status.Error(codes.PermissionDenied, "domain belongs to another user")
+	case errors.Is(err, storage.ErrURLExists):
+		return nil, status.Error(codes.AlreadyExists, "url already exists")
+	case err != nil:
+		log.Error("failed to add url", slog.Any("error", err))
+		return nil, status.Error(codes.Internal, "failed to add url")
+	}
+
+	return &shortenerv1.SaveResponse{Alias: alias}, nil
+}
+
+func (s *serverAPI) Get(ctx context.Context, req *shortenerv1.GetRequest) (*shortenerv1.GetResponse, error) {
+	const op = "grpc.shortener.Get"
+
+	log := s.log.With(slog.String("op", op))
+
+	if req.GetAlias() == "" {
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	link, err := s.ownLink(ctx, s.domain(req.GetDomain()), req.GetAlias())
+	if err != nil {
+		return nil, s.lookupError(log, err)
+	}
+
+	return &shortenerv1.GetResponse{Link: toLink(link)}, nil
+}
+
+func (s *serverAPI) Delete(ctx context.Context, req *shortenerv1.DeleteRequest) (*shortenerv1.DeleteResponse, error) {
+	const op = "grpc.shortener.Delete"
+
+	log := s.log.With(slog.String("op", op))
+
+	if req.GetAlias() == "" {
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	domain := s.domain(req.GetDomain())
+
+	// the deleted link goes to the audit log
+	before, err := s.ownLink(ctx, domain, req.GetAlias())
+	if err == nil {
+		e := s.auditEntry(ctx)
+		e.Action = storage.AuditDelete
+		e.Entity = storage.AuditEntityURL
+		e.Domain = domain
+		e.Alias = req.GetAlias()
+		e.Before = audit.Snapshot(audit.NewLink(before))
+		err = s.storage.DeleteURL(domain, req.GetAlias(), e)
+	}
+	if err != nil {
+		return nil, s.lookupError(log, err)
+	}
+
+	log.Info("deleted alias", slog.String("alias", req.GetAlias()))
+
+	return &shortenerv1.DeleteResponse{}, nil
+}
+
+func (s *serverAPI) List(ctx context.Context, req *shortenerv1.ListRequest) (*shortenerv1.ListResponse, error) {
+	const op = "grpc.shortener.List"
+
+	log := s.log.With(slog.String("op", op))
+
+	size := int(req.GetPageSize())
+	if size < 0 || size > maxPageSize {
+		return nil, status.Error(codes.InvalidArgument, "invalid page_size")
+	}
+	if size == 0 {
+		size = defaultPageSize
+	}
+
+	var afterID int64
+	if req.GetPageToken() != "" {
+		var err error
+		afterID, err = strconv.ParseInt(req.GetPageToken(), 10, 64)
+		if err != nil || afterID < 1 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page_token")
+		}
+	}
+
+	links, err := s.storage.ListURLs(userFromContext(ctx), s.domain(req.GetDomain()), afterID, size)
+	if err != nil {
+		log.Error("failed to list urls", slog.Any("error", err))
+		return nil, status.Error(codes.Internal, "failed to list urls")
+	}
+
+	resp := &shortenerv1.ListResponse{Links: make([]*shortenerv1.Link, 0, len(links))}
+	for _, l := range links {
+		resp.Links = append(resp.Links, toLink(l))
+	}
+	if len(links) == size {
+		resp.NextPageToken = strconv.FormatInt(links[len(links)-1].ID, 10)
+	}
+
+	return resp, nil
+}
+
+func (s *serverAPI) Stats(ctx context.Context, req *shortenerv1.StatsRequest) (*shortenerv1.StatsResponse, error) {
+	const op = "grpc.shortener.Stats"
+
+	log := s.log.With(slog.String("op", op))
+
+	if req.GetAlias() == "" {
+		return nil, status.Error(codes.InvalidArgument, "alias is required")
+	}
+
+	domain := s.domain(req.GetDomain())
+
+	_, err := s.ownLink(ctx, domain, req.GetAlias())
+	var stats storage.Stats
+	if err == nil {
+		stats, err = s.storage.GetStats(domain, req.GetAlias())
+	}
+	if err != nil {
+		return nil, s.lookupError(log, err)
+	}
+
+	return &shortenerv1.StatsResponse{
+		Clicks:       stats.Clicks,
+		Variants:     stats.Variants,
+		FirstClickAt: toTimestamp(stats.FirstClickAt),
+		LastClickAt:  toTimestamp(stats.LastClickAt),
+	}, nil
+}
+
+// domain maps the main short domain to the default namespace.
+func (s *serverAPI) domain(name string) string {
+	return links.Namespace(name, s.opts.DefaultDomain)
+}
+
+// ownLink returns the link if it belongs to the caller and so does its
+// custom domain. Links of other users are not found, like in List.
+func (s *serverAPI) ownLink(ctx context.Context, domain, alias string) (storage.URL, error) {
+	caller := userFromContext(ctx)
+
+	link, err := links.Own(s.storage, caller, domain, alias)
+	if err != nil {
+		return storage.URL{}, err
+	}
+
+	if domain != "" {
+		d, err := s.storage.GetDomain(domain)
+		if errors.Is(err, storage.ErrDomainNotFound) || err == nil && d.Owner != caller {
+			return storage.URL{}, storage.ErrUrlNotFound
+		}
+		if err != nil {
+			return storage.URL{}, err
+		}
+	}
+
+	return link, nil
+}
+
+// lookupError converts errors of alias lookups to statuses.
+func (s *serverAPI) lookupError(log *slog.Logger, err error) error {
+	switch {
+	case errors.Is(err, storage.ErrUrlNotFound):
+		return status.Error(codes.NotFound, "url not found")
+	case errors.Is(err, storage.ErrURLDeleted):
+		return status.Error(codes.NotFound, "url is deleted")
+	default:
+		log.Error("failed to get url", slog.Any("error", err))
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// auditEntry returns an audit entry with the caller, its address and
+// the x-request-id metadata, see audit.NewEntry.
+func (s *serverAPI) auditEntry(ctx context.Context) storage.AuditEntry {
+	e := storage.AuditEntry{Actor: userFromContext(ctx)}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		e.IP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(e.IP); err == nil {
+			e.IP = host
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get("x-request-id"); len(ids) > 0 {
+			e.RequestID = ids[0]
+		}
+	}
+
+	return e
+}
+
+func validateSave(req *shortenerv1.SaveRequest) error {
+	switch {
+	case req.GetUrl() == "":
+		return status.Error(codes.InvalidArgument, "url is required")
+	case req.GetRedirectType() != 0 && req.GetRedirectType() != 301 && req.GetRedirectType() != 302 &&
+		req.GetRedirectType() != 307 && req.GetRedirectType() != 308:
+		return status.Error(codes.InvalidArgument, "redirect_type must be one of: 301 302 307 308")
+	case len(req.GetPassword()) > 72:
+		return status.Error(codes.InvalidArgument, "password is too long")
+	case req.GetMaxClicks() < 0:
+		return status.Error(codes.InvalidArgument, "max_clicks must not be negative")
+	case len(req.GetTitle()) > 200 || len(req.GetDescription()) > 500:
+		return status.Error(codes.InvalidArgument, "title or description is too long")
+	case req.GetActiveFrom() != nil && req.GetActiveUntil() != nil &&
+		!req.GetActiveUntil().AsTime().After(req.GetActiveFrom().AsTime()):
+		return status.Error(codes.InvalidArgument, "active_until must be after active_from")
+	}
+
+	for _, u := range []string{req.GetPendingUrl(), req.GetFallbackUrl(), req.GetImage()} {
+		if u == "" {
+			continue
+		}
+		if _, err := urlnorm.Normalize(u, urlnorm.Options{}); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid url")
+		}
+	}
+
+	return nil
+}
+
+// toLink converts the link, leaving out its password hash.
+func toLink(u storage.URL) *shortenerv1.Link {
+	return &shortenerv1.Link{
+		Domain:       u.Domain,
+		Alias:        u.Alias,
+		Url:          u.URL,
+		Owner:        u.Owner,
+		RedirectType: int32(u.RedirectType),
+		Passthrough:  u.Passthrough,
+		Params:       u.Params,
+		Campaign:     u.Campaign,
+		Protected:    u.PasswordHash != "",
+		Clicks:       u.Clicks,
+		MaxClicks:    u.MaxClicks,
+		Burn:         u.Burn,
+		ActiveFrom:   toTimestamp(u.ActiveFrom),
+		ActiveUntil:  toTimestamp(u.ActiveUntil),
+		PendingUrl:   u.PendingURL,
+		FallbackUrl:  u.FallbackURL,
+		Title:        u.Title,
+		Description:  u.Description,
+		Image:        u.Image,
+		CreatedAt:    toTimestamp(u.CreatedAt),
+	}
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+
+	return ts.AsTime()
+}
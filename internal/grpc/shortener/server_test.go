@@ -0,0 +1,344 @@
+package shortener_test
+
+import (
+	"context"
+	"encoding/base64"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	shortenerv1 "urlshortener/gen/go/shortener"
+	"urlshortener/internal/grpc/shortener"
+	"urlshortener/internal/grpc/shortener/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/apikey"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+// newClient serves the API over an in-memory listener.
+func newClient(t *testing.T, st shortener.Storage) shortenerv1.ShortenerClient {
+	t.Helper()
+
+	return newClientWithKeys(t, st, nil)
+}
+
+func newClientWithKeys(t *testing.T, st shortener.Storage, keys shortener.KeyStore) shortenerv1.ShortenerClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(
+		auth.UnaryServerInterceptor(shortener.AuthFunc(map[string]string{"user": "secret"}, keys)),
+	))
+	shortener.Register(srv, slogdiscard.NewDiscardLogger(), st, shortener.Options{
+		DefaultDomain: "sho.rt",
+	})
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return shortenerv1.NewShortenerClient(cc)
+}
+
+func withAuth(user, password string) context.Context {
+	token := base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+token)
+}
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	client := newClient(t, mocks.NewStorage(t))
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "No credentials", ctx: context.Background()},
+		{name: "Wrong password", ctx: withAuth("user", "wrong")},
+		{name: "Unknown user", ctx: withAuth("other", "secret")},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.Get(tc.ctx, &shortenerv1.GetRequest{Alias: "abc"})
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
+
+func TestAPIKey(t *testing.T) {
+	t.Parallel()
+
+	st := mocks.NewStorage(t)
+	st.On("ListURLs", "alice", "", int64(0), 100).Return([]storage.URL{}, nil).Once()
+
+	keys := mocks.NewKeyStore(t)
+	keys.On("GetAPIKey", apikey.Hash("us_key")).Return(storage.APIKey{ID: 1, Owner: "alice"}, nil).Once()
+	keys.On("GetAPIKey", apikey.Hash("us_wrong")).Return(storage.APIKey{}, storage.ErrAPIKeyNotFound).Once()
+
+	client := newClientWithKeys(t, st, keys)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), shortener.APIKeyMetadata, "us_key")
+	_, err := client.List(ctx, &shortenerv1.ListRequest{})
+	require.NoError(t, err)
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), shortener.APIKeyMetadata, "us_wrong")
+	_, err = client.List(ctx, &shortenerv1.ListRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestSave(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		req       *shortenerv1.SaveRequest
+		mockError error
+		code      codes.Code
+	}{
+		{
+			name: "Success",
+			req:  &shortenerv1.SaveRequest{Url: "https://example.com", Alias: "ex"},
+			code: codes.OK,
+		},
+		{
+			name: "Default domain",
+			req:  &shortenerv1.SaveRequest{Url: "https://example.com", Alias: "ex", Domain: "SHO.RT"},
+			code: codes.OK,
+		},
+		{
+			name: "Empty URL",
+			req:  &shortenerv1.SaveRequest{Alias: "ex"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Invalid redirect type",
+			req:  &shortenerv1.SaveRequest{Url: "https://example.com", RedirectType: 303},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Reserved alias",
+			req:  &shortenerv1.SaveRequest{Url: "https://example.com", Alias: "api"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name:      "Alias exists",
+			req:       &shortenerv1.SaveRequest{Url: "https://example.com", Alias: "ex"},
+			mockError: storage.ErrURLExists,
+			code:      codes.AlreadyExists,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mocks.NewStorage(t)
+			if tc.code == codes.OK || tc.mockError != nil {
+				st.On("SaveURL", mock.MatchedBy(func(u storage.URL) bool {
+					return u.Domain == "" && u.Alias == "ex" && u.Owner == "user"
+				}), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Actor == "user" && e.Action == storage.AuditCreate
+				})).Return(int64(1), tc.mockError).Once()
+			}
+
+			resp, err := newClient(t, st).Save(withAuth("user", "secret"), tc.req)
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				assert.Equal(t, "ex", resp.GetAlias())
+			}
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		domain    string
+		link      storage.URL
+		mockError error
+		// domainOwner owns the custom domain of the request
+		domainOwner string
+		code        codes.Code
+	}{
+		{
+			name: "Success",
+			link: storage.URL{Alias: "ex", URL: "https://example.com", Owner: "user", PasswordHash: "hash"},
+			code: codes.OK,
+		},
+		{
+			name:        "Custom domain",
+			domain:      "go.example.com",
+			link:        storage.URL{Alias: "ex", URL: "https://example.com", Owner: "user", PasswordHash: "hash"},
+			domainOwner: "user",
+			code:        codes.OK,
+		},
+		{name: "Not found", mockError: storage.ErrUrlNotFound, code: codes.NotFound},
+		{name: "Deleted", mockError: storage.ErrURLDeleted, code: codes.NotFound},
+		{
+			name: "Link of another user",
+			link: storage.URL{Alias: "ex", URL: "https://example.com", Owner: "bob"},
+			code: codes.NotFound,
+		},
+		{
+			name:        "Domain of another user",
+			domain:      "go.example.com",
+			link:        storage.URL{Alias: "ex", URL: "https://example.com", Owner: "user"},
+			domainOwner: "bob",
+			code:        codes.NotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mocks.NewStorage(t)
+			st.On("GetURL", tc.domain, "ex").Return(tc.link, tc.mockError).Once()
+			if tc.domainOwner != "" {
+				st.On("GetDomain", tc.domain).Return(storage.Domain{Name: tc.domain, Owner: tc.domainOwner}, nil).Once()
+			}
+
+			resp, err := newClient(t, st).Get(withAuth("user", "secret"), &shortenerv1.GetRequest{Alias: "ex", Domain: tc.domain})
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				assert.Equal(t, "https://example.com", resp.GetLink().GetUrl())
+				assert.True(t, resp.GetLink().GetProtected())
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		owner string
+		code  codes.Code
+	}{
+		{name: "Success", owner: "user", code: codes.OK},
+		{name: "Link of another user", owner: "bob", code: codes.NotFound},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mocks.NewStorage(t)
+			st.On("GetURL", "", "ex").Return(storage.URL{Alias: "ex", URL: "https://example.com", Owner: tc.owner}, nil).Once()
+			if tc.code == codes.OK {
+				st.On("DeleteURL", "", "ex", mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditDelete && e.Alias == "ex" && e.Before != nil
+				})).Return(nil).Once()
+			}
+
+			_, err := newClient(t, st).Delete(withAuth("user", "secret"), &shortenerv1.DeleteRequest{Alias: "ex"})
+			require.Equal(t, tc.code, status.Code(err))
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name      string
+		req       *shortenerv1.ListRequest
+		afterID   int64
+		limit     int
+		links     []storage.URL
+		code      codes.Code
+		nextToken string
+	}{
+		{
+			name:      "Full page",
+			req:       &shortenerv1.ListRequest{PageSize: 2},
+			limit:     2,
+			links:     []storage.URL{{ID: 3, Alias: "a"}, {ID: 7, Alias: "b"}},
+			nextToken: "7",
+		},
+		{
+			name:    "Last page",
+			req:     &shortenerv1.ListRequest{PageToken: "7"},
+			afterID: 7,
+			limit:   100,
+			links:   []storage.URL{{ID: 9, Alias: "c"}},
+		},
+		{
+			name: "Invalid token",
+			req:  &shortenerv1.ListRequest{PageToken: "x"},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "Page too large",
+			req:  &shortenerv1.ListRequest{PageSize: 5000},
+			code: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mocks.NewStorage(t)
+			if tc.code == codes.OK {
+				st.On("ListURLs", "user", "", tc.afterID, tc.limit).Return(tc.links, nil).Once()
+			}
+
+			resp, err := newClient(t, st).List(withAuth("user", "secret"), tc.req)
+			require.Equal(t, tc.code, status.Code(err))
+			if tc.code == codes.OK {
+				assert.Len(t, resp.GetLinks(), len(tc.links))
+				assert.Equal(t, tc.nextToken, resp.GetNextPageToken())
+			}
+		})
+	}
+}
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	st := mocks.NewStorage(t)
+	st.On("GetURL", "", "ex").Return(storage.URL{Alias: "ex", Owner: "user"}, nil).Once()
+	st.On("GetStats", "", "ex").Return(storage.Stats{
+		Clicks:       5,
+		Variants:     map[string]int64{"a": 3, "b": 2},
+		FirstClickAt: first,
+		LastClickAt:  first.Add(time.Hour),
+	}, nil).Once()
+
+	resp, err := newClient(t, st).Stats(withAuth("user", "secret"), &shortenerv1.StatsRequest{Alias: "ex"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), resp.GetClicks())
+	assert.Equal(t, int64(3), resp.GetVariants()["a"])
+	assert.True(t, first.Equal(resp.GetFirstClickAt().AsTime()))
+
+	other := mocks.NewStorage(t)
+	other.On("GetURL", "", "ex").Return(storage.URL{Alias: "ex", Owner: "bob"}, nil).Once()
+
+	_, err = newClient(t, other).Stats(withAuth("user", "secret"), &shortenerv1.StatsRequest{Alias: "ex"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
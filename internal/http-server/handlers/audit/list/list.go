@@ -0,0 +1,114 @@
+package list
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/hostname"
+)
+
+type Response struct {
+	resp.Response
+	Entries []storage.AuditEntry `json:"entries"`
+	// NextAfterID is passed as after_id to get the next page,
+	// empty on the last page.
+	NextAfterID int64 `json:"next_after_id,omitempty"`
+}
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=AuditLister
+type AuditLister interface {
+	ListAudit(f storage.AuditFilter) ([]storage.AuditEntry, error)
+}
+
+// New returns audit log entries (admin only), oldest first.
+// Query params actor, action, entity, domain and alias filter by exact
+// match, from and to (RFC 3339) by time, after_id and limit (100 by default, up to 1000) page.
+func New(log *slog.Logger, auditLister AuditLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.audit.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		f, err := parseFilter(r)
+		if err != nil {
+			log.Info("invalid filter", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		entries, err := auditLister.ListAudit(f)
+		if err != nil {
+			log.Error("failed to list audit entries", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to list audit entries"))
+			return
+		}
+
+		if entries == nil {
+			entries = []storage.AuditEntry{}
+		}
+
+		var next int64
+		if len(entries) == f.Limit {
+			next = entries[len(entries)-1].ID
+		}
+
+		render.JSON(w, r, Response{
+			Response:    resp.OK(),
+			Entries:     entries,
+			NextAfterID: next,
+		})
+	}
+}
+
+func parseFilter(r *http.Request) (storage.AuditFilter, error) {
+	q := r.URL.Query()
+
+	f := storage.AuditFilter{
+		Actor:  q.Get("actor"),
+		Action: q.Get("action"),
+		Entity: q.Get("entity"),
+		Domain: hostname.Normalize(q.Get("domain")),
+		Alias:  q.Get("alias"),
+	}
+
+	var err error
+	if v := q.Get("from"); v != "" {
+		if f.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, errors.New("invalid from")
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return f, errors.New("invalid to")
+		}
+	}
+	if v := q.Get("after_id"); v != "" {
+		if f.AfterID, err = strconv.ParseInt(v, 10, 64); err != nil || f.AfterID < 0 {
+			return f, errors.New("invalid after_id")
+		}
+	}
+	f.Limit = defaultLimit
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxLimit {
+			return f, errors.New("invalid limit")
+		}
+	}
+
+	return f, nil
+}
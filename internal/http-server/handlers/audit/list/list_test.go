@@ -0,0 +1,111 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/audit/list"
+	"urlshortener/internal/http-server/handlers/audit/list/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestListHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		query       string
+		wantStatus  int
+		wantError   string
+		wantFilter  storage.AuditFilter
+		mockEntries []storage.AuditEntry
+		mockError   error
+		mockCalled  bool
+		wantNext    int64
+	}{
+		{
+			name:        "No filters",
+			wantStatus:  http.StatusOK,
+			wantFilter:  storage.AuditFilter{Limit: 100},
+			mockEntries: []storage.AuditEntry{{ID: 1, Action: storage.AuditCreate, Alias: "abc"}},
+			mockCalled:  true,
+		},
+		{
+			name:       "Filters",
+			query:      "?actor=alice&action=delete&entity=url&domain=Go.Brand.com&alias=abc&from=2025-01-01T00:00:00Z&to=2025-02-01T00:00:00Z&after_id=10&limit=2",
+			wantStatus: http.StatusOK,
+			wantFilter: storage.AuditFilter{
+				Actor:   "alice",
+				Action:  "delete",
+				Entity:  "url",
+				Domain:  "go.brand.com",
+				Alias:   "abc",
+				From:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				To:      time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+				AfterID: 10,
+				Limit:   2,
+			},
+			mockEntries: []storage.AuditEntry{{ID: 11}, {ID: 12}},
+			mockCalled:  true,
+			wantNext:    12,
+		},
+		{
+			name:       "Invalid from",
+			query:      "?from=yesterday",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid from",
+		},
+		{
+			name:       "Limit too large",
+			query:      "?limit=5000",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid limit",
+		},
+		{
+			name:       "Storage error",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to list audit entries",
+			wantFilter: storage.AuditFilter{Limit: 100},
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			auditListerMock := mocks.NewAuditLister(t)
+
+			if tc.mockCalled {
+				auditListerMock.On("ListAudit", tc.wantFilter).
+					Return(tc.mockEntries, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), auditListerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/audit"+tc.query, nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+			require.Equal(t, tc.wantNext, resp.NextAfterID)
+			if tc.wantError == "" {
+				require.Len(t, resp.Entries, len(tc.mockEntries))
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package verify
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+type Response struct {
+	resp.Response
+	storage.AuditVerification
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=AuditVerifier
+type AuditVerifier interface {
+	VerifyAudit() (storage.AuditVerification, error)
+}
+
+// New recomputes the hash chain of the audit log (admin only).
+// A broken chain means entries were changed or removed outside the service,
+// broken_at is the first entry that doesn't match.
+func New(log *slog.Logger, auditVerifier AuditVerifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.audit.verify.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		v, err := auditVerifier.VerifyAudit()
+		if err != nil {
+			log.Error("failed to verify audit log", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to verify audit log"))
+			return
+		}
+
+		if !v.Valid {
+			log.Warn("audit chain is broken", slog.Int64("broken_at", v.BrokenAt))
+		}
+
+		render.JSON(w, r, Response{
+			Response:          resp.OK(),
+			AuditVerification: v,
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package verify_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/audit/verify"
+	"urlshortener/internal/http-server/handlers/audit/verify/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestVerifyHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		mockResult storage.AuditVerification
+		mockError  error
+		wantStatus int
+		wantError  string
+	}{
+		{
+			name:       "Valid chain",
+			mockResult: storage.AuditVerification{Entries: 3, Valid: true},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Broken chain",
+			mockResult: storage.AuditVerification{Entries: 3, BrokenAt: 2},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			mockError:  errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to verify audit log",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			auditVerifierMock := mocks.NewAuditVerifier(t)
+			auditVerifierMock.On("VerifyAudit").
+				Return(tc.mockResult, tc.mockError).
+				Once()
+
+			handler := verify.New(slogdiscard.NewDiscardLogger(), auditVerifierMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/admin/audit/verify", nil)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp verify.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+			if tc.wantError == "" {
+				require.Equal(t, tc.mockResult, resp.AuditVerification)
+			}
+		})
+	}
+}
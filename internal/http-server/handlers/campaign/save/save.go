@@ -0,0 +1,92 @@
+package save
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+type Request struct {
+	// Params are added to the destination of every link of the campaign,
+	// link params with the same key take precedence
+	Params map[string]string `json:"params" validate:"dive,keys,required,endkeys"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=CampaignSaver
+type CampaignSaver interface {
+	GetCampaignParams(name string) (map[string]string, error)
+	SaveCampaign(name string, params map[string]string, e storage.AuditEntry) error
+}
+
+// New creates or replaces default params of the campaign.
+// Links of the campaign pick them up on the next redirect.
+func New(log *slog.Logger, campaignSaver CampaignSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.campaign.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := chi.URLParam(r, "name")
+		if name == "" {
+			log.Info("campaign name is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("campaign name is required"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		// the previous params go to the audit log
+		action := storage.AuditUpdate
+		before, err := campaignSaver.GetCampaignParams(name)
+		if errors.Is(err, storage.ErrCampaignNotFound) {
+			action = storage.AuditCreate
+		} else if err != nil {
+			log.Error("failed to get campaign", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save campaign"))
+			return
+		}
+
+		e := audit.NewEntry(r, action, storage.AuditEntityCampaign, "", name, before, req.Params)
+		if err := campaignSaver.SaveCampaign(name, req.Params, e); err != nil {
+			log.Error("failed to save campaign", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save campaign"))
+			return
+		}
+
+		log.Info("campaign saved", slog.String("name", name))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,112 @@
+package save_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/campaign/save"
+	"urlshortener/internal/http-server/handlers/campaign/save/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestSaveCampaignHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		campaign   string
+		body       string
+		wantStatus int
+		wantError  string
+		mockParams map[string]string
+		mockError  error
+		mockCalled bool
+	}{
+		{
+			name:       "Success",
+			campaign:   "spring",
+			body:       `{"params": {"utm_campaign": "spring", "utm_medium": "email"}}`,
+			wantStatus: http.StatusOK,
+			mockParams: map[string]string{"utm_campaign": "spring", "utm_medium": "email"},
+			mockCalled: true,
+		},
+		{
+			name:       "Empty name",
+			campaign:   "",
+			body:       `{"params": {}}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "campaign name is required",
+		},
+		{
+			name:       "Empty body",
+			campaign:   "spring",
+			body:       "",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "empty request",
+		},
+		{
+			name:       "Empty param key",
+			campaign:   "spring",
+			body:       `{"params": {"": "x"}}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "field Params[] is a required field",
+		},
+		{
+			name:       "Storage error",
+			campaign:   "spring",
+			body:       `{"params": {"utm_campaign": "spring"}}`,
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to save campaign",
+			mockParams: map[string]string{"utm_campaign": "spring"},
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			campaignSaverMock := mocks.NewCampaignSaver(t)
+
+			if tc.mockCalled {
+				campaignSaverMock.On("GetCampaignParams", tc.campaign).
+					Return(nil, storage.ErrCampaignNotFound).
+					Once()
+				campaignSaverMock.On("SaveCampaign", tc.campaign, tc.mockParams, mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditCreate && e.Entity == storage.AuditEntityCampaign
+				})).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), campaignSaverMock)
+
+			req, err := http.NewRequest(http.MethodPut, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", tc.campaign)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+		})
+	}
+}
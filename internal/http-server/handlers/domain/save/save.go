@@ -0,0 +1,126 @@
+package save
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/hostname"
+)
+
+type Request struct {
+	// NotFoundURL is where unknown aliases of the domain redirect, 404 if empty
+	NotFoundURL string `json:"not_found_url,omitempty" validate:"omitempty,url"`
+	// RootURL is where the domain root redirects, 404 if empty
+	RootURL string `json:"root_url,omitempty" validate:"omitempty,url"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=DomainSaver
+type DomainSaver interface {
+	GetDomain(name string) (storage.Domain, error)
+	SaveDomain(d storage.Domain, e storage.AuditEntry) error
+}
+
+// Options configures the domain save handler.
+type Options struct {
+	// DefaultDomain can't be registered as a custom domain.
+	DefaultDomain string
+}
+
+// New registers a custom short domain for the user or updates its settings.
+// A domain belongs to the user who registered it first.
+func New(log *slog.Logger, domainSaver DomainSaver, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		name := hostname.Normalize(chi.URLParam(r, "name"))
+		if name == "" {
+			log.Info("domain name is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("domain name is required"))
+			return
+		}
+
+		if !hostname.Valid(name) || name == hostname.Normalize(opts.DefaultDomain) {
+			log.Info("invalid domain name", slog.String("domain", name))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid domain name"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		owner := auth.Principal(r.Context())
+
+		// the previous settings go to the audit log
+		action := storage.AuditUpdate
+		var before any
+		existing, err := domainSaver.GetDomain(name)
+		switch {
+		case errors.Is(err, storage.ErrDomainNotFound):
+			action = storage.AuditCreate
+		case err != nil:
+			log.Error("failed to get domain", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save domain"))
+			return
+		default:
+			before = existing
+		}
+
+		domain := storage.Domain{
+			Name:        name,
+			Owner:       owner,
+			NotFoundURL: req.NotFoundURL,
+			RootURL:     req.RootURL,
+		}
+		err = domainSaver.SaveDomain(domain, audit.NewEntry(r, action, storage.AuditEntityDomain,
+			name, "", before, domain))
+		if errors.Is(err, storage.ErrDomainExists) {
+			log.Info("domain belongs to another user", slog.String("domain", name))
+			resp.RenderError(w, r, http.StatusConflict, resp.Error("domain belongs to another user"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to save domain", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save domain"))
+			return
+		}
+
+		log.Info("domain saved", slog.String("domain", name))
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,130 @@
+package save_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/domain/save"
+	"urlshortener/internal/http-server/handlers/domain/save/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestSaveDomainHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		domain     string
+		body       string
+		wantStatus int
+		wantError  string
+		mockDomain storage.Domain
+		mockError  error
+	}{
+		{
+			name:       "Success",
+			domain:     "Go.Brand.com",
+			body:       `{"not_found_url": "https://brand.com/404", "root_url": "https://brand.com"}`,
+			wantStatus: http.StatusOK,
+			mockDomain: storage.Domain{
+				Name: "go.brand.com", Owner: "alice",
+				NotFoundURL: "https://brand.com/404", RootURL: "https://brand.com",
+			},
+		},
+		{
+			name:       "Empty name",
+			domain:     "",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "domain name is required",
+		},
+		{
+			name:       "Invalid name",
+			domain:     "localhost",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid domain name",
+		},
+		{
+			name:       "Default domain",
+			domain:     "sho.rt",
+			body:       `{}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid domain name",
+		},
+		{
+			name:       "Invalid url",
+			domain:     "go.brand.com",
+			body:       `{"root_url": "not a url"}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "field RootURL is not a valid URL",
+		},
+		{
+			name:       "Registered by another user",
+			domain:     "go.brand.com",
+			body:       `{}`,
+			wantStatus: http.StatusConflict,
+			wantError:  "domain belongs to another user",
+			mockDomain: storage.Domain{Name: "go.brand.com", Owner: "alice"},
+			mockError:  storage.ErrDomainExists,
+		},
+		{
+			name:       "Storage error",
+			domain:     "go.brand.com",
+			body:       `{}`,
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to save domain",
+			mockDomain: storage.Domain{Name: "go.brand.com", Owner: "alice"},
+			mockError:  errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			domainSaverMock := mocks.NewDomainSaver(t)
+
+			if tc.mockDomain.Name != "" {
+				domainSaverMock.On("GetDomain", tc.mockDomain.Name).
+					Return(storage.Domain{}, storage.ErrDomainNotFound).
+					Once()
+				domainSaverMock.On("SaveDomain", tc.mockDomain, mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditCreate && e.Domain == tc.mockDomain.Name
+				})).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), domainSaverMock, save.Options{DefaultDomain: "sho.rt"})
+
+			req, err := http.NewRequest(http.MethodPut, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", tc.domain)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+		})
+	}
+}
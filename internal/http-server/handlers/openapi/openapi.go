@@ -0,0 +1,80 @@
+// Package openapi serves the OpenAPI document of the HTTP API and
+// Swagger UI for it.
+package openapi
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Spec is the OpenAPI 3 document of the HTTP API. The tests of
+// cmd/url-shortener compare it with the routes and request/response types.
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed swagger.html
+var swaggerPage string
+
+var swaggerTmpl = template.Must(template.New("swagger").Parse(swaggerPage))
+
+// dist holds the Swagger UI script and styles, so the page doesn't load
+// code from a CDN.
+//
+//go:embed dist/swagger-ui-bundle.js dist/swagger-ui.css
+var dist embed.FS
+
+// New serves the OpenAPI document.
+func New(log *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		if _, err := w.Write(Spec); err != nil {
+			log.Error("failed to write openapi document",
+				slog.String("op", "handlers.openapi.New"),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// NewUI serves Swagger UI showing the document at specURL.
+func NewUI(log *slog.Logger, specURL string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := swaggerTmpl.Execute(w, specURL); err != nil {
+			log.Error("failed to render swagger ui",
+				slog.String("op", "handlers.openapi.NewUI"),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.Any("error", err),
+			)
+		}
+	}
+}
+
+// NewAssets serves the Swagger UI files under prefix, e.g. /docs/.
+func NewAssets(prefix string) http.Handler {
+	files, err := fs.Sub(dist, "dist")
+	if err != nil {
+		// only fails for an invalid path
+		panic(err)
+	}
+	fileServer := http.StripPrefix(prefix, http.FileServerFS(files))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no directory listing
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		fileServer.ServeHTTP(w, r)
+	})
+}
@@ -9,16 +9,29 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
 
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
 	"urlshortener/internal/storage"
 	resp "urlshortener/lib/api/response"
 )
 
 //go:generate go run github.com/vektra/mockery/v2@latest --name=URLDeleter
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	GetURL(domain string, alias string) (storage.URL, error)
+	DeleteURL(domain string, alias string, e storage.AuditEntry) error
 }
 
-func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
+// Options configures the delete handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New deletes a link of the caller. Links of other users are answered
+// with 404 as if they didn't exist.
+func New(log *slog.Logger, urlDeleter URLDeleter, opts Options) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.delete.New"
 
@@ -30,27 +43,33 @@ func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("alias is required"))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
 			return
 		}
 
-		err := urlDeleter.DeleteURL(alias)
-		if errors.Is(err, storage.ErrUrlNotFound) {
+		// links of a custom domain are selected with the domain query param
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		// the deleted link goes to the audit log
+		before, err := links.Own(urlDeleter, auth.Principal(r.Context()), domain, alias)
+		if err == nil {
+			err = urlDeleter.DeleteURL(domain, alias, audit.NewEntry(r, storage.AuditDelete, storage.AuditEntityURL,
+				domain, alias, audit.NewLink(before), nil))
+		}
+		if errors.Is(err, storage.ErrUrlNotFound) || errors.Is(err, storage.ErrURLDeleted) {
 			log.Info("alias not found", slog.String("alias", alias))
-			w.WriteHeader(http.StatusNotFound)
-			render.JSON(w, r, resp.Error("url not found"))
+			resp.RenderError(w, r, http.StatusNotFound, resp.ErrorCode(resp.CodeNotFound, "url not found"))
 			return
 		}
 
 		if err != nil {
 			log.Error("failed to delete url", slog.Any("error", err))
-			w.WriteHeader(http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("failed to delete url"))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to delete url"))
 			return
 		}
 
 		log.Info("deleted alias", slog.String("alias", alias))
+
 		render.JSON(w, r, resp.OK())
 	}
 }
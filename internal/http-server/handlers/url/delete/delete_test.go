@@ -9,10 +9,12 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"urlshortener/internal/http-server/handlers/url/delete"
 	"urlshortener/internal/http-server/handlers/url/delete/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
 	"urlshortener/internal/storage"
 	"urlshortener/lib/api/response"
 	"urlshortener/lib/logger/handlers/slogdiscard"
@@ -26,6 +28,8 @@ func TestDeleteHandler(t *testing.T) {
 		wantResponse response.Response
 		mockError    error
 		mockCalled   bool
+		// owner of the stored link, the caller is alice
+		owner string
 	}{
 		{
 			name:  "Delete success",
@@ -52,17 +56,31 @@ func TestDeleteHandler(t *testing.T) {
 			wantResponse: response.Response{
 				Status: response.StatusError,
 				Error:  "url not found",
+				Code:   response.CodeNotFound,
 			},
 			wantStatus: http.StatusNotFound,
 			mockError:  storage.ErrUrlNotFound,
 			mockCalled: true,
 		},
+		{
+			name:  "Link of another user",
+			alias: "other",
+			owner: "bob",
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "url not found",
+				Code:   response.CodeNotFound,
+			},
+			wantStatus: http.StatusNotFound,
+			mockCalled: true,
+		},
 		{
 			name:  "Internal error",
 			alias: "internal_error",
 			wantResponse: response.Response{
 				Status: response.StatusError,
 				Error:  "failed to delete url",
+				Code:   response.CodeInternal,
 			},
 			wantStatus: http.StatusInternalServerError,
 			mockError:  errors.New("internal error"),
@@ -76,20 +94,32 @@ func TestDeleteHandler(t *testing.T) {
 
 			urlDeleterMock := mocks.NewURLDeleter(t)
 
+			if tc.owner == "" {
+				tc.owner = "alice"
+			}
+
 			if tc.mockCalled {
-				urlDeleterMock.On("DeleteURL", tc.alias).
-					Return(tc.mockError).
+				urlDeleterMock.On("GetURL", "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: "https://example.com", Owner: tc.owner}, nil).
 					Once()
+				if tc.owner == "alice" {
+					urlDeleterMock.On("DeleteURL", "", tc.alias, mock.MatchedBy(func(e storage.AuditEntry) bool {
+						return e.Action == storage.AuditDelete && e.Alias == tc.alias &&
+							string(e.Before) != "" && e.After == nil
+					})).
+						Return(tc.mockError).
+						Once()
+				}
 			}
 
-			handler := delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock)
+			handler := delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, delete.Options{DefaultDomain: "sho.rt"})
 
 			req, err := http.NewRequest(http.MethodDelete, "/", nil)
 			require.NoError(t, err)
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("alias", tc.alias)
-			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithPrincipal(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), "alice"))
 
 			rr := httptest.NewRecorder()
 
@@ -103,6 +133,7 @@ func TestDeleteHandler(t *testing.T) {
 
 			require.Equal(t, tc.wantResponse.Status, resp.Status)
 			require.Equal(t, tc.wantResponse.Error, resp.Error)
+			require.Equal(t, tc.wantResponse.Code, resp.Code)
 
 			if tc.mockCalled {
 				urlDeleterMock.AssertExpectations(t)
@@ -0,0 +1,143 @@
+package get
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+// Link is a short link as returned by the API, the password hash
+// is never exposed.
+type Link struct {
+	// ID orders links in lists, see the after query param.
+	ID int64 `json:"id"`
+	// Domain is empty for links of the default domain.
+	Domain       string            `json:"domain,omitempty"`
+	Alias        string            `json:"alias"`
+	URL          string            `json:"url"`
+	Owner        string            `json:"owner,omitempty"`
+	RedirectType int               `json:"redirect_type,omitempty"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Campaign     string            `json:"campaign,omitempty"`
+	// Protected links ask visitors for a password.
+	Protected   bool              `json:"protected,omitempty"`
+	Clicks      int64             `json:"clicks"`
+	MaxClicks   int64             `json:"max_clicks,omitempty"`
+	Burn        bool              `json:"burn,omitempty"`
+	ActiveFrom  *time.Time        `json:"active_from,omitempty"`
+	ActiveUntil *time.Time        `json:"active_until,omitempty"`
+	PendingURL  string            `json:"pending_url,omitempty"`
+	FallbackURL string            `json:"fallback_url,omitempty"`
+	Rules       []storage.Rule    `json:"rules,omitempty"`
+	Variants    []storage.Variant `json:"variants,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Image       string            `json:"image,omitempty"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Link Link `json:"link"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLGetter
+type URLGetter interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+}
+
+// Options configures the get handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New returns the link with its rules and variants. Links of a custom
+// domain are selected with the domain query param. Links of other users
+// are answered with 404 as if they didn't exist.
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.get.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		link, err := links.Own(urlGetter, auth.Principal(r.Context()), domain, alias)
+		if errors.Is(err, storage.ErrUrlNotFound) || errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.ErrorCode(resp.CodeNotFound, "url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to get url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to get url"))
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     NewLink(link),
+		})
+	}
+}
+
+// NewLink returns the API view of u.
+func NewLink(u storage.URL) Link {
+	return Link{
+		ID:           u.ID,
+		Domain:       u.Domain,
+		Alias:        u.Alias,
+		URL:          u.URL,
+		Owner:        u.Owner,
+		RedirectType: u.RedirectType,
+		Passthrough:  u.Passthrough,
+		Params:       u.Params,
+		Campaign:     u.Campaign,
+		Protected:    u.PasswordHash != "",
+		Clicks:       u.Clicks,
+		MaxClicks:    u.MaxClicks,
+		Burn:         u.Burn,
+		ActiveFrom:   timePtr(u.ActiveFrom),
+		ActiveUntil:  timePtr(u.ActiveUntil),
+		PendingURL:   u.PendingURL,
+		FallbackURL:  u.FallbackURL,
+		Rules:        u.Rules,
+		Variants:     u.Variants,
+		Title:        u.Title,
+		Description:  u.Description,
+		Image:        u.Image,
+		CreatedAt:    timePtr(u.CreatedAt),
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,146 @@
+package get_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/get"
+	"urlshortener/internal/http-server/handlers/url/get/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestGetHandler(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name       string
+		alias      string
+		domain     string
+		link       storage.URL
+		mockError  error
+		mockCalled bool
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:  "Success",
+			alias: "test_alias",
+			link: storage.URL{ID: 7, Alias: "test_alias", URL: "https://example.com", Owner: "user",
+				PasswordHash: "hash", Clicks: 3, CreatedAt: created,
+				Rules: []storage.Rule{{OS: "ios", URL: "https://apps.apple.com"}}},
+			mockCalled: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Custom domain",
+			alias:      "test_alias",
+			domain:     "Go.Example.com",
+			link:       storage.URL{ID: 8, Domain: "go.example.com", Alias: "test_alias", URL: "https://example.com", Owner: "user"},
+			mockCalled: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Default domain",
+			alias:      "test_alias",
+			domain:     "Sho.RT",
+			link:       storage.URL{ID: 10, Alias: "test_alias", URL: "https://example.com", Owner: "user"},
+			mockCalled: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Link of another user",
+			alias:      "test_alias",
+			link:       storage.URL{ID: 9, Alias: "test_alias", URL: "https://example.com", Owner: "bob"},
+			mockCalled: true,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Empty alias",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			mockError:  storage.ErrUrlNotFound,
+			mockCalled: true,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Deleted",
+			alias:      "deleted",
+			mockError:  storage.ErrURLDeleted,
+			mockCalled: true,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Internal error",
+			alias:      "test_alias",
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   response.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.mockCalled {
+				urlGetterMock.On("GetURL", tc.link.Domain, tc.alias).
+					Return(tc.link, tc.mockError).
+					Once()
+			}
+
+			handler := get.New(slogdiscard.NewDiscardLogger(), urlGetterMock, get.Options{DefaultDomain: "sho.rt"})
+
+			req := httptest.NewRequest(http.MethodGet, "/?domain="+tc.domain, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp get.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantCode, resp.Code)
+
+			if tc.wantStatus == http.StatusOK {
+				require.Equal(t, response.StatusOK, resp.Status)
+				require.Equal(t, get.NewLink(tc.link), resp.Link)
+			}
+		})
+	}
+}
+
+func TestNewLink(t *testing.T) {
+	t.Parallel()
+
+	link := get.NewLink(storage.URL{Alias: "a", URL: "https://example.com", PasswordHash: "hash"})
+	require.True(t, link.Protected)
+	require.Nil(t, link.ActiveFrom)
+	require.Nil(t, link.CreatedAt)
+
+	b, err := json.Marshal(link)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "hash")
+}
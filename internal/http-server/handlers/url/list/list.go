@@ -0,0 +1,112 @@
+package list
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/http-server/handlers/url/get"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Response struct {
+	resp.Response
+	Links []get.Link `json:"links"`
+	// NextAfter is passed as after to get the next page,
+	// empty on the last page.
+	NextAfter int64 `json:"next_after,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLLister
+type URLLister interface {
+	ListURLs(owner string, domain string, afterID int64, limit int) ([]storage.URL, error)
+}
+
+// Options configures the list handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New returns live links of the user on a domain, oldest first.
+// Query param domain selects a custom domain, after and limit
+// (100 by default, up to 1000) page. Rules and variants are left out,
+// get a link to see them.
+func New(log *slog.Logger, urlLister URLLister, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		after, limit, err := parsePage(r)
+		if err != nil {
+			log.Info("invalid page", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ErrorCode(resp.CodeValidation, err.Error()))
+			return
+		}
+
+		owner := auth.Principal(r.Context())
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		urls, err := urlLister.ListURLs(owner, domain, after, limit)
+		if err != nil {
+			log.Error("failed to list urls", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to list urls"))
+			return
+		}
+
+		links := make([]get.Link, 0, len(urls))
+		for _, u := range urls {
+			links = append(links, get.NewLink(u))
+		}
+
+		var next int64
+		if len(urls) == limit {
+			next = urls[len(urls)-1].ID
+		}
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Links:     links,
+			NextAfter: next,
+		})
+	}
+}
+
+func parsePage(r *http.Request) (int64, int, error) {
+	q := r.URL.Query()
+
+	var (
+		after int64
+		limit = defaultLimit
+		err   error
+	)
+	if v := q.Get("after"); v != "" {
+		if after, err = strconv.ParseInt(v, 10, 64); err != nil || after < 1 {
+			return 0, 0, errors.New("invalid after")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 || limit > maxLimit {
+			return 0, 0, errors.New("invalid limit")
+		}
+	}
+
+	return after, limit, nil
+}
@@ -0,0 +1,114 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/list"
+	"urlshortener/internal/http-server/handlers/url/list/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestListHandler(t *testing.T) {
+	page := func(n int) []storage.URL {
+		urls := make([]storage.URL, n)
+		for i := range urls {
+			urls[i] = storage.URL{ID: int64(i + 1), Alias: "a", URL: "https://example.com", Owner: "user"}
+		}
+		return urls
+	}
+
+	cases := []struct {
+		name       string
+		query      string
+		wantDomain string
+		wantAfter  int64
+		wantLimit  int
+		urls       []storage.URL
+		mockError  error
+		wantStatus int
+		wantLinks  int
+		wantNext   int64
+	}{
+		{
+			name:       "Defaults",
+			wantLimit:  100,
+			urls:       page(2),
+			wantStatus: http.StatusOK,
+			wantLinks:  2,
+		},
+		{
+			name:       "Empty",
+			wantLimit:  100,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Full page",
+			query:      "after=10&limit=3&domain=Go.Example.com",
+			wantDomain: "go.example.com",
+			wantAfter:  10,
+			wantLimit:  3,
+			urls:       page(3),
+			wantStatus: http.StatusOK,
+			wantLinks:  3,
+			wantNext:   3,
+		},
+		{
+			name:       "Invalid after",
+			query:      "after=x",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Limit too big",
+			query:      "limit=1001",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Internal error",
+			wantLimit:  100,
+			mockError:  errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlListerMock := mocks.NewURLLister(t)
+			if tc.wantLimit > 0 {
+				urlListerMock.On("ListURLs", "user", tc.wantDomain, tc.wantAfter, tc.wantLimit).
+					Return(tc.urls, tc.mockError).
+					Once()
+			}
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), urlListerMock, list.Options{DefaultDomain: "sho.rt"})
+
+			req := httptest.NewRequest(http.MethodGet, "/?"+tc.query, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, response.StatusOK, resp.Status)
+			require.NotNil(t, resp.Links)
+			require.Len(t, resp.Links, tc.wantLinks)
+			require.Equal(t, tc.wantNext, resp.NextAfter)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package purge
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLPurger
+type URLPurger interface {
+	PurgeURL(domain string, alias string, e storage.AuditEntry) error
+}
+
+// Options configures the purge handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New removes the link for good with its rules, variants and clicks,
+// its alias is free right away (admin only).
+// Links of a custom domain are selected with the domain query param.
+func New(log *slog.Logger, urlPurger URLPurger, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.purge.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		err := urlPurger.PurgeURL(domain, alias, audit.NewEntry(r, storage.AuditPurge, storage.AuditEntityURL,
+			domain, alias, nil, nil))
+		if errors.Is(err, storage.ErrUrlNotFound) {
+			log.Info("alias not found", slog.String("domain", domain), slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to purge url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to purge url"))
+			return
+		}
+
+		log.Info("purged alias", slog.String("domain", domain), slog.String("alias", alias))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,115 @@
+package purge_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/purge"
+	"urlshortener/internal/http-server/handlers/url/purge/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestPurgeHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		alias        string
+		wantStatus   int
+		wantResponse response.Response
+		mockError    error
+		mockCalled   bool
+	}{
+		{
+			name:  "Purge success",
+			alias: "test_alias",
+			wantResponse: response.Response{
+				Status: response.StatusOK,
+			},
+			wantStatus: http.StatusOK,
+			mockCalled: true,
+		},
+		{
+			name:  "Empty alias",
+			alias: "",
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "alias is required",
+			},
+			wantStatus: http.StatusBadRequest,
+			mockCalled: false,
+		},
+		{
+			name:  "URL not found",
+			alias: "not_found",
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "url not found",
+			},
+			wantStatus: http.StatusNotFound,
+			mockError:  storage.ErrUrlNotFound,
+			mockCalled: true,
+		},
+		{
+			name:  "Internal error",
+			alias: "internal_error",
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "failed to purge url",
+			},
+			wantStatus: http.StatusInternalServerError,
+			mockError:  errors.New("internal error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlPurgerMock := mocks.NewURLPurger(t)
+
+			if tc.mockCalled {
+				urlPurgerMock.On("PurgeURL", "", tc.alias, mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditPurge && e.Alias == tc.alias
+				})).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := purge.New(slogdiscard.NewDiscardLogger(), urlPurgerMock, purge.Options{DefaultDomain: "sho.rt"})
+
+			req, err := http.NewRequest(http.MethodDelete, "/", nil)
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+			err = json.Unmarshal(rr.Body.Bytes(), &resp)
+			require.NoError(t, err)
+
+			require.Equal(t, tc.wantResponse.Status, resp.Status)
+			require.Equal(t, tc.wantResponse.Error, resp.Error)
+
+			if tc.mockCalled {
+				urlPurgerMock.AssertExpectations(t)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package qr
+
+import "sync"
+
+const (
+	defaultCacheSize  = 1024
+	defaultCacheBytes = 32 << 20
+)
+
+// cache keeps rendered codes, bounded by the number of entries and their
+// total size. When full, the oldest entries are evicted.
+type cache struct {
+	mu       sync.Mutex
+	size     int
+	maxBytes int64
+	bytes    int64
+	items    map[string][]byte
+	order    []string
+}
+
+func newCache(size int, maxBytes int64) *cache {
+	if size <= 0 {
+		size = defaultCacheSize
+	}
+	if maxBytes <= 0 {
+		maxBytes = defaultCacheBytes
+	}
+
+	return &cache{
+		size:     size,
+		maxBytes: maxBytes,
+		items:    make(map[string][]byte, size),
+	}
+}
+
+func (c *cache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.items[key]
+
+	return v, ok
+}
+
+func (c *cache) add(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.items[key]; ok {
+		return
+	}
+	// a code larger than the whole cache would only evict the others
+	if int64(len(value)) > c.maxBytes {
+		return
+	}
+
+	for len(c.order) >= c.size || c.bytes+int64(len(value)) > c.maxBytes {
+		c.bytes -= int64(len(c.items[c.order[0]]))
+		delete(c.items, c.order[0])
+		c.order = c.order[1:]
+	}
+
+	c.items[key] = value
+	c.bytes += int64(len(value))
+	c.order = append(c.order, key)
+}
@@ -0,0 +1,41 @@
+package qr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheBytes(t *testing.T) {
+	c := newCache(10, 10)
+
+	c.add("a", make([]byte, 4))
+	c.add("b", make([]byte, 4))
+	// evicts a to stay within 10 bytes
+	c.add("c", make([]byte, 4))
+	// larger than the whole cache, not kept
+	c.add("d", make([]byte, 11))
+
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	for _, key := range []string{"b", "c"} {
+		_, ok := c.get(key)
+		assert.True(t, ok, key)
+	}
+	_, ok = c.get("d")
+	assert.False(t, ok)
+	assert.EqualValues(t, 8, c.bytes)
+}
+
+func TestCacheSize(t *testing.T) {
+	c := newCache(2, 100)
+
+	c.add("a", []byte("a"))
+	c.add("b", []byte("b"))
+	c.add("c", []byte("c"))
+
+	_, ok := c.get("a")
+	assert.False(t, ok)
+	assert.Len(t, c.items, 2)
+	assert.EqualValues(t, 2, c.bytes)
+}
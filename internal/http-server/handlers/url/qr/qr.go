@@ -0,0 +1,238 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/qr"
+)
+
+const (
+	defaultSize = 256
+	minSize     = 32
+	maxSize     = 2048
+
+	defaultMargin = 4
+	maxMargin     = 32
+)
+
+// URLGetter is an interface for checking that the alias exists and belongs to the caller.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLGetter
+type URLGetter interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+}
+
+// Options configures the QR code handler.
+type Options struct {
+	// BaseURL is the public address short links are served on,
+	// e.g. https://sho.rt. Taken from the request if empty.
+	BaseURL string
+	// CacheSize is the number of rendered codes kept in memory.
+	CacheSize int
+	// CacheBytes bounds the total size of the kept codes.
+	CacheBytes int64
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New renders a QR code of the short URL of a link of the caller (GET /url/{alias}/qr).
+//
+// Query params:
+//   - format: png (default) or svg
+//   - size: width and height in pixels, 32-2048, 256 by default
+//   - level: error correction L, M (default), Q or H
+//   - margin: quiet zone in modules, 0-32, 4 by default
+//   - fg, bg: hex colours, 000000 and ffffff by default
+//   - domain: custom domain of the link, the default one if empty
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	codes := newCache(opts.CacheSize, opts.CacheBytes)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		p, err := parseParams(r.URL.Query())
+		if err != nil {
+			log.Info("invalid params", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		_, err = links.Own(urlGetter, auth.Principal(r.Context()), domain, alias)
+		if errors.Is(err, storage.ErrUrlNotFound) {
+			log.Info("url not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+			return
+		}
+		if errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("url deleted", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusGone, resp.Error("link is no longer available"))
+			return
+		}
+		if err != nil {
+			log.Error("failed to get url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		shortURL := baseURL(r, opts.BaseURL, domain) + "/" + url.PathEscape(alias)
+		key := shortURL + "|" + p.key()
+
+		code, ok := codes.get(key)
+		if !ok {
+			if p.format == "svg" {
+				code, err = qr.SVG(shortURL, p.opts)
+			} else {
+				code, err = qr.PNG(shortURL, p.opts)
+			}
+			if err != nil {
+				log.Error("failed to render qr code", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+			codes.add(key, code)
+		}
+
+		log.Info("rendered qr code", slog.String("alias", alias),
+			slog.String("format", p.format), slog.Bool("cached", ok))
+
+		if p.format == "svg" {
+			w.Header().Set("Content-Type", "image/svg+xml")
+		} else {
+			w.Header().Set("Content-Type", "image/png")
+		}
+		// the code is only served to the owner of the link,
+		// shared caches must not keep it
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		_, _ = w.Write(code)
+	}
+}
+
+type params struct {
+	format string
+	opts   qr.Options
+	// raw colours as given, normalized to lower case
+	fg, bg string
+}
+
+func (p params) key() string {
+	return fmt.Sprintf("%s|%d|%s|%d|%s|%s", p.format, p.opts.Size, p.opts.Level, p.opts.Margin, p.fg, p.bg)
+}
+
+func parseParams(q url.Values) (params, error) {
+	p := params{
+		format: "png",
+		opts: qr.Options{
+			Size:   defaultSize,
+			Level:  qr.LevelMedium,
+			Margin: defaultMargin,
+		},
+		fg: "000000",
+		bg: "ffffff",
+	}
+
+	if v := q.Get("format"); v != "" {
+		if v != "png" && v != "svg" {
+			return p, errors.New("format must be one of: png svg")
+		}
+		p.format = v
+	}
+
+	if v := q.Get("size"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err != nil || size < minSize || size > maxSize {
+			return p, fmt.Errorf("size must be a number between %d and %d", minSize, maxSize)
+		}
+		p.opts.Size = size
+	}
+
+	if v := q.Get("level"); v != "" {
+		level, err := qr.ParseLevel(v)
+		if err != nil {
+			return p, errors.New("level must be one of: L M Q H")
+		}
+		p.opts.Level = level
+	}
+
+	if v := q.Get("margin"); v != "" {
+		margin, err := strconv.Atoi(v)
+		if err != nil || margin < 0 || margin > maxMargin {
+			return p, fmt.Errorf("margin must be a number between 0 and %d", maxMargin)
+		}
+		p.opts.Margin = margin
+	}
+
+	fg, err := qr.ParseColor(valueOr(q.Get("fg"), p.fg))
+	if err != nil {
+		return p, errors.New("fg must be a hex color")
+	}
+	bg, err := qr.ParseColor(valueOr(q.Get("bg"), p.bg))
+	if err != nil {
+		return p, errors.New("bg must be a hex color")
+	}
+	p.opts.Foreground, p.opts.Background = fg, bg
+	p.fg = fmt.Sprintf("%02x%02x%02x", fg.R, fg.G, fg.B)
+	p.bg = fmt.Sprintf("%02x%02x%02x", bg.R, bg.G, bg.B)
+
+	return p, nil
+}
+
+// baseURL returns the address links of domain are served on: the configured
+// or requested one for the default domain, the custom domain with the same
+// scheme otherwise.
+func baseURL(r *http.Request, configured string, domain string) string {
+	base := strings.TrimSuffix(configured, "/")
+	if base == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		base = scheme + "://" + r.Host
+	}
+
+	if domain == "" {
+		return base
+	}
+
+	scheme, _, _ := strings.Cut(base, "://")
+
+	return scheme + "://" + domain
+}
+
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+
+	return v
+}
@@ -0,0 +1,220 @@
+package qr_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/qr"
+	"urlshortener/internal/http-server/handlers/url/qr/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestQRHandler(t *testing.T) {
+	cases := []struct {
+		name  string
+		alias string
+		query string
+		// owner of the stored link, the caller is alice
+		owner       string
+		mockError   error
+		wantStatus  int
+		wantType    string
+		wantError   string
+		wantSize    int
+		wantContain string
+	}{
+		{
+			name:       "PNG by default",
+			alias:      "test_alias",
+			wantStatus: http.StatusOK,
+			wantType:   "image/png",
+			wantSize:   256,
+		},
+		{
+			name:       "PNG with params",
+			alias:      "test_alias",
+			query:      "size=512&level=h&margin=0&fg=%23102030&bg=fff",
+			wantStatus: http.StatusOK,
+			wantType:   "image/png",
+			wantSize:   512,
+		},
+		{
+			name:        "SVG",
+			alias:       "test_alias",
+			query:       "format=svg&size=128&fg=ff0000",
+			wantStatus:  http.StatusOK,
+			wantType:    "image/svg+xml",
+			wantContain: `fill="#ff0000"`,
+		},
+		{
+			name:       "Invalid format",
+			alias:      "test_alias",
+			query:      "format=gif",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "format must be one of: png svg",
+		},
+		{
+			name:       "Size too big",
+			alias:      "test_alias",
+			query:      "size=10000",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "size must be a number between 32 and 2048",
+		},
+		{
+			name:       "Invalid level",
+			alias:      "test_alias",
+			query:      "level=X",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "level must be one of: L M Q H",
+		},
+		{
+			name:       "Invalid color",
+			alias:      "test_alias",
+			query:      "bg=nope",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "bg must be a hex color",
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			mockError:  storage.ErrUrlNotFound,
+			wantStatus: http.StatusNotFound,
+			wantError:  "not found",
+		},
+		{
+			name:       "Link of another user",
+			alias:      "test_alias",
+			owner:      "bob",
+			wantStatus: http.StatusNotFound,
+			wantError:  "not found",
+		},
+		{
+			name:       "Storage error",
+			alias:      "broken",
+			mockError:  errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "internal error",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			if tc.owner == "" {
+				tc.owner = "alice"
+			}
+			if tc.wantStatus != http.StatusBadRequest {
+				urlGetterMock.On("GetURL", "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, Owner: tc.owner}, tc.mockError).Once()
+			}
+
+			handler := qr.New(slogdiscard.NewDiscardLogger(), urlGetterMock, qr.Options{
+				BaseURL: "https://sho.rt",
+			})
+
+			req, err := http.NewRequest(http.MethodGet, "/url/"+tc.alias+"/qr?"+tc.query, nil)
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			ctx := auth.WithPrincipal(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), "alice")
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			if tc.wantError != "" {
+				var resp response.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+				require.Equal(t, tc.wantError, resp.Error)
+				return
+			}
+
+			require.Equal(t, tc.wantType, rr.Header().Get("Content-Type"))
+
+			if tc.wantSize != 0 {
+				img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+				require.NoError(t, err)
+				require.Equal(t, tc.wantSize, img.Bounds().Dx())
+			}
+			if tc.wantContain != "" {
+				require.True(t, strings.Contains(rr.Body.String(), tc.wantContain))
+			}
+		})
+	}
+}
+
+func TestQRHandler_Cache(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", "abc").Return(storage.URL{Alias: "abc"}, nil).Twice()
+
+	handler := qr.New(slogdiscard.NewDiscardLogger(), urlGetterMock, qr.Options{})
+
+	do := func(host string) []byte {
+		req, err := http.NewRequest(http.MethodGet, "/url/abc/qr?size=64", nil)
+		require.NoError(t, err)
+		req.Host = host
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", "abc")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.Bytes()
+	}
+
+	// the code encodes the address the link is served on,
+	// different hosts must not share a cache entry
+	require.NotEqual(t, do("a.example"), do("b.example"))
+}
+
+func TestQRHandler_Domain(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "go.brand.com", "abc").Return(storage.URL{Domain: "go.brand.com", Alias: "abc"}, nil).Once()
+	urlGetterMock.On("GetURL", "", "abc").Return(storage.URL{Alias: "abc"}, nil).Times(3)
+
+	handler := qr.New(slogdiscard.NewDiscardLogger(), urlGetterMock, qr.Options{
+		BaseURL:       "https://sho.rt",
+		DefaultDomain: "sho.rt",
+	})
+
+	do := func(query string) string {
+		req, err := http.NewRequest(http.MethodGet, "/url/abc/qr?format=svg&"+query, nil)
+		require.NoError(t, err)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", "abc")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		return rr.Body.String()
+	}
+
+	// same alias on another domain is another short URL
+	require.NotEqual(t, do("domain=Go.Brand.com"), do(""))
+	// the default domain is the default namespace
+	require.Equal(t, do("domain=sho.rt"), do(""))
+}
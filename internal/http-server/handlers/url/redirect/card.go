@@ -0,0 +1,55 @@
+package redirect
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+
+	"urlshortener/internal/storage"
+	"urlshortener/lib/useragent"
+)
+
+var cardTmpl = template.Must(template.ParseFS(templates, "templates/card.html"))
+
+// hasCard reports whether the link has metadata to show when unfurled.
+func hasCard(link storage.URL) bool {
+	return link.Title != "" || link.Description != "" || link.Image != ""
+}
+
+// wantsCard reports whether the request comes from a chat app or social
+// network fetching the link to build a preview.
+func wantsCard(r *http.Request) bool {
+	return r.Method == http.MethodGet && useragent.IsLinkPreview(r.UserAgent())
+}
+
+// renderCard writes a page with Open Graph and Twitter card tags.
+// It never reveals the destination: crawlers of protected, limited
+// and burn links get the same page.
+func renderCard(w http.ResponseWriter, r *http.Request, link storage.URL) error {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+
+	title := link.Title
+	if title == "" {
+		title = link.Alias
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	return cardTmpl.Execute(w, struct {
+		URL         string
+		Title       string
+		Description string
+		Image       string
+	}{
+		URL:         scheme + "://" + r.Host + "/" + url.PathEscape(link.Alias),
+		Title:       title,
+		Description: link.Description,
+		Image:       link.Image,
+	})
+}
@@ -0,0 +1,99 @@
+package redirect
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// QueryMerge decides what happens when the request and the destination
+// have the same query key.
+type QueryMerge string
+
+const (
+	// QueryMergeOverride replaces destination values with request values.
+	QueryMergeOverride QueryMerge = "override"
+	// QueryMergeKeep keeps destination values and drops request values.
+	QueryMergeKeep QueryMerge = "keep"
+	// QueryMergeAppend keeps both, destination values first.
+	QueryMergeAppend QueryMerge = "append"
+)
+
+// restPath returns what follows the alias in the request path:
+// "/alias/a/b.html" -> "a/b.html".
+// r.URL.Path is used instead of the chi wildcard because
+// middleware.URLFormat strips the extension from the route path.
+func restPath(requestPath string) string {
+	p := strings.TrimPrefix(requestPath, "/")
+
+	_, rest, _ := strings.Cut(p, "/")
+
+	return rest
+}
+
+// passthrough appends rest to the destination path and merges query
+// into the destination query. rest can't climb above the destination
+// path with "..".
+func passthrough(destination string, rest string, query url.Values, mode QueryMerge) (string, error) {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("parse destination: %w", err)
+	}
+
+	if rest != "" {
+		cleaned := path.Clean("/" + rest)
+		if strings.HasSuffix(rest, "/") && cleaned != "/" {
+			cleaned += "/"
+		}
+
+		u.Path = strings.TrimSuffix(u.Path, "/") + cleaned
+		u.RawPath = ""
+	}
+
+	if len(query) > 0 {
+		merged := u.Query()
+		mergeQuery(merged, query, mode)
+		u.RawQuery = merged.Encode()
+	}
+
+	return u.String(), nil
+}
+
+// injectParams sets params in the destination query.
+// They override destination values with the same key.
+func injectParams(destination string, params map[string]string) (string, error) {
+	if len(params) == 0 {
+		return destination, nil
+	}
+
+	u, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("parse destination: %w", err)
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// mergeQuery merges src into dst according to mode.
+func mergeQuery(dst url.Values, src url.Values, mode QueryMerge) {
+	for key, values := range src {
+		_, exists := dst[key]
+
+		switch {
+		case !exists:
+			dst[key] = values
+		case mode == QueryMergeKeep:
+		case mode == QueryMergeAppend:
+			dst[key] = append(dst[key], values...)
+		default:
+			dst[key] = values
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package redirect
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/hostname"
+)
+
+// resolveDomain returns the custom domain the request came to.
+// The default domain and hosts nobody registered resolve to the default
+// namespace: a Domain with empty Name and no settings.
+func resolveDomain(r *http.Request, urlGetter URLGetter, defaultDomain string) (storage.Domain, error) {
+	host := hostname.Normalize(r.Host)
+	if host == "" || host == hostname.Normalize(defaultDomain) {
+		return storage.Domain{}, nil
+	}
+
+	domain, err := urlGetter.GetDomain(host)
+	if errors.Is(err, storage.ErrDomainNotFound) {
+		return storage.Domain{}, nil
+	}
+	if err != nil {
+		return storage.Domain{}, err
+	}
+
+	return domain, nil
+}
+
+// NewRoot handles the domain root (GET /): redirects to the root URL of the
+// request domain or of the config, or renders the landing page.
+func NewRoot(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewRoot"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		domain, err := resolveDomain(r, urlGetter, opts.DefaultDomain)
+		if err != nil {
+			log.Error("failed to get domain", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		target := domain.RootURL
+		if target == "" {
+			target = opts.RootURL
+		}
+		if target != "" {
+			log.Info("redirecting domain root", slog.String("domain", domain.Name))
+			w.Header().Set("Cache-Control", "private, no-store")
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+
+		if opts.RootPage == nil {
+			notFound(w, r, log, domain, opts)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := opts.RootPage.Execute(w, pageData{Host: r.Host}); err != nil {
+			log.Error("failed to render landing page", slog.Any("error", err))
+		}
+	}
+}
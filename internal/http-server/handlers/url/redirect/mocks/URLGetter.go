@@ -2,34 +2,132 @@
 
 package mocks
 
-import mock "github.com/stretchr/testify/mock"
+import (
+	mock "github.com/stretchr/testify/mock"
+
+	storage "urlshortener/internal/storage"
+)
 
 // URLGetter is an autogenerated mock type for the URLGetter type
 type URLGetter struct {
 	mock.Mock
 }
 
-// GetURL provides a mock function with given fields: alias
-func (_m *URLGetter) GetURL(alias string) (string, error) {
-	ret := _m.Called(alias)
+// BurnURL provides a mock function with given fields: domain, alias, variant, e
+func (_m *URLGetter) BurnURL(domain string, alias string, variant string, e storage.AuditEntry) error {
+	ret := _m.Called(domain, alias, variant, e)
 
 	if len(ret) == 0 {
-		panic("no return value specified for GetURL")
+		panic("no return value specified for BurnURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string, storage.AuditEntry) error); ok {
+		r0 = rf(domain, alias, variant, e)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CountClick provides a mock function with given fields: domain, alias, variant
+func (_m *URLGetter) CountClick(domain string, alias string, variant string) error {
+	ret := _m.Called(domain, alias, variant)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, string, string) error); ok {
+		r0 = rf(domain, alias, variant)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetCampaignParams provides a mock function with given fields: name
+func (_m *URLGetter) GetCampaignParams(name string) (map[string]string, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCampaignParams")
+	}
+
+	var r0 map[string]string
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (map[string]string, error)); ok {
+		return rf(name)
+	}
+	if rf, ok := ret.Get(0).(func(string) map[string]string); ok {
+		r0 = rf(name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]string)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDomain provides a mock function with given fields: name
+func (_m *URLGetter) GetDomain(name string) (storage.Domain, error) {
+	ret := _m.Called(name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDomain")
 	}
 
-	var r0 string
+	var r0 storage.Domain
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (string, error)); ok {
-		return rf(alias)
+	if rf, ok := ret.Get(0).(func(string) (storage.Domain, error)); ok {
+		return rf(name)
 	}
-	if rf, ok := ret.Get(0).(func(string) string); ok {
-		r0 = rf(alias)
+	if rf, ok := ret.Get(0).(func(string) storage.Domain); ok {
+		r0 = rf(name)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(storage.Domain)
 	}
 
 	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(alias)
+		r1 = rf(name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURL provides a mock function with given fields: domain, alias
+func (_m *URLGetter) GetURL(domain string, alias string) (storage.URL, error) {
+	ret := _m.Called(domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (storage.URL, error)); ok {
+		return rf(domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) storage.URL); ok {
+		r0 = rf(domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(domain, alias)
 	} else {
 		r1 = ret.Error(1)
 	}
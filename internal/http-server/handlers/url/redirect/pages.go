@@ -0,0 +1,87 @@
+package redirect
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+var notFoundTmpl = template.Must(template.ParseFS(templates, "templates/not_found.html"))
+
+// pageData is passed to the not found and landing templates.
+type pageData struct {
+	// Host is the domain the request came to.
+	Host string
+	// Alias is empty on the root and on unknown paths.
+	Alias string
+}
+
+// wantsHTML reports whether the client is a browser rather than an API
+// client: browsers list text/html in Accept, API clients send
+// application/json or */*.
+func wantsHTML(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "text/html")
+}
+
+// notFound responds to an unknown alias. API clients get JSON, browsers are
+// redirected to the not found URL of the domain or of the config if there is
+// one, or get the HTML page.
+func notFound(w http.ResponseWriter, r *http.Request, log *slog.Logger, domain storage.Domain, opts Options) {
+	if !wantsHTML(r) {
+		resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
+		return
+	}
+
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	target := domain.NotFoundURL
+	if target == "" {
+		target = opts.NotFoundURL
+	}
+	if target != "" {
+		http.Redirect(w, r, target, http.StatusFound)
+		return
+	}
+
+	tmpl := opts.NotFoundPage
+	if tmpl == nil {
+		tmpl = notFoundTmpl
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+
+	err := tmpl.Execute(w, pageData{Host: r.Host, Alias: chi.URLParam(r, "alias")})
+	if err != nil {
+		log.Error("failed to render not found page", slog.Any("error", err))
+	}
+}
+
+// NewNotFound responds to paths no route matches the same way
+// as to unknown aliases.
+func NewNotFound(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewNotFound"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		domain, err := resolveDomain(r, urlGetter, opts.DefaultDomain)
+		if err != nil {
+			log.Error("failed to get domain", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		notFound(w, r, log, domain, opts)
+	}
+}
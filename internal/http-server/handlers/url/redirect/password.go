@@ -0,0 +1,92 @@
+package redirect
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"html/template"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+//go:embed templates
+var templates embed.FS
+
+var passwordTmpl = template.Must(template.ParseFS(templates, "templates/password.html"))
+
+// unlockCookie remembers a correct password. It is scoped to the alias path.
+const unlockCookie = "unlock"
+
+// unlocked reports whether the request carries a valid unlock cookie for link.
+func unlocked(r *http.Request, link storage.URL, secret []byte) bool {
+	c, err := r.Cookie(unlockCookie)
+	if err != nil {
+		return false
+	}
+
+	expires, sig, ok := strings.Cut(c.Value, ".")
+	if !ok {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > unix {
+		return false
+	}
+
+	return hmac.Equal([]byte(sig), []byte(unlockSignature(link, expires, secret)))
+}
+
+// setUnlockCookie remembers the unlock for ttl.
+func setUnlockCookie(w http.ResponseWriter, link storage.URL, secret []byte, ttl time.Duration) {
+	expiresAt := time.Now().Add(ttl)
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     unlockCookie,
+		Value:    expires + "." + unlockSignature(link, expires, secret),
+		Path:     "/" + link.Alias,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// unlockSignature signs alias and expiry. The password hash is part of
+// the signature, so changing the password invalidates issued cookies.
+func unlockSignature(link storage.URL, expires string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(link.Alias + "\n" + expires + "\n" + link.PasswordHash))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func renderPasswordForm(w http.ResponseWriter, status int, alias string, errMsg string) error {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(status)
+
+	return passwordTmpl.Execute(w, struct {
+		Alias string
+		Error string
+	}{
+		Alias: alias,
+		Error: errMsg,
+	})
+}
+
+// clientIP returns the host part of RemoteAddr.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,120 @@
+package redirect
+
+import (
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/storage"
+)
+
+var previewTmpl = template.Must(template.ParseFS(templates, "templates/preview.html"))
+
+// previewedCookie lets the continue button of the preview page through
+// when previews are shown by default. It is scoped to the alias path.
+const (
+	previewedCookie = "previewed"
+	previewedTTL    = 5 * time.Minute
+)
+
+// NewPreview shows where the link leads instead of redirecting (GET /{alias}+).
+func NewPreview(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.redirect.NewPreview"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		link, ok := lookup(w, r, log, urlGetter, opts)
+		if !ok {
+			return
+		}
+
+		log.Info("showing preview", slog.String("alias", link.Alias))
+
+		// the continue button must not show the preview again
+		// if it is on by default
+		setPreviewedCookie(w, link.Alias)
+
+		if err := renderPreview(w, link); err != nil {
+			log.Error("failed to render preview", slog.Any("error", err))
+		}
+	}
+}
+
+// hiddenReason tells why the preview must not show the destination of
+// link, empty if it can. Previews don't count clicks, so showing the
+// destination of a limited or inactive link would get around the limit.
+func hiddenReason(link storage.URL, now time.Time) string {
+	switch {
+	case link.PasswordHash != "":
+		return "the link is password protected"
+	case link.Burn:
+		return "the link can be opened only once"
+	case link.MaxClicks > 0:
+		return "the link can be opened a limited number of times"
+	case linkActivity(link, now) != active:
+		return "the link is not active"
+	}
+
+	return ""
+}
+
+func renderPreview(w http.ResponseWriter, link storage.URL) error {
+	hidden := hiddenReason(link, time.Now())
+
+	var domain string
+	if u, err := url.Parse(link.URL); err == nil && hidden == "" {
+		domain = u.Hostname()
+	}
+	destination := link.URL
+	if hidden != "" {
+		destination = ""
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-store")
+
+	return previewTmpl.Execute(w, struct {
+		Alias       string
+		Destination string
+		Domain      string
+		Hidden      string
+		Targeted    bool
+		CreatedAt   time.Time
+		Clicks      int64
+		ContinueURL string
+	}{
+		Alias:       link.Alias,
+		Destination: destination,
+		Domain:      domain,
+		Hidden:      hidden,
+		Targeted:    len(link.Rules) > 0 || len(link.Variants) > 0,
+		CreatedAt:   link.CreatedAt,
+		Clicks:      link.Clicks,
+		ContinueURL: "/" + url.PathEscape(link.Alias),
+	})
+}
+
+func previewed(r *http.Request) bool {
+	_, err := r.Cookie(previewedCookie)
+
+	return err == nil
+}
+
+func setPreviewedCookie(w http.ResponseWriter, alias string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     previewedCookie,
+		Value:    "1",
+		Path:     "/" + alias,
+		MaxAge:   int(previewedTTL.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
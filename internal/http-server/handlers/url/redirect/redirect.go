@@ -2,16 +2,21 @@ package redirect
 
 import (
 	"errors"
+	"fmt"
+	"html/template"
 	"net/http"
+	"time"
 
 	"log/slog"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
-	"github.com/go-chi/render"
+	"golang.org/x/crypto/bcrypt"
 
 	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/ratelimit"
 
+	"urlshortener/internal/audit"
 	"urlshortener/internal/storage"
 )
 
@@ -19,10 +24,84 @@ import (
 //
 //go:generate go run github.com/vektra/mockery/v2@latest --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetURL(domain string, alias string) (storage.URL, error)
+	GetCampaignParams(name string) (map[string]string, error)
+	CountClick(domain string, alias string, variant string) error
+	BurnURL(domain string, alias string, variant string, e storage.AuditEntry) error
+	GetDomain(name string) (storage.Domain, error)
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// Options configures the redirect handler.
+type Options struct {
+	// DefaultStatus is used for links without their own redirect type.
+	// Falls back to 302 Found if not set or not a redirect status.
+	DefaultStatus int
+	// PermanentMaxAge is how long clients may cache 301/308 redirects.
+	PermanentMaxAge time.Duration
+	// QueryMerge resolves query keys present both in the request and
+	// in the destination of a passthrough link. Defaults to override.
+	QueryMerge QueryMerge
+	// UnlockSecret signs cookies remembering a correct link password.
+	UnlockSecret []byte
+	// UnlockTTL is how long a correct link password is remembered.
+	UnlockTTL time.Duration
+	// PasswordAttempts is the number of wrong passwords allowed per link
+	// and client IP within PasswordAttemptsWindow.
+	PasswordAttempts       int
+	PasswordAttemptsWindow time.Duration
+	// NotYetActiveStatus and NotYetActiveMessage are returned before the
+	// activation window of a link without pending URL.
+	// Default to 404 and "link is not active yet".
+	NotYetActiveStatus  int
+	NotYetActiveMessage string
+	// VariantTTL is how long a visitor stays on the same A/B variant.
+	VariantTTL time.Duration
+	// PreviewByDefault shows the preview page instead of redirecting,
+	// the continue button on it leads to the redirect.
+	PreviewByDefault bool
+	// DefaultDomain is the main short domain. Links are resolved in the
+	// namespace of the request host if it is a registered custom domain,
+	// in the default one otherwise.
+	DefaultDomain string
+	// NotFoundURL is where browsers are redirected on unknown aliases
+	// of domains without their own. NotFoundPage is rendered if empty,
+	// the built-in page if nil.
+	NotFoundURL  string
+	NotFoundPage *template.Template
+	// RootURL is where the root of domains without their own redirects.
+	// RootPage is rendered if empty, 404 if nil.
+	RootURL  string
+	RootPage *template.Template
+}
+
+// IsRedirectStatus reports whether code can be used as a link redirect type.
+func IsRedirectStatus(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+
+	return false
+}
+
+func New(log *slog.Logger, urlGetter URLGetter, opts Options) http.HandlerFunc {
+	defaultStatus := opts.DefaultStatus
+	if !IsRedirectStatus(defaultStatus) {
+		defaultStatus = http.StatusFound
+	}
+
+	attempts := ratelimit.New(opts.PasswordAttempts, opts.PasswordAttemptsWindow)
+
+	notYetActiveStatus := opts.NotYetActiveStatus
+	if notYetActiveStatus == 0 {
+		notYetActiveStatus = http.StatusNotFound
+	}
+	notYetActiveMessage := opts.NotYetActiveMessage
+	if notYetActiveMessage == "" {
+		notYetActiveMessage = "link is not active yet"
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
@@ -31,31 +110,260 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			slog.String("request_id", middleware.GetReqID(r.Context())),
 		)
 
-		alias := chi.URLParam(r, "alias")
-		if alias == "" {
-			log.Info("alias is empty")
-			w.WriteHeader(http.StatusBadRequest)
-			render.JSON(w, r, resp.Error("alias is required"))
+		link, ok := lookup(w, r, log, urlGetter, opts)
+		if !ok {
+			return
+		}
+		alias := link.Alias
+
+		if hasCard(link) {
+			// crawlers get the card, humans the redirect
+			w.Header().Add("Vary", "User-Agent")
+
+			if wantsCard(r) {
+				log.Info("showing card", slog.String("alias", alias), slog.String("user_agent", r.UserAgent()))
+				if err := renderCard(w, r, link); err != nil {
+					log.Error("failed to render card", slog.Any("error", err))
+				}
+				return
+			}
+		}
+
+		if opts.PreviewByDefault && r.Method == http.MethodGet && !previewed(r) {
+			log.Info("showing preview", slog.String("alias", alias))
+			setPreviewedCookie(w, alias)
+			if err := renderPreview(w, link); err != nil {
+				log.Error("failed to render preview", slog.Any("error", err))
+			}
+			return
+		}
+
+		var err error
+
+		switch linkActivity(link, time.Now()) {
+		case pending:
+			if link.PendingURL != "" {
+				log.Info("link is not active yet, redirecting to pending url", slog.String("alias", alias))
+				w.Header().Set("Cache-Control", "private, no-store")
+				http.Redirect(w, r, link.PendingURL, http.StatusFound)
+				return
+			}
+
+			log.Info("link is not active yet", slog.String("alias", alias))
+			resp.RenderError(w, r, notYetActiveStatus, resp.Error(notYetActiveMessage))
+			return
+		case ended:
+			if link.FallbackURL != "" {
+				log.Info("link is no longer active, redirecting to fallback url", slog.String("alias", alias))
+				w.Header().Set("Cache-Control", "private, no-store")
+				http.Redirect(w, r, link.FallbackURL, http.StatusFound)
+				return
+			}
+
+			log.Info("link is no longer active", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusGone, resp.Error("link is no longer available"))
+			return
+		}
+
+		status := link.RedirectType
+		if !IsRedirectStatus(status) {
+			status = defaultStatus
+		}
+		cache := cacheControl(status, opts.PermanentMaxAge)
+
+		if link.MaxClicks > 0 || link.Burn || !link.ActiveUntil.IsZero() || len(link.Variants) > 0 {
+			// every click has to reach the server to be counted,
+			// links with a window change destination when it ends
+			cache = "private, no-store"
+		}
+
+		if link.PasswordHash != "" {
+			// browsers must ask again once the cookie expires
+			cache = "private, no-store"
+
+			if !unlocked(r, link, opts.UnlockSecret) {
+				if r.Method != http.MethodPost {
+					log.Info("password required", slog.String("alias", alias))
+					if err := renderPasswordForm(w, http.StatusOK, alias, ""); err != nil {
+						log.Error("failed to render password form", slog.Any("error", err))
+					}
+					return
+				}
+
+				key := alias + "|" + clientIP(r)
+				if !attempts.Allow(key) {
+					log.Info("too many password attempts", slog.String("alias", alias))
+					if err := renderPasswordForm(w, http.StatusTooManyRequests, alias, "Too many attempts, try again later"); err != nil {
+						log.Error("failed to render password form", slog.Any("error", err))
+					}
+					return
+				}
+
+				password := r.PostFormValue("password")
+				if err := bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)); err != nil {
+					attempts.Hit(key)
+					log.Info("wrong password", slog.String("alias", alias))
+					if err := renderPasswordForm(w, http.StatusUnauthorized, alias, "Wrong password"); err != nil {
+						log.Error("failed to render password form", slog.Any("error", err))
+					}
+					return
+				}
+
+				attempts.Reset(key)
+				setUnlockCookie(w, link, opts.UnlockSecret, opts.UnlockTTL)
+				// browser must follow with GET instead of repeating the form POST
+				status = http.StatusSeeOther
+			}
+		}
+
+		params, err := linkParams(urlGetter, link)
+		if err != nil {
+			log.Error("failed to get campaign params", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+			return
+		}
+
+		if len(link.Rules) > 0 {
+			w.Header().Add("Vary", "User-Agent, Accept-Language")
+		}
+
+		// targeting rules first, then A/B variants, then the link URL
+		target := link.URL
+		var variant string
+		if rule, ok := matchRule(r, link.Rules); ok {
+			target = rule.URL
+		} else if len(link.Variants) > 0 {
+			v, sticky := pickVariant(r, link.Variants)
+			if !sticky {
+				setVariantCookie(w, alias, v.Name, opts.VariantTTL)
+			}
+			target = v.URL
+			variant = v.Name
+		}
+
+		destination, err := injectParams(target, params)
+		if err != nil {
+			log.Error("failed to inject params", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
 			return
 		}
 
-		resURL, err := urlGetter.GetURL(alias)
-		if errors.Is(err, storage.ErrUrlNotFound) {
-			log.Info("url not found", slog.String("alias", alias))
-			w.WriteHeader(http.StatusNotFound)
-			render.JSON(w, r, resp.Error("not found"))
+		rest := restPath(r.URL.Path)
+		if link.Passthrough {
+			destination, err = passthrough(destination, rest, r.URL.Query(), opts.QueryMerge)
+			if err != nil {
+				log.Error("failed to build passthrough url", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+				return
+			}
+		} else if rest != "" {
+			log.Info("passthrough is disabled", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("not found"))
 			return
 		}
 
+		if link.Burn {
+			// only one of concurrent visitors manages to delete the link
+			err = urlGetter.BurnURL(link.Domain, alias, variant, audit.NewEntry(r, storage.AuditDelete,
+				storage.AuditEntityURL, link.Domain, alias, audit.NewLink(link), nil))
+		} else {
+			err = urlGetter.CountClick(link.Domain, alias, variant)
+		}
+		// the link may have been used up, deleted or burnt since lookup
+		if errors.Is(err, storage.ErrClicksExhausted) || errors.Is(err, storage.ErrUrlNotFound) ||
+			errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("link is used up", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusGone, resp.Error("link is no longer available"))
+			return
+		}
 		if err != nil {
-			log.Error("failed to get url", slog.Any("error", err))
-			w.WriteHeader(http.StatusInternalServerError)
-			render.JSON(w, r, resp.Error("internal error"))
+			log.Error("failed to count click", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
+		log.Info("got url", slog.String("url", destination), slog.Int("status", status),
+			slog.String("variant", variant))
+
+		w.Header().Set("Cache-Control", cache)
 		// redirect to found url
-		http.Redirect(w, r, resURL, http.StatusFound)
+		http.Redirect(w, r, destination, status)
+	}
+}
+
+// lookup finds the link of the alias URL param on the request domain.
+// On failure it writes the error response and returns false.
+func lookup(w http.ResponseWriter, r *http.Request, log *slog.Logger, urlGetter URLGetter, opts Options) (storage.URL, bool) {
+	alias := chi.URLParam(r, "alias")
+	if alias == "" {
+		log.Info("alias is empty")
+		resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+		return storage.URL{}, false
+	}
+
+	domain, err := resolveDomain(r, urlGetter, opts.DefaultDomain)
+	if err != nil {
+		log.Error("failed to get domain", slog.Any("error", err))
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+		return storage.URL{}, false
+	}
+
+	link, err := urlGetter.GetURL(domain.Name, alias)
+	if errors.Is(err, storage.ErrUrlNotFound) {
+		log.Info("url not found", slog.String("domain", domain.Name), slog.String("alias", alias))
+		notFound(w, r, log, domain, opts)
+		return storage.URL{}, false
+	}
+
+	if errors.Is(err, storage.ErrURLDeleted) {
+		log.Info("url deleted", slog.String("domain", domain.Name), slog.String("alias", alias))
+		resp.RenderError(w, r, http.StatusGone, resp.Error("link is no longer available"))
+		return storage.URL{}, false
+	}
+
+	if err != nil {
+		log.Error("failed to get url", slog.Any("error", err))
+		resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("internal error"))
+		return storage.URL{}, false
+	}
+
+	return link, true
+}
+
+// linkParams returns the query params to add to the link destination:
+// defaults of its campaign overridden by params of the link itself.
+func linkParams(urlGetter URLGetter, link storage.URL) (map[string]string, error) {
+	if link.Campaign == "" {
+		return link.Params, nil
+	}
+
+	campaignParams, err := urlGetter.GetCampaignParams(link.Campaign)
+	if errors.Is(err, storage.ErrCampaignNotFound) {
+		return link.Params, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	params := make(map[string]string, len(campaignParams)+len(link.Params))
+	for key, value := range campaignParams {
+		params[key] = value
+	}
+	for key, value := range link.Params {
+		params[key] = value
+	}
+
+	return params, nil
+}
+
+// cacheControl lets clients cache permanent redirects for maxAge.
+// Temporary ones are never cached, so every click reaches the server
+// and the link can be changed at any moment.
+func cacheControl(status int, maxAge time.Duration) string {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusPermanentRedirect:
+		return fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds()))
+	default:
+		return "private, no-store"
 	}
 }
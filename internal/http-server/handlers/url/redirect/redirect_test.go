@@ -3,9 +3,14 @@ package redirect_test
 import (
 	"context"
 	"encoding/json"
+	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 	"urlshortener/internal/http-server/handlers/url/redirect"
 	"urlshortener/internal/http-server/handlers/url/redirect/mocks"
 	"urlshortener/internal/storage"
@@ -13,7 +18,9 @@ import (
 	"urlshortener/lib/logger/handlers/slogdiscard"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func TestRedirectHandler(t *testing.T) {
@@ -25,6 +32,27 @@ func TestRedirectHandler(t *testing.T) {
 		mockError     error
 		mockCalled    bool
 		mockReturnURL string
+		redirectType  int
+		defaultStatus int
+		wantCache     string
+		path          string
+		passthrough   bool
+		queryMerge    redirect.QueryMerge
+		wantLocation  string
+		params        map[string]string
+		campaign      string
+		campaignParam map[string]string
+		campaignError error
+		maxClicks     int64
+		burn          bool
+		clickError    error
+		activeFrom    time.Time
+		activeUntil   time.Time
+		pendingURL    string
+		fallbackURL   string
+		rules         []storage.Rule
+		userAgent     string
+		language      string
 	}{
 		{
 			name:          "Redirect succes",
@@ -33,6 +61,44 @@ func TestRedirectHandler(t *testing.T) {
 			wantResponse:  response.Response{},
 			mockCalled:    true,
 			mockReturnURL: "https://example.com",
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Permanent redirect",
+			alias:         "permanent",
+			wantStatus:    http.StatusMovedPermanently,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			redirectType:  http.StatusMovedPermanently,
+			wantCache:     "public, max-age=3600",
+		},
+		{
+			name:          "Permanent redirect keeping method",
+			alias:         "permanent_308",
+			wantStatus:    http.StatusPermanentRedirect,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			redirectType:  http.StatusPermanentRedirect,
+			wantCache:     "public, max-age=3600",
+		},
+		{
+			name:          "Default from options",
+			alias:         "default_307",
+			wantStatus:    http.StatusTemporaryRedirect,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			defaultStatus: http.StatusTemporaryRedirect,
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Link type overrides default",
+			alias:         "override",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			redirectType:  http.StatusFound,
+			defaultStatus: http.StatusPermanentRedirect,
+			wantCache:     "private, no-store",
 		},
 		{
 			name:       "Empty alias",
@@ -55,6 +121,257 @@ func TestRedirectHandler(t *testing.T) {
 			mockCalled: true,
 			mockError:  storage.ErrUrlNotFound,
 		},
+		{
+			name:       "Deleted link",
+			alias:      "deleted",
+			wantStatus: http.StatusGone,
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is no longer available",
+			},
+			mockCalled: true,
+			mockError:  storage.ErrURLDeleted,
+		},
+		{
+			name:          "Passthrough path and query",
+			alias:         "docs",
+			path:          "/docs/guide/../intro/?x=1&z=3",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/base/?x=0&y=2",
+			passthrough:   true,
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/base/intro/?x=1&y=2&z=3",
+		},
+		{
+			name:          "Passthrough keeps destination query",
+			alias:         "docs",
+			path:          "/docs/a.html?x=1",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/?x=0",
+			passthrough:   true,
+			queryMerge:    redirect.QueryMergeKeep,
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/a.html?x=0",
+		},
+		{
+			name:          "Passthrough appends query",
+			alias:         "docs",
+			path:          "/docs?x=1",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/?x=0",
+			passthrough:   true,
+			queryMerge:    redirect.QueryMergeAppend,
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/?x=0&x=1",
+		},
+		{
+			name:          "Link params",
+			alias:         "utm",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/?id=1&utm_source=old",
+			params:        map[string]string{"utm_source": "newsletter"},
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/?id=1&utm_source=newsletter",
+		},
+		{
+			name:          "Campaign defaults overridden by link params",
+			alias:         "campaign",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/",
+			params:        map[string]string{"utm_source": "twitter"},
+			campaign:      "spring",
+			campaignParam: map[string]string{"utm_source": "default", "utm_campaign": "spring"},
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/?utm_campaign=spring&utm_source=twitter",
+		},
+		{
+			name:          "Unknown campaign",
+			alias:         "no_campaign",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com/",
+			campaign:      "unknown",
+			campaignError: storage.ErrCampaignNotFound,
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Click limited",
+			alias:         "limited",
+			wantStatus:    http.StatusMovedPermanently,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			redirectType:  http.StatusMovedPermanently,
+			maxClicks:     3,
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Clicks exhausted",
+			alias:         "exhausted",
+			wantStatus:    http.StatusGone,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			maxClicks:     3,
+			clickError:    storage.ErrClicksExhausted,
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is no longer available",
+			},
+		},
+		{
+			name:          "Deleted since lookup",
+			alias:         "deleted",
+			wantStatus:    http.StatusGone,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			clickError:    storage.ErrURLDeleted,
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is no longer available",
+			},
+		},
+		{
+			name:          "Burn after reading",
+			alias:         "burn",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			burn:          true,
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Burned by concurrent visitor",
+			alias:         "burned",
+			wantStatus:    http.StatusGone,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			burn:          true,
+			clickError:    storage.ErrUrlNotFound,
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is no longer available",
+			},
+		},
+		{
+			name:          "Inside activation window",
+			alias:         "window",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			activeFrom:    time.Now().Add(-time.Hour),
+			activeUntil:   time.Now().Add(time.Hour),
+			wantCache:     "private, no-store",
+		},
+		{
+			name:          "Not active yet",
+			alias:         "pending",
+			wantStatus:    http.StatusNotFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			activeFrom:    time.Now().Add(time.Hour),
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is not active yet",
+			},
+		},
+		{
+			name:          "Not active yet with pending url",
+			alias:         "pending_url",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			activeFrom:    time.Now().Add(time.Hour),
+			pendingURL:    "https://example.com/soon",
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/soon",
+		},
+		{
+			name:          "Window ended",
+			alias:         "ended",
+			wantStatus:    http.StatusGone,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			activeUntil:   time.Now().Add(-time.Hour),
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "link is no longer available",
+			},
+		},
+		{
+			name:          "Window ended with fallback",
+			alias:         "fallback",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			activeUntil:   time.Now().Add(-time.Hour),
+			fallbackURL:   "https://example.com/over",
+			wantCache:     "private, no-store",
+			wantLocation:  "https://example.com/over",
+		},
+		{
+			name:          "Rule by OS",
+			alias:         "app",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			rules: []storage.Rule{
+				{OS: "ios", URL: "https://apps.apple.com/app"},
+				{OS: "android", Visitor: "human", URL: "https://play.google.com/app"},
+				{Language: "ru", URL: "https://example.com/ru"},
+			},
+			userAgent:    "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) Mobile/15E148",
+			language:     "ru-RU,ru;q=0.9",
+			wantCache:    "private, no-store",
+			wantLocation: "https://apps.apple.com/app",
+		},
+		{
+			name:          "Rule by language",
+			alias:         "app",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			rules: []storage.Rule{
+				{OS: "ios", URL: "https://apps.apple.com/app"},
+				{OS: "android", Visitor: "human", URL: "https://play.google.com/app"},
+				{Language: "ru", URL: "https://example.com/ru"},
+			},
+			userAgent:    "Mozilla/5.0 (Windows NT 10.0; Win64; x64) Chrome/120.0",
+			language:     "en;q=0.5,ru-RU",
+			wantCache:    "private, no-store",
+			wantLocation: "https://example.com/ru",
+		},
+		{
+			name:          "No rule matches",
+			alias:         "app",
+			wantStatus:    http.StatusFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			rules: []storage.Rule{
+				{OS: "ios", URL: "https://apps.apple.com/app"},
+				{OS: "android", Visitor: "human", URL: "https://play.google.com/app"},
+				{Language: "ru", URL: "https://example.com/ru"},
+			},
+			userAgent:    "Mozilla/5.0 (Linux; Android 14; Googlebot) Mobile",
+			language:     "en",
+			wantCache:    "private, no-store",
+			wantLocation: "https://example.com",
+		},
+		{
+			name:          "Rest of path without passthrough",
+			alias:         "docs",
+			path:          "/docs/guide",
+			wantStatus:    http.StatusNotFound,
+			mockCalled:    true,
+			mockReturnURL: "https://example.com",
+			wantResponse: response.Response{
+				Status: response.StatusError,
+				Error:  "not found",
+			},
+		},
 	}
 
 	for _, tc := range cases {
@@ -64,15 +381,61 @@ func TestRedirectHandler(t *testing.T) {
 			urlRedirecterMock := mocks.NewURLGetter(t)
 
 			if tc.mockCalled {
-				urlRedirecterMock.On("GetURL", tc.alias).
-					Return(tc.mockReturnURL, tc.mockError).
+				urlRedirecterMock.On("GetURL", "", tc.alias).
+					Return(storage.URL{
+						Alias:        tc.alias,
+						URL:          tc.mockReturnURL,
+						RedirectType: tc.redirectType,
+						Passthrough:  tc.passthrough,
+						Params:       tc.params,
+						Campaign:     tc.campaign,
+						MaxClicks:    tc.maxClicks,
+						Burn:         tc.burn,
+						ActiveFrom:   tc.activeFrom,
+						ActiveUntil:  tc.activeUntil,
+						PendingURL:   tc.pendingURL,
+						FallbackURL:  tc.fallbackURL,
+						Rules:        tc.rules,
+					}, tc.mockError).
 					Once()
 			}
 
-			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlRedirecterMock)
+			usesWindowURL := tc.pendingURL != "" || tc.fallbackURL != ""
+			if (redirect.IsRedirectStatus(tc.wantStatus) && !usesWindowURL) || tc.clickError != nil {
+				if tc.burn {
+					urlRedirecterMock.On("BurnURL", "", tc.alias, "", mock.MatchedBy(func(e storage.AuditEntry) bool {
+						return e.Action == storage.AuditDelete && e.Alias == tc.alias && e.Actor == ""
+					})).
+						Return(tc.clickError).
+						Once()
+				} else {
+					urlRedirecterMock.On("CountClick", "", tc.alias, "").
+						Return(tc.clickError).
+						Once()
+				}
+			}
+
+			if tc.campaign != "" {
+				urlRedirecterMock.On("GetCampaignParams", tc.campaign).
+					Return(tc.campaignParam, tc.campaignError).
+					Once()
+			}
 
-			req, err := http.NewRequest(http.MethodGet, "/", nil)
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlRedirecterMock, redirect.Options{
+				DefaultStatus:   tc.defaultStatus,
+				PermanentMaxAge: time.Hour,
+				QueryMerge:      tc.queryMerge,
+			})
+
+			path := tc.path
+			if path == "" {
+				path = "/"
+			}
+
+			req, err := http.NewRequest(http.MethodGet, path, nil)
 			require.NoError(t, err)
+			req.Header.Set("User-Agent", tc.userAgent)
+			req.Header.Set("Accept-Language", tc.language)
 
 			rctx := chi.NewRouteContext()
 			rctx.URLParams.Add("alias", tc.alias)
@@ -84,8 +447,13 @@ func TestRedirectHandler(t *testing.T) {
 
 			require.Equal(t, tc.wantStatus, rr.Code)
 
-			if tc.wantStatus == http.StatusFound {
-				require.Equal(t, tc.mockReturnURL, rr.Header().Get("Location"))
+			if redirect.IsRedirectStatus(tc.wantStatus) {
+				wantLocation := tc.wantLocation
+				if wantLocation == "" {
+					wantLocation = tc.mockReturnURL
+				}
+				require.Equal(t, wantLocation, rr.Header().Get("Location"))
+				require.Equal(t, tc.wantCache, rr.Header().Get("Cache-Control"))
 			} else {
 				var resp response.Response
 				err = json.Unmarshal(rr.Body.Bytes(), &resp)
@@ -100,3 +468,470 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_Password(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	link := storage.URL{
+		Alias:        "protected",
+		URL:          "https://example.com",
+		RedirectType: http.StatusMovedPermanently,
+		PasswordHash: string(hash),
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", link.Alias).Return(link, nil)
+	urlGetterMock.On("CountClick", "", link.Alias, "").Return(nil)
+
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, redirect.Options{
+		UnlockSecret:           []byte("app secret"),
+		UnlockTTL:              time.Hour,
+		PasswordAttempts:       2,
+		PasswordAttemptsWindow: time.Hour,
+	})
+
+	do := func(method string, password string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		var body io.Reader
+		if method == http.MethodPost {
+			body = strings.NewReader(url.Values{"password": {password}}.Encode())
+		}
+
+		req, err := http.NewRequest(method, "/"+link.Alias, body)
+		require.NoError(t, err)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", link.Alias)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// form instead of redirect
+	rr := do(http.MethodGet, "")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Header().Get("Content-Type"), "text/html")
+	require.Contains(t, rr.Body.String(), `name="password"`)
+
+	rr = do(http.MethodPost, "wrong")
+	require.Equal(t, http.StatusUnauthorized, rr.Code)
+	require.Empty(t, rr.Result().Cookies())
+
+	// correct password redirects and sets the unlock cookie
+	rr = do(http.MethodPost, "secret")
+	require.Equal(t, http.StatusSeeOther, rr.Code)
+	require.Equal(t, link.URL, rr.Header().Get("Location"))
+	require.Equal(t, "private, no-store", rr.Header().Get("Cache-Control"))
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	// cookie skips the form
+	rr = do(http.MethodGet, "", cookies...)
+	require.Equal(t, http.StatusMovedPermanently, rr.Code)
+	require.Equal(t, link.URL, rr.Header().Get("Location"))
+
+	// forged cookie is ignored
+	forged := *cookies[0]
+	forged.Value = "9999999999.deadbeef"
+	rr = do(http.MethodGet, "", &forged)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	// wrong attempts are limited
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "wrong").Code)
+	require.Equal(t, http.StatusUnauthorized, do(http.MethodPost, "wrong").Code)
+	require.Equal(t, http.StatusTooManyRequests, do(http.MethodPost, "secret").Code)
+}
+
+func TestRedirectHandler_Variants(t *testing.T) {
+	link := storage.URL{
+		Alias: "ab",
+		URL:   "https://example.com",
+		Variants: []storage.Variant{
+			{Name: "A", URL: "https://example.com/a", Weight: 1},
+			{Name: "B", URL: "https://example.com/b", Weight: 3},
+			{Name: "off", URL: "https://example.com/off", Weight: 0},
+		},
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", link.Alias).Return(link, nil)
+	urlGetterMock.On("CountClick", "", link.Alias, mock.Anything).Return(nil)
+
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, redirect.Options{
+		VariantTTL: time.Hour,
+	})
+
+	do := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/"+link.Alias, nil)
+		require.NoError(t, err)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", link.Alias)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	seen := map[string]int{}
+	for i := 0; i < 200; i++ {
+		rr := do()
+		require.Equal(t, http.StatusFound, rr.Code)
+		seen[rr.Header().Get("Location")]++
+
+		cookies := rr.Result().Cookies()
+		require.Len(t, cookies, 1)
+		require.Equal(t, "/"+link.Alias, cookies[0].Path)
+	}
+	require.Len(t, seen, 2)
+	require.Greater(t, seen["https://example.com/b"], seen["https://example.com/a"])
+
+	// the cookie keeps the visitor on the variant
+	for i := 0; i < 10; i++ {
+		rr := do(&http.Cookie{Name: "variant", Value: "A"})
+		require.Equal(t, "https://example.com/a", rr.Header().Get("Location"))
+		require.Empty(t, rr.Result().Cookies())
+	}
+
+	urlGetterMock.AssertCalled(t, "CountClick", "", link.Alias, "A")
+	urlGetterMock.AssertNotCalled(t, "CountClick", "", link.Alias, "off")
+}
+
+func TestPreviewHandler(t *testing.T) {
+	link := storage.URL{
+		Alias:     "pv",
+		URL:       "https://example.com/some/page?q=1",
+		Clicks:    42,
+		CreatedAt: time.Date(2024, 5, 17, 10, 0, 0, 0, time.UTC),
+	}
+	protected := storage.URL{
+		Alias:        "secret",
+		URL:          "https://example.com/hidden",
+		PasswordHash: "hash",
+	}
+
+	burn := storage.URL{
+		Alias: "once",
+		URL:   "https://example.com/burn-target",
+		Burn:  true,
+	}
+	limited := storage.URL{
+		Alias:     "limited",
+		URL:       "https://example.com/limited-target",
+		MaxClicks: 3,
+	}
+	pending := storage.URL{
+		Alias:      "soon",
+		URL:        "https://example.com/pending-target",
+		ActiveFrom: time.Now().Add(time.Hour),
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", link.Alias).Return(link, nil)
+	urlGetterMock.On("GetURL", "", protected.Alias).Return(protected, nil)
+	urlGetterMock.On("GetURL", "", burn.Alias).Return(burn, nil)
+	urlGetterMock.On("GetURL", "", limited.Alias).Return(limited, nil)
+	urlGetterMock.On("GetURL", "", pending.Alias).Return(pending, nil)
+	urlGetterMock.On("GetURL", "", "missing").Return(storage.URL{}, storage.ErrUrlNotFound)
+	urlGetterMock.On("CountClick", "", link.Alias, "").Return(nil).Once()
+
+	preview := redirect.NewPreview(slogdiscard.NewDiscardLogger(), urlGetterMock, redirect.Options{})
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, redirect.Options{
+		PreviewByDefault: true,
+	})
+
+	do := func(h http.HandlerFunc, alias string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+		require.NoError(t, err)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", alias)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	rr := do(preview, link.Alias)
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	require.Contains(t, body, "example.com")
+	require.Contains(t, body, "2024-05-17")
+	require.Contains(t, body, "42")
+	require.Contains(t, body, `href="/pv"`)
+
+	rr = do(preview, protected.Alias)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotContains(t, rr.Body.String(), "/hidden")
+
+	// previews don't count clicks, so limited and inactive links
+	// must not give their destination away
+	for _, l := range []storage.URL{burn, limited, pending} {
+		rr = do(preview, l.Alias)
+		require.Equal(t, http.StatusOK, rr.Code, l.Alias)
+		require.NotContains(t, rr.Body.String(), "example.com", l.Alias)
+		require.Contains(t, rr.Body.String(), "Hidden", l.Alias)
+
+		rr = do(handler, l.Alias)
+		require.Equal(t, http.StatusOK, rr.Code, l.Alias)
+		require.NotContains(t, rr.Body.String(), "example.com", l.Alias)
+	}
+
+	rr = do(preview, "missing")
+	require.Equal(t, http.StatusNotFound, rr.Code)
+
+	// preview by default: the first visit shows the preview and sets the cookie,
+	// the continue button redirects
+	rr = do(handler, link.Alias)
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Empty(t, rr.Header().Get("Location"))
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, "/"+link.Alias, cookies[0].Path)
+
+	rr = do(handler, link.Alias, cookies[0])
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, link.URL, rr.Header().Get("Location"))
+}
+
+func TestRedirectHandler_Card(t *testing.T) {
+	link := storage.URL{
+		Alias:       "og",
+		URL:         "https://example.com/article",
+		Title:       "Launch <day>",
+		Description: "Everything we shipped",
+		Image:       "https://example.com/cover.png",
+		Burn:        true,
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", link.Alias).Return(link, nil)
+	urlGetterMock.On("BurnURL", "", link.Alias, "", mock.Anything).Return(nil).Once()
+
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, redirect.Options{})
+
+	do := func(ua string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/"+link.Alias, nil)
+		require.NoError(t, err)
+		req.Header.Set("User-Agent", ua)
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", link.Alias)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	// unfurling must not burn the link
+	for _, ua := range []string{"Twitterbot/1.0", "facebookexternalhit/1.1", "Slackbot-LinkExpanding 1.0"} {
+		rr := do(ua)
+		require.Equal(t, http.StatusOK, rr.Code)
+		require.Contains(t, rr.Header().Get("Vary"), "User-Agent")
+
+		body := rr.Body.String()
+		require.Contains(t, body, `<meta property="og:title" content="Launch &lt;day&gt;">`)
+		require.Contains(t, body, `<meta property="og:description" content="Everything we shipped">`)
+		require.Contains(t, body, `<meta property="og:image" content="https://example.com/cover.png">`)
+		require.Contains(t, body, `<meta name="twitter:card" content="summary_large_image">`)
+		require.NotContains(t, body, link.URL)
+	}
+
+	rr := do("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36")
+	require.Equal(t, http.StatusFound, rr.Code)
+	require.Equal(t, link.URL, rr.Header().Get("Location"))
+	require.Contains(t, rr.Header().Get("Vary"), "User-Agent")
+}
+
+func TestRedirectHandler_Domains(t *testing.T) {
+	brand := storage.Domain{
+		Name:        "go.brand.com",
+		Owner:       "alice",
+		NotFoundURL: "https://brand.com/404",
+		RootURL:     "https://brand.com",
+	}
+
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetDomain", brand.Name).Return(brand, nil)
+	urlGetterMock.On("GetDomain", "unknown.com").Return(storage.Domain{}, storage.ErrDomainNotFound)
+	urlGetterMock.On("GetURL", "", "promo").Return(storage.URL{Alias: "promo", URL: "https://example.com/default"}, nil)
+	urlGetterMock.On("GetURL", brand.Name, "promo").Return(storage.URL{Domain: brand.Name, Alias: "promo", URL: "https://brand.com/promo"}, nil)
+	urlGetterMock.On("GetURL", brand.Name, "missing").Return(storage.URL{}, storage.ErrUrlNotFound)
+	urlGetterMock.On("CountClick", mock.Anything, "promo", "").Return(nil)
+
+	opts := redirect.Options{DefaultDomain: "sho.rt"}
+	handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, opts)
+	root := redirect.NewRoot(slogdiscard.NewDiscardLogger(), urlGetterMock, opts)
+
+	do := func(h http.HandlerFunc, host, alias string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+		require.NoError(t, err)
+		req.Host = host
+		// browsers are redirected to the not found URL of the domain
+		req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("alias", alias)
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	cases := []struct {
+		name         string
+		handler      http.HandlerFunc
+		host         string
+		alias        string
+		wantStatus   int
+		wantLocation string
+	}{
+		{"default domain", handler, "Sho.rt:443", "promo", http.StatusFound, "https://example.com/default"},
+		{"unregistered host", handler, "unknown.com", "promo", http.StatusFound, "https://example.com/default"},
+		{"custom domain", handler, "go.brand.com", "promo", http.StatusFound, "https://brand.com/promo"},
+		{"custom domain not found", handler, "GO.BRAND.COM", "missing", http.StatusFound, "https://brand.com/404"},
+		{"custom domain root", root, "go.brand.com", "", http.StatusFound, "https://brand.com"},
+		{"default domain root", root, "sho.rt", "", http.StatusNotFound, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := do(tc.handler, tc.host, tc.alias)
+			require.Equal(t, tc.wantStatus, rr.Code)
+			require.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+		})
+	}
+
+	urlGetterMock.AssertCalled(t, "CountClick", brand.Name, "promo", "")
+	urlGetterMock.AssertCalled(t, "CountClick", "", "promo", "")
+}
+
+func TestRedirectHandler_NotFoundPages(t *testing.T) {
+	urlGetterMock := mocks.NewURLGetter(t)
+	urlGetterMock.On("GetURL", "", "missing").Return(storage.URL{}, storage.ErrUrlNotFound)
+
+	custom := template.Must(template.New("404").Parse(`<p>No {{ .Alias }} on {{ .Host }}</p>`))
+	landing := template.Must(template.New("root").Parse(`<h1>Welcome to {{ .Host }}</h1>`))
+
+	cases := []struct {
+		name         string
+		opts         redirect.Options
+		root         bool
+		accept       string
+		wantStatus   int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:       "API client gets JSON",
+			accept:     "application/json",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `{"status":"Error","error":"not found"}`,
+		},
+		{
+			name:       "Browser gets built-in page",
+			accept:     "text/html,application/xhtml+xml,*/*;q=0.8",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "sho.rt/missing",
+		},
+		{
+			name:       "Browser gets custom page",
+			opts:       redirect.Options{NotFoundPage: custom},
+			accept:     "text/html",
+			wantStatus: http.StatusNotFound,
+			wantBody:   "<p>No missing on sho.rt</p>",
+		},
+		{
+			name:         "Browser is redirected to fallback",
+			opts:         redirect.Options{NotFoundURL: "https://example.com/404", NotFoundPage: custom},
+			accept:       "text/html",
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://example.com/404",
+		},
+		{
+			name:       "API client is not redirected to fallback",
+			opts:       redirect.Options{NotFoundURL: "https://example.com/404"},
+			accept:     "*/*",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `"error":"not found"`,
+		},
+		{
+			name:         "Root redirects to homepage",
+			opts:         redirect.Options{RootURL: "https://example.com", RootPage: landing},
+			root:         true,
+			wantStatus:   http.StatusFound,
+			wantLocation: "https://example.com",
+		},
+		{
+			name:       "Root serves landing page",
+			opts:       redirect.Options{RootPage: landing},
+			root:       true,
+			accept:     "text/html",
+			wantStatus: http.StatusOK,
+			wantBody:   "<h1>Welcome to sho.rt</h1>",
+		},
+		{
+			name:       "Root is not found by default",
+			root:       true,
+			accept:     "application/json",
+			wantStatus: http.StatusNotFound,
+			wantBody:   `"error":"not found"`,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.opts.DefaultDomain = "sho.rt"
+
+			alias := "missing"
+			handler := redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock, tc.opts)
+			if tc.root {
+				alias = ""
+				handler = redirect.NewRoot(slogdiscard.NewDiscardLogger(), urlGetterMock, tc.opts)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, "/"+alias, nil)
+			require.NoError(t, err)
+			req.Host = "sho.rt"
+			req.Header.Set("Accept", tc.accept)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			require.Equal(t, tc.wantLocation, rr.Header().Get("Location"))
+			require.Contains(t, rr.Body.String(), tc.wantBody)
+		})
+	}
+}
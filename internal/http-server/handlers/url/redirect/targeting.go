@@ -0,0 +1,46 @@
+package redirect
+
+import (
+	"net/http"
+
+	"urlshortener/internal/storage"
+	"urlshortener/lib/useragent"
+)
+
+// matchRule returns the first rule matching the client.
+func matchRule(r *http.Request, rules []storage.Rule) (storage.Rule, bool) {
+	if len(rules) == 0 {
+		return storage.Rule{}, false
+	}
+
+	client := useragent.Parse(r.UserAgent())
+	language := useragent.PreferredLanguage(r.Header.Get("Accept-Language"))
+
+	for _, rule := range rules {
+		if ruleMatches(rule, client, language) {
+			return rule, true
+		}
+	}
+
+	return storage.Rule{}, false
+}
+
+func ruleMatches(rule storage.Rule, client useragent.Info, language string) bool {
+	if rule.OS != "" && rule.OS != client.OS {
+		return false
+	}
+
+	if rule.Device != "" && rule.Device != client.Device {
+		return false
+	}
+
+	if rule.Visitor != "" && (rule.Visitor == "bot") != client.Bot {
+		return false
+	}
+
+	if rule.Language != "" && !useragent.MatchLanguage(rule.Language, language) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,54 @@
+package redirect
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// variantCookie keeps a visitor on the same variant. It is scoped to the alias path.
+const variantCookie = "variant"
+
+// pickVariant returns the variant the visitor was assigned before,
+// or picks one at random by weight. The bool reports whether the
+// assignment came from the cookie.
+func pickVariant(r *http.Request, variants []storage.Variant) (storage.Variant, bool) {
+	if c, err := r.Cookie(variantCookie); err == nil {
+		for _, v := range variants {
+			if v.Name == c.Value {
+				return v, true
+			}
+		}
+	}
+
+	total := 0
+	for _, v := range variants {
+		total += v.Weight
+	}
+	if total <= 0 {
+		return variants[0], false
+	}
+
+	n := rand.IntN(total)
+	for _, v := range variants {
+		if n < v.Weight {
+			return v, false
+		}
+		n -= v.Weight
+	}
+
+	return variants[len(variants)-1], false
+}
+
+func setVariantCookie(w http.ResponseWriter, alias string, name string, ttl time.Duration) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     variantCookie,
+		Value:    name,
+		Path:     "/" + alias,
+		MaxAge:   int(ttl.Seconds()),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
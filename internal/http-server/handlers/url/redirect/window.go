@@ -0,0 +1,30 @@
+package redirect
+
+import (
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+type activity int
+
+const (
+	active activity = iota
+	// pending links are not active yet
+	pending
+	// ended links are past their activation window
+	ended
+)
+
+// linkActivity places now relative to the activation window of link.
+func linkActivity(link storage.URL, now time.Time) activity {
+	if !link.ActiveFrom.IsZero() && now.Before(link.ActiveFrom) {
+		return pending
+	}
+
+	if !link.ActiveUntil.IsZero() && !now.Before(link.ActiveUntil) {
+		return ended
+	}
+
+	return active
+}
@@ -0,0 +1,69 @@
+package restore
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLRestorer
+type URLRestorer interface {
+	RestoreURL(domain string, alias string, e storage.AuditEntry) error
+}
+
+// Options configures the restore handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New brings back a deleted link (admin only).
+// Links of a custom domain are selected with the domain query param.
+func New(log *slog.Logger, urlRestorer URLRestorer, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.restore.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		// the storage records the link as it was deleted
+		err := urlRestorer.RestoreURL(domain, alias, audit.NewEntry(r, storage.AuditRestore, storage.AuditEntityURL,
+			domain, alias, nil, nil))
+		if errors.Is(err, storage.ErrUrlNotFound) {
+			log.Info("alias not found", slog.String("domain", domain), slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to restore url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to restore url"))
+			return
+		}
+
+		log.Info("restored alias", slog.String("domain", domain), slog.String("alias", alias))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,137 @@
+package rules
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/urlnorm"
+)
+
+type Request struct {
+	// Rules are evaluated in order on redirect, the first match wins.
+	// The limit keeps evaluation on redirect cheap.
+	Rules []Rule `json:"rules" validate:"max=20,dive"`
+}
+
+type Rule struct {
+	OS       string `json:"os,omitempty" validate:"omitempty,oneof=ios android windows macos linux chromeos"`
+	Device   string `json:"device,omitempty" validate:"omitempty,oneof=mobile tablet desktop"`
+	Visitor  string `json:"visitor,omitempty" validate:"omitempty,oneof=bot human"`
+	Language string `json:"language,omitempty" validate:"omitempty,bcp47_language_tag"`
+	URL      string `json:"url" validate:"required,url"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=RulesSaver
+type RulesSaver interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+	SaveRules(domain string, alias string, rules []storage.Rule, e storage.AuditEntry) error
+}
+
+// Options configures the rules handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New replaces targeting rules of a link of the caller. An empty list removes them.
+// Links of a custom domain are selected with the domain query param.
+func New(log *slog.Logger, rulesSaver RulesSaver, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.rules.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ValidationError(validateErr))
+			return
+		}
+
+		rules := make([]storage.Rule, 0, len(req.Rules))
+		for _, rule := range req.Rules {
+			// same destination policy as the link URL itself
+			if _, err := urlnorm.Normalize(rule.URL, urlnorm.Options{}); err != nil {
+				log.Info("invalid rule url", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid url"))
+				return
+			}
+
+			rules = append(rules, storage.Rule{
+				OS:       rule.OS,
+				Device:   rule.Device,
+				Visitor:  rule.Visitor,
+				Language: rule.Language,
+				URL:      rule.URL,
+			})
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		// the link before the change goes to the audit log
+		before, err := rulesSaver.GetURL(domain, alias)
+		if err == nil && before.Owner != auth.Principal(r.Context()) {
+			// links of other users are not found, like in list
+			err = storage.ErrUrlNotFound
+		}
+		if err == nil {
+			after := before
+			after.Rules = rules
+			err = rulesSaver.SaveRules(domain, alias, rules, audit.NewEntry(r, storage.AuditUpdate, storage.AuditEntityURL,
+				domain, alias, audit.NewLink(before), audit.NewLink(after)))
+		}
+		if errors.Is(err, storage.ErrUrlNotFound) || errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to save rules", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save rules"))
+			return
+		}
+
+		log.Info("rules saved", slog.String("alias", alias), slog.Int("count", len(rules)))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
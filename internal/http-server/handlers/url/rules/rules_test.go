@@ -0,0 +1,140 @@
+package rules_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/rules"
+	"urlshortener/internal/http-server/handlers/url/rules/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestRulesHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		alias      string
+		body       string
+		wantStatus int
+		wantError  string
+		mockRules  []storage.Rule
+		mockError  error
+		mockCalled bool
+		// owner of the stored link, the caller is alice
+		owner string
+	}{
+		{
+			name:       "Success",
+			alias:      "app",
+			body:       `{"rules": [{"os": "ios", "url": "https://apps.apple.com/app"}, {"language": "pt-BR", "visitor": "human", "url": "https://example.com/br"}]}`,
+			wantStatus: http.StatusOK,
+			mockRules: []storage.Rule{
+				{OS: "ios", URL: "https://apps.apple.com/app"},
+				{Language: "pt-BR", Visitor: "human", URL: "https://example.com/br"},
+			},
+			mockCalled: true,
+		},
+		{
+			name:       "Remove rules",
+			alias:      "app",
+			body:       `{"rules": []}`,
+			wantStatus: http.StatusOK,
+			mockRules:  []storage.Rule{},
+			mockCalled: true,
+		},
+		{
+			name:       "Unknown OS",
+			alias:      "app",
+			body:       `{"rules": [{"os": "symbian", "url": "https://example.com"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "field OS must be one of: ios android windows macos linux chromeos",
+		},
+		{
+			name:       "Invalid rule URL",
+			alias:      "app",
+			body:       `{"rules": [{"device": "mobile", "url": "not a url"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "field URL is not a valid URL",
+		},
+		{
+			name:       "Rule URL without host",
+			alias:      "app",
+			body:       `{"rules": [{"device": "mobile", "url": "javascript:alert(1)"}]}`,
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid url",
+		},
+		{
+			name:       "URL not found",
+			alias:      "missing",
+			body:       `{"rules": [{"os": "android", "url": "https://play.google.com/app"}]}`,
+			wantStatus: http.StatusNotFound,
+			wantError:  "url not found",
+			mockRules:  []storage.Rule{{OS: "android", URL: "https://play.google.com/app"}},
+			mockError:  storage.ErrUrlNotFound,
+			mockCalled: true,
+		},
+		{
+			name:       "Link of another user",
+			alias:      "other",
+			body:       `{"rules": []}`,
+			owner:      "bob",
+			wantStatus: http.StatusNotFound,
+			wantError:  "url not found",
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rulesSaverMock := mocks.NewRulesSaver(t)
+
+			if tc.owner == "" {
+				tc.owner = "alice"
+			}
+
+			if tc.mockCalled {
+				rulesSaverMock.On("GetURL", "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, URL: "https://example.com", Owner: tc.owner}, nil).
+					Once()
+				if tc.owner == "alice" {
+					rulesSaverMock.On("SaveRules", "", tc.alias, tc.mockRules, mock.MatchedBy(func(e storage.AuditEntry) bool {
+						return e.Action == storage.AuditUpdate && e.Alias == tc.alias
+					})).
+						Return(tc.mockError).
+						Once()
+				}
+			}
+
+			handler := rules.New(slogdiscard.NewDiscardLogger(), rulesSaverMock, rules.Options{DefaultDomain: "sho.rt"})
+
+			req, err := http.NewRequest(http.MethodPut, "/", strings.NewReader(tc.body))
+			require.NoError(t, err)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			req = req.WithContext(auth.WithPrincipal(context.WithValue(req.Context(), chi.RouteCtxKey, rctx), "alice"))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+		})
+	}
+}
@@ -5,10 +5,13 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
 	"urlshortener/internal/storage"
 	resp "urlshortener/lib/api/response"
-	"urlshortener/lib/random"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -18,6 +21,58 @@ import (
 type Request struct {
 	URL   string `json:"url" validate:"required,url"`
 	Alias string `json:"alias,omitempty"`
+	// RedirectType is the HTTP status used by the redirect,
+	// the default from config is used if empty
+	RedirectType int `json:"redirect_type,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	// Passthrough forwards /alias/rest/of/path?x=1 to the destination
+	// with the path appended and the query merged
+	Passthrough bool `json:"passthrough,omitempty"`
+	// Params are added to the destination query on redirect (utm_source, ...),
+	// the stored destination stays clean
+	Params map[string]string `json:"params,omitempty" validate:"dive,keys,required,endkeys"`
+	// Campaign links share default params set with PUT /campaign/{name}
+	Campaign string `json:"campaign,omitempty"`
+	// Password protects the link: visitors have to enter it before the redirect
+	Password string `json:"password,omitempty" validate:"omitempty,max=72"`
+	// MaxClicks limits the number of redirects, the link returns 410 Gone after that
+	MaxClicks int64 `json:"max_clicks,omitempty" validate:"omitempty,min=1"`
+	// Burn deletes the link after the first redirect
+	Burn bool `json:"burn,omitempty"`
+	// ActiveFrom and ActiveUntil limit when the link redirects to URL
+	ActiveFrom  time.Time `json:"active_from,omitempty"`
+	ActiveUntil time.Time `json:"active_until,omitempty" validate:"omitempty,gtfield=ActiveFrom"`
+	// PendingURL is used before ActiveFrom, FallbackURL after ActiveUntil
+	PendingURL  string `json:"pending_url,omitempty" validate:"omitempty,url"`
+	FallbackURL string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+	// Variants split traffic between destinations by weight (A/B test),
+	// visitors stay on their variant
+	Variants []Variant `json:"variants,omitempty" validate:"max=10,dive"`
+	// Title, Description and Image are shown when the link is unfurled
+	// by chat apps and social networks
+	Title       string `json:"title,omitempty" validate:"omitempty,max=200"`
+	Description string `json:"description,omitempty" validate:"omitempty,max=500"`
+	Image       string `json:"image,omitempty" validate:"omitempty,url"`
+	// Domain is a custom short domain registered by the user,
+	// aliases are unique per domain. The default domain if empty
+	Domain string `json:"domain,omitempty"`
+}
+
+// LogValue leaves the password out of the logs.
+func (r Request) LogValue() slog.Value {
+	// plain has no LogValue, slog would call this one again
+	type plain Request
+	if r.Password != "" {
+		r.Password = "[redacted]"
+	}
+
+	return slog.AnyValue(plain(r))
+}
+
+type Variant struct {
+	// Name defaults to A, B, C... by position
+	Name   string `json:"name,omitempty" validate:"omitempty,max=32,alphanum"`
+	URL    string `json:"url" validate:"required,url"`
+	Weight int    `json:"weight" validate:"required,min=1,max=1000"`
 }
 
 type Response struct {
@@ -25,15 +80,21 @@ type Response struct {
 	Alias string `json:"alias,omitempty"`
 }
 
-// TODO: move to config
-const aliasLength = 6
-
 //go:generate go run github.com/vektra/mockery/v2@latest --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) (int64, error)
+	links.Storage
 }
 
-func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
+// Options configures the save handler, see links.Options.
+type Options = links.Options
+
+// New creates links. Errors have a status and a resp.Code*: 400 for empty
+// or malformed bodies, 422 for invalid fields and reserved aliases, 403 for
+// domains of other users, 409 for taken aliases and 500 for failures on
+// our side.
+func New(log *slog.Logger, urlSaver URLSaver, opts Options) http.HandlerFunc {
+	saver := links.New(log, urlSaver, opts)
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
@@ -47,13 +108,13 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 		err := render.DecodeJSON(r.Body, &req)
 		if errors.Is(err, io.EOF) {
 			log.Error("request body is empty")
-			render.JSON(w, r, resp.Error("empty request"))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ErrorCode(resp.CodeEmptyRequest, "empty request"))
 			return
 		}
 
 		if err != nil {
 			log.Error("failed to decode request body", slog.Any("error", err))
-			render.JSON(w, r, resp.Error("failed to decode request"))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ErrorCode(resp.CodeInvalidJSON, "failed to decode request"))
 			return
 		}
 
@@ -68,33 +129,72 @@ func New(log *slog.Logger, urlSaver URLSaver) http.HandlerFunc {
 			log.Error("invalid request", slog.Any("error", err))
 
 			// Convert validator errors to client-friendly format and send response
-			render.JSON(w, r, resp.ValidationError(validateErr))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ValidationError(validateErr))
 			return
 		}
 
-		alias := req.Alias
-		if alias == "" {
-			alias = random.NewRandomString(aliasLength)
+		variants := make([]storage.Variant, 0, len(req.Variants))
+		for _, v := range req.Variants {
+			variants = append(variants, storage.Variant{Name: v.Name, URL: v.URL, Weight: v.Weight})
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
-		if errors.Is(err, storage.ErrURLExists) {
-			log.Info("url already exists", slog.Any("error", err))
-			render.JSON(w, r, resp.Error("url already exists"))
-			return
+		link := storage.URL{
+			Domain: req.Domain,
+			Alias:  req.Alias,
+			URL:    req.URL,
+			// Links belong to the user they were created by
+			Owner:        auth.Principal(r.Context()),
+			RedirectType: req.RedirectType,
+			Passthrough:  req.Passthrough,
+			Params:       req.Params,
+			Campaign:     req.Campaign,
+			MaxClicks:    req.MaxClicks,
+			Burn:         req.Burn,
+			ActiveFrom:   req.ActiveFrom,
+			ActiveUntil:  req.ActiveUntil,
+			PendingURL:   req.PendingURL,
+			FallbackURL:  req.FallbackURL,
+			Variants:     variants,
+			Title:        req.Title,
+			Description:  req.Description,
+			Image:        req.Image,
 		}
 
-		if err != nil {
+		alias, err := saver.Save(link, req.Password, audit.NewEntry(r, "", "", "", "", nil, nil))
+		switch {
+		case errors.Is(err, links.ErrInvalidURL):
+			log.Info("failed to normalize url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeInvalidURL, "invalid url"))
+			return
+		case errors.Is(err, links.ErrReservedAlias):
+			log.Info("alias is reserved", slog.String("alias", req.Alias))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeAliasReserved, "alias is reserved"))
+			return
+		case errors.Is(err, links.ErrInvalidVariant):
+			log.Info("invalid variants", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeValidation, err.Error()))
+			return
+		case errors.Is(err, links.ErrDomainNotRegistered):
+			log.Info("domain is not registered", slog.String("domain", req.Domain))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeDomainNotRegistered, "domain is not registered"))
+			return
+		case errors.Is(err, links.ErrDomainForbidden):
+			log.Info("domain belongs to another user", slog.String("domain", req.Domain))
+			resp.RenderError(w, r, http.StatusForbidden, resp.ErrorCode(resp.CodeDomainForbidden, "domain belongs to another user"))
+			return
+		case errors.Is(err, storage.ErrURLExists):
+			log.Info("url already exists", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusConflict, resp.ErrorCode(resp.CodeAliasExists, "url already exists"))
+			return
+		case err != nil:
 			log.Error("failed to add url", slog.Any("error", err))
-			render.JSON(w, r, resp.Error("failed to add url"))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to add url"))
 			return
 		}
 
-		log.Info("url added", slog.Int64("id", id))
 		render.JSON(w, r, Response{
 			Response: resp.OK(),
 			Alias:    alias,
 		})
-
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,16 +15,26 @@ import (
 
 	"urlshortener/internal/http-server/handlers/url/save"
 	"urlshortener/internal/http-server/handlers/url/save/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
 	"urlshortener/lib/logger/handlers/slogdiscard"
 )
 
 func TestSaveHandler(t *testing.T) {
 	cases := []struct {
-		name      string
-		alias     string
-		url       string
-		respError string
-		mockError error
+		name       string
+		alias      string
+		url        string
+		respError  string
+		respCode   string
+		status     int
+		mockError  error
+		dedup      bool
+		normalized string
+		existing   string // alias returned by GetAliasByNormalized
+		wantAlias  string
+		redirect   int
 	}{
 		{
 			name:  "Success",
@@ -40,20 +51,79 @@ func TestSaveHandler(t *testing.T) {
 			url:       "",
 			alias:     "some_alias",
 			respError: "field URL is a required field",
+			respCode:  resp.CodeValidation,
+			status:    http.StatusUnprocessableEntity,
 		},
 		{
 			name:      "Invalid URL",
 			url:       "some invalid URL",
 			alias:     "some_alias",
 			respError: "field URL is not a valid URL",
+			respCode:  resp.CodeValidation,
+			status:    http.StatusUnprocessableEntity,
 		},
 		{
 			name:      "SaveURL Error",
 			alias:     "test_alias",
 			url:       "https://google.com",
 			respError: "failed to add url",
+			respCode:  resp.CodeInternal,
+			status:    http.StatusInternalServerError,
 			mockError: errors.New("unexpected error"),
 		},
+		{
+			name:      "Alias exists",
+			alias:     "test_alias",
+			url:       "https://google.com",
+			respError: "url already exists",
+			respCode:  resp.CodeAliasExists,
+			status:    http.StatusConflict,
+			mockError: storage.ErrURLExists,
+		},
+		{
+			name:       "Dedup returns existing alias",
+			url:        "https://Google.com:443/?b=2&a=1#top",
+			dedup:      true,
+			normalized: "https://google.com/?a=1&b=2",
+			existing:   "existing",
+			wantAlias:  "existing",
+		},
+		{
+			name:       "Dedup without match saves new url",
+			url:        "https://google.com",
+			dedup:      true,
+			normalized: "https://google.com/",
+		},
+		{
+			name:      "Reserved alias",
+			alias:     "webhook",
+			url:       "https://google.com",
+			respError: "alias is reserved",
+			respCode:  resp.CodeAliasReserved,
+			status:    http.StatusUnprocessableEntity,
+		},
+		{
+			name:      "Dedup skipped for custom alias",
+			alias:     "custom_alias",
+			url:       "https://google.com",
+			dedup:     true,
+			wantAlias: "custom_alias",
+		},
+		{
+			name:     "Redirect type",
+			alias:    "permanent",
+			url:      "https://google.com",
+			redirect: http.StatusPermanentRedirect,
+		},
+		{
+			name:      "Invalid redirect type",
+			alias:     "some_alias",
+			url:       "https://google.com",
+			redirect:  http.StatusOK,
+			respError: "field RedirectType must be one of: 301 302 307 308",
+			respCode:  resp.CodeValidation,
+			status:    http.StatusUnprocessableEntity,
+		},
 	}
 
 	for _, tc := range cases {
@@ -64,15 +134,29 @@ func TestSaveHandler(t *testing.T) {
 
 			urlSaverMock := mocks.NewURLSaver(t)
 
-			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+			if tc.dedup && tc.alias == "" {
+				lookupErr := storage.ErrUrlNotFound
+				if tc.existing != "" {
+					lookupErr = nil
+				}
+				urlSaverMock.On("GetAliasByNormalized", "", tc.normalized, "").
+					Return(tc.existing, lookupErr).
+					Once()
+			}
+
+			if (tc.respError == "" || tc.mockError != nil) && tc.existing == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(u storage.URL) bool {
+					return u.URL == tc.url && u.Alias != "" && u.RedirectType == tc.redirect
+				}), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditCreate && e.Before == nil && string(e.After) != ""
+				})).
 					Return(int64(1), tc.mockError).
 					Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{Dedup: tc.dedup})
 
-			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s", "redirect_type": %d}`, tc.url, tc.alias, tc.redirect)
 
 			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
 			require.NoError(t, err)
@@ -80,17 +164,151 @@ func TestSaveHandler(t *testing.T) {
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
 
-			require.Equal(t, rr.Code, http.StatusOK)
+			wantStatus := tc.status
+			if wantStatus == 0 {
+				wantStatus = http.StatusOK
+			}
+			require.Equal(t, wantStatus, rr.Code)
 
 			body := rr.Body.String()
 
-			var resp save.Response
+			var response save.Response
+
+			require.NoError(t, json.Unmarshal([]byte(body), &response))
 
-			require.NoError(t, json.Unmarshal([]byte(body), &resp))
+			require.Equal(t, tc.respError, response.Error)
+			require.Equal(t, tc.respCode, response.Code)
 
-			require.Equal(t, tc.respError, resp.Error)
+			if tc.wantAlias != "" {
+				require.Equal(t, tc.wantAlias, response.Alias)
+			}
 
 			// TODO: add more checks
 		})
 	}
 }
+
+func TestSaveHandler_Domain(t *testing.T) {
+	cases := []struct {
+		name       string
+		domain     string
+		user       string
+		mockDomain storage.Domain
+		mockError  error
+		wantDomain string
+		respError  string
+		status     int
+	}{
+		{
+			name:       "Default domain",
+			domain:     "sho.rt",
+			user:       "alice",
+			wantDomain: "",
+		},
+		{
+			name:       "Own domain",
+			domain:     "Go.Brand.com",
+			user:       "alice",
+			mockDomain: storage.Domain{Name: "go.brand.com", Owner: "alice"},
+			wantDomain: "go.brand.com",
+		},
+		{
+			name:       "Domain of another user",
+			domain:     "go.brand.com",
+			user:       "bob",
+			mockDomain: storage.Domain{Name: "go.brand.com", Owner: "alice"},
+			respError:  "domain belongs to another user",
+			status:     http.StatusForbidden,
+		},
+		{
+			name:      "Unregistered domain",
+			domain:    "go.brand.com",
+			user:      "alice",
+			mockError: storage.ErrDomainNotFound,
+			respError: "domain is not registered",
+			status:    http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			if tc.mockDomain.Name != "" || tc.mockError != nil {
+				urlSaverMock.On("GetDomain", "go.brand.com").Return(tc.mockDomain, tc.mockError).Once()
+			}
+			if tc.respError == "" {
+				urlSaverMock.On("SaveURL", mock.MatchedBy(func(u storage.URL) bool {
+					return u.Domain == tc.wantDomain && u.Owner == tc.user
+				}), mock.Anything).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, save.Options{DefaultDomain: "sho.rt"})
+
+			input := fmt.Sprintf(`{"url": "https://google.com", "domain": "%s"}`, tc.domain)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if tc.status != 0 {
+				require.Equal(t, tc.status, rr.Code)
+			}
+
+			var response save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.Equal(t, tc.respError, response.Error)
+		})
+	}
+}
+
+func TestSaveHandler_Body(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		respCode string
+	}{
+		{name: "Empty body", body: "", respCode: resp.CodeEmptyRequest},
+		{name: "Malformed JSON", body: `{"url":`, respCode: resp.CodeInvalidJSON},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), mocks.NewURLSaver(t), save.Options{})
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.body)))
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusBadRequest, rr.Code)
+
+			var response save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			require.Equal(t, tc.respCode, response.Code)
+		})
+	}
+}
+
+func TestRequest_LogValue(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	log.Info("request body decoded", slog.Any("request", save.Request{URL: "https://example.com", Password: "hunter2"}))
+
+	require.NotContains(t, buf.String(), "hunter2")
+	require.Contains(t, buf.String(), "https://example.com")
+	require.Contains(t, buf.String(), "[redacted]")
+}
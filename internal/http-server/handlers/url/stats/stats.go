@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+type Response struct {
+	resp.Response
+	Clicks int64 `json:"clicks"`
+	// Variants are clicks by A/B variant.
+	Variants map[string]int64 `json:"variants,omitempty"`
+	// FirstClickAt and LastClickAt are empty if there are no clicks.
+	FirstClickAt *time.Time `json:"first_click_at,omitempty"`
+	LastClickAt  *time.Time `json:"last_click_at,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=StatsGetter
+type StatsGetter interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+	GetStats(domain string, alias string) (storage.Stats, error)
+}
+
+// Options configures the stats handler.
+type Options struct {
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New returns click statistics of the link. Links of a custom
+// domain are selected with the domain query param. Links of other users
+// are answered with 404 as if they didn't exist.
+func New(log *slog.Logger, statsGetter StatsGetter, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		_, err := links.Own(statsGetter, auth.Principal(r.Context()), domain, alias)
+
+		var stats storage.Stats
+		if err == nil {
+			stats, err = statsGetter.GetStats(domain, alias)
+		}
+		if errors.Is(err, storage.ErrUrlNotFound) || errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.ErrorCode(resp.CodeNotFound, "url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to get stats", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to get stats"))
+			return
+		}
+
+		res := Response{
+			Response: resp.OK(),
+			Clicks:   stats.Clicks,
+			Variants: stats.Variants,
+		}
+		if !stats.FirstClickAt.IsZero() {
+			res.FirstClickAt = &stats.FirstClickAt
+			res.LastClickAt = &stats.LastClickAt
+		}
+
+		render.JSON(w, r, res)
+	}
+}
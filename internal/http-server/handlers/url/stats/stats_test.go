@@ -0,0 +1,132 @@
+package stats_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/stats"
+	"urlshortener/internal/http-server/handlers/url/stats/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestStatsHandler(t *testing.T) {
+	first := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	last := first.Add(time.Hour)
+
+	cases := []struct {
+		name  string
+		alias string
+		// owner of the stored link, the caller is user
+		owner      string
+		getError   error
+		stats      storage.Stats
+		mockError  error
+		mockCalled bool
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name:       "Success",
+			alias:      "test_alias",
+			stats:      storage.Stats{Clicks: 5, Variants: map[string]int64{"A": 2, "B": 3}, FirstClickAt: first, LastClickAt: last},
+			mockCalled: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "No clicks",
+			alias:      "test_alias",
+			stats:      storage.Stats{Variants: map[string]int64{}},
+			mockCalled: true,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Empty alias",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Not found",
+			alias:      "missing",
+			getError:   storage.ErrUrlNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Link of another user",
+			alias:      "test_alias",
+			owner:      "bob",
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Internal error",
+			alias:      "test_alias",
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   response.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			statsGetterMock := mocks.NewStatsGetter(t)
+			if tc.alias != "" {
+				owner := tc.owner
+				if owner == "" {
+					owner = "user"
+				}
+				statsGetterMock.On("GetURL", "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, Owner: owner}, tc.getError).
+					Once()
+			}
+			if tc.mockCalled {
+				statsGetterMock.On("GetStats", "", tc.alias).
+					Return(tc.stats, tc.mockError).
+					Once()
+			}
+
+			handler := stats.New(slogdiscard.NewDiscardLogger(), statsGetterMock, stats.Options{DefaultDomain: "sho.rt"})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", tc.alias)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "user"))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp stats.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantCode, resp.Code)
+
+			if tc.wantStatus != http.StatusOK {
+				return
+			}
+			require.Equal(t, tc.stats.Clicks, resp.Clicks)
+			if tc.stats.FirstClickAt.IsZero() {
+				require.Nil(t, resp.FirstClickAt)
+				require.Nil(t, resp.LastClickAt)
+				return
+			}
+			require.Equal(t, tc.stats.Variants, resp.Variants)
+			require.True(t, tc.stats.FirstClickAt.Equal(*resp.FirstClickAt))
+			require.True(t, tc.stats.LastClickAt.Equal(*resp.LastClickAt))
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package update
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/handlers/url/get"
+	"urlshortener/internal/http-server/handlers/url/save"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/links"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/urlnorm"
+)
+
+// Request changes the fields that are set, the rest are kept.
+// Empty strings, zero numbers and zero times remove optional settings.
+type Request struct {
+	URL          *string `json:"url,omitempty"`
+	RedirectType *int    `json:"redirect_type,omitempty"`
+	Passthrough  *bool   `json:"passthrough,omitempty"`
+	// Params replace the params of the link, an empty object removes them
+	Params   map[string]string `json:"params,omitempty"`
+	Campaign *string           `json:"campaign,omitempty"`
+	// Password protects the link, empty removes the protection
+	Password    *string    `json:"password,omitempty"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty"`
+	Burn        *bool      `json:"burn,omitempty"`
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	PendingURL  *string    `json:"pending_url,omitempty"`
+	FallbackURL *string    `json:"fallback_url,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Image       *string    `json:"image,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Link get.Link `json:"link"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=URLUpdater
+type URLUpdater interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+	UpdateURL(u storage.URL, e storage.AuditEntry) error
+}
+
+// Options configures the update handler.
+type Options struct {
+	// StripTracking ignores tracking parameters when normalizing
+	// the destination, same as for new links.
+	StripTracking bool
+	// DefaultDomain is the main short domain, selecting it with the
+	// domain query param is the same as leaving the param out.
+	DefaultDomain string
+}
+
+// New changes a link and returns it. The result is validated by the
+// rules of new links, so errors have the same codes as on save.
+// Rules and variants are not changed here. Links of other users are
+// answered with 404 as if they didn't exist.
+func New(log *slog.Logger, urlUpdater URLUpdater, opts Options) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("alias is required"))
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ErrorCode(resp.CodeEmptyRequest, "empty request"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.ErrorCode(resp.CodeInvalidJSON, "failed to decode request"))
+			return
+		}
+
+		domain := links.Namespace(r.URL.Query().Get("domain"), opts.DefaultDomain)
+
+		before, err := links.Own(urlUpdater, auth.Principal(r.Context()), domain, alias)
+		if errors.Is(err, storage.ErrUrlNotFound) || errors.Is(err, storage.ErrURLDeleted) {
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.ErrorCode(resp.CodeNotFound, "url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to get url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to update url"))
+			return
+		}
+
+		merged := req.apply(before)
+		if err := validator.New().Struct(merged); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ValidationError(validateErr))
+			return
+		}
+
+		after := before
+		after.URL = merged.URL
+		after.RedirectType = merged.RedirectType
+		after.Passthrough = merged.Passthrough
+		after.Params = merged.Params
+		after.Campaign = merged.Campaign
+		after.MaxClicks = merged.MaxClicks
+		after.Burn = merged.Burn
+		after.ActiveFrom = merged.ActiveFrom
+		after.ActiveUntil = merged.ActiveUntil
+		after.PendingURL = merged.PendingURL
+		after.FallbackURL = merged.FallbackURL
+		after.Title = merged.Title
+		after.Description = merged.Description
+		after.Image = merged.Image
+
+		if req.URL != nil {
+			after.Normalized, err = urlnorm.Normalize(after.URL, urlnorm.Options{StripTracking: opts.StripTracking})
+			if err != nil {
+				log.Info("failed to normalize url", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeInvalidURL, "invalid url"))
+				return
+			}
+		}
+
+		if req.Password != nil {
+			after.PasswordHash = ""
+			if *req.Password != "" {
+				hash, err := bcrypt.GenerateFromPassword([]byte(*req.Password), bcrypt.DefaultCost)
+				if err != nil {
+					log.Error("failed to hash password", slog.Any("error", err))
+					resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to update url"))
+					return
+				}
+				after.PasswordHash = string(hash)
+			}
+		}
+
+		err = urlUpdater.UpdateURL(after, audit.NewEntry(r, storage.AuditUpdate, storage.AuditEntityURL,
+			domain, alias, audit.NewLink(before), audit.NewLink(after)))
+		if errors.Is(err, storage.ErrUrlNotFound) {
+			// deleted in the meantime
+			log.Info("alias not found", slog.String("alias", alias))
+			resp.RenderError(w, r, http.StatusNotFound, resp.ErrorCode(resp.CodeNotFound, "url not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to update url", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.ErrorCode(resp.CodeInternal, "failed to update url"))
+			return
+		}
+
+		log.Info("url updated", slog.String("alias", alias))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Link:     get.NewLink(after),
+		})
+	}
+}
+
+// apply returns the link as a save request with the changes applied,
+// to be checked by the same rules as a new link.
+func (req Request) apply(u storage.URL) save.Request {
+	merged := save.Request{
+		URL:          u.URL,
+		RedirectType: u.RedirectType,
+		Passthrough:  u.Passthrough,
+		Params:       u.Params,
+		Campaign:     u.Campaign,
+		MaxClicks:    u.MaxClicks,
+		Burn:         u.Burn,
+		ActiveFrom:   u.ActiveFrom,
+		ActiveUntil:  u.ActiveUntil,
+		PendingURL:   u.PendingURL,
+		FallbackURL:  u.FallbackURL,
+		Title:        u.Title,
+		Description:  u.Description,
+		Image:        u.Image,
+	}
+
+	set(&merged.URL, req.URL)
+	set(&merged.RedirectType, req.RedirectType)
+	set(&merged.Passthrough, req.Passthrough)
+	if req.Params != nil {
+		merged.Params = req.Params
+	}
+	set(&merged.Campaign, req.Campaign)
+	set(&merged.Password, req.Password)
+	set(&merged.MaxClicks, req.MaxClicks)
+	set(&merged.Burn, req.Burn)
+	set(&merged.ActiveFrom, req.ActiveFrom)
+	set(&merged.ActiveUntil, req.ActiveUntil)
+	set(&merged.PendingURL, req.PendingURL)
+	set(&merged.FallbackURL, req.FallbackURL)
+	set(&merged.Title, req.Title)
+	set(&merged.Description, req.Description)
+	set(&merged.Image, req.Image)
+
+	return merged
+}
+
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
@@ -0,0 +1,170 @@
+package update_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/update"
+	"urlshortener/internal/http-server/handlers/url/update/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	existing := storage.URL{
+		ID:           1,
+		Alias:        "test_alias",
+		URL:          "https://example.com",
+		Normalized:   "https://example.com/",
+		Owner:        "user",
+		PasswordHash: "hash",
+		MaxClicks:    10,
+		ActiveFrom:   from,
+		Params:       map[string]string{"utm_source": "x"},
+		Title:        "Title",
+	}
+
+	cases := []struct {
+		name string
+		body string
+		// caller of the request, the stored link belongs to user
+		caller     string
+		getError   error
+		wantUpdate func(u storage.URL) bool
+		updateErr  error
+		wantStatus int
+		wantCode   string
+	}{
+		{
+			name: "Change destination",
+			body: `{"url": "https://example.org/new", "title": ""}`,
+			wantUpdate: func(u storage.URL) bool {
+				return u.URL == "https://example.org/new" && u.Normalized == "https://example.org/new" &&
+					u.Title == "" && u.PasswordHash == "hash" && u.MaxClicks == 10 && u.Params["utm_source"] == "x"
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "Remove limits",
+			body: `{"password": "", "max_clicks": 0, "active_from": "0001-01-01T00:00:00Z", "params": {}}`,
+			wantUpdate: func(u storage.URL) bool {
+				return u.PasswordHash == "" && u.MaxClicks == 0 && u.ActiveFrom.IsZero() &&
+					len(u.Params) == 0 && u.URL == existing.URL
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "New password",
+			body: `{"password": "secret"}`,
+			wantUpdate: func(u storage.URL) bool {
+				return u.PasswordHash != "" && u.PasswordHash != "hash"
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Empty body",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   response.CodeEmptyRequest,
+		},
+		{
+			name:       "Invalid url",
+			body:       `{"url": "not a url"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   response.CodeValidation,
+		},
+		{
+			name:       "Window ends before it starts",
+			body:       `{"active_until": "2024-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   response.CodeValidation,
+		},
+		{
+			name:       "Invalid redirect type",
+			body:       `{"redirect_type": 303}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   response.CodeValidation,
+		},
+		{
+			name:       "Not found",
+			body:       `{"title": "x"}`,
+			getError:   storage.ErrUrlNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Link of another user",
+			body:       `{"title": "x"}`,
+			caller:     "bob",
+			wantStatus: http.StatusNotFound,
+			wantCode:   response.CodeNotFound,
+		},
+		{
+			name:       "Update error",
+			body:       `{"title": "x"}`,
+			wantUpdate: func(u storage.URL) bool { return u.Title == "x" },
+			updateErr:  errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   response.CodeInternal,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocks.NewURLUpdater(t)
+			if tc.body != "" {
+				urlUpdaterMock.On("GetURL", "", "test_alias").
+					Return(existing, tc.getError).
+					Once()
+			}
+			if tc.wantUpdate != nil {
+				urlUpdaterMock.On("UpdateURL", mock.MatchedBy(tc.wantUpdate), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditUpdate && e.Alias == "test_alias" &&
+						e.Before != nil && e.After != nil
+				})).
+					Return(tc.updateErr).
+					Once()
+			}
+
+			handler := update.New(slogdiscard.NewDiscardLogger(), urlUpdaterMock, update.Options{})
+
+			req := httptest.NewRequest(http.MethodPatch, "/", bytes.NewReader([]byte(tc.body)))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("alias", "test_alias")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+			caller := tc.caller
+			if caller == "" {
+				caller = "user"
+			}
+			req = req.WithContext(auth.WithPrincipal(req.Context(), caller))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code, rr.Body.String())
+
+			var resp update.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantCode, resp.Code)
+
+			if tc.wantStatus == http.StatusOK {
+				require.Equal(t, "test_alias", resp.Link.Alias)
+			}
+		})
+	}
+}
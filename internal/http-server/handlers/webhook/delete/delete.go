@@ -0,0 +1,61 @@
+package delete
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=WebhookDeleter
+type WebhookDeleter interface {
+	DeleteWebhook(owner string, id int64, e storage.AuditEntry) error
+}
+
+// New unsubscribes the webhook of the user, pending deliveries are dropped.
+func New(log *slog.Logger, webhookDeleter WebhookDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Info("invalid webhook id", slog.String("id", chi.URLParam(r, "id")))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid webhook id"))
+			return
+		}
+
+		owner := auth.Principal(r.Context())
+
+		err = webhookDeleter.DeleteWebhook(owner, id, audit.NewEntry(r, storage.AuditDelete, storage.AuditEntityWebhook,
+			"", strconv.FormatInt(id, 10), nil, nil))
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("webhook not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to delete webhook", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to delete webhook"))
+			return
+		}
+
+		log.Info("webhook deleted", slog.Int64("id", id))
+
+		render.JSON(w, r, resp.OK())
+	}
+}
@@ -0,0 +1,97 @@
+package delete_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/webhook/delete"
+	"urlshortener/internal/http-server/handlers/webhook/delete/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/api/response"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestDeleteWebhookHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		id         string
+		wantStatus int
+		wantError  string
+		mockError  error
+		mockCalled bool
+	}{
+		{
+			name:       "Success",
+			id:         "3",
+			wantStatus: http.StatusOK,
+			mockCalled: true,
+		},
+		{
+			name:       "Invalid id",
+			id:         "abc",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid webhook id",
+		},
+		{
+			name:       "Not found",
+			id:         "3",
+			wantStatus: http.StatusNotFound,
+			wantError:  "webhook not found",
+			mockError:  storage.ErrWebhookNotFound,
+			mockCalled: true,
+		},
+		{
+			name:       "Storage error",
+			id:         "3",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to delete webhook",
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			webhookDeleterMock := mocks.NewWebhookDeleter(t)
+
+			if tc.mockCalled {
+				webhookDeleterMock.On("DeleteWebhook", "alice", int64(3), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Action == storage.AuditDelete && e.Entity == storage.AuditEntityWebhook && e.Alias == "3"
+				})).
+					Return(tc.mockError).
+					Once()
+			}
+
+			handler := delete.New(slogdiscard.NewDiscardLogger(), webhookDeleterMock)
+
+			req, err := http.NewRequest(http.MethodDelete, "/", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp response.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+		})
+	}
+}
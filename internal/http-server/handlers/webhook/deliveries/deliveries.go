@@ -0,0 +1,119 @@
+package deliveries
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+type Response struct {
+	resp.Response
+	Deliveries []storage.Delivery `json:"deliveries"`
+	// NextBeforeID is passed as before_id to get the next page,
+	// empty on the last page.
+	NextBeforeID int64 `json:"next_before_id,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=DeliveryLister
+type DeliveryLister interface {
+	ListDeliveries(owner string, webhookID int64, f storage.DeliveryFilter) ([]storage.Delivery, error)
+}
+
+// New returns the delivery log of a webhook of the user, newest first.
+// Query param status (pending, delivered, dead) filters, before_id and
+// limit (100 by default, up to 1000) page.
+func New(log *slog.Logger, deliveryLister DeliveryLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.deliveries.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+		if err != nil {
+			log.Info("invalid webhook id", slog.String("id", chi.URLParam(r, "id")))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("invalid webhook id"))
+			return
+		}
+
+		f, err := parseFilter(r)
+		if err != nil {
+			log.Info("invalid filter", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error(err.Error()))
+			return
+		}
+
+		owner := auth.Principal(r.Context())
+
+		deliveries, err := deliveryLister.ListDeliveries(owner, id, f)
+		if errors.Is(err, storage.ErrWebhookNotFound) {
+			log.Info("webhook not found", slog.Int64("id", id))
+			resp.RenderError(w, r, http.StatusNotFound, resp.Error("webhook not found"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to list deliveries", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to list deliveries"))
+			return
+		}
+
+		if deliveries == nil {
+			deliveries = []storage.Delivery{}
+		}
+
+		var next int64
+		if len(deliveries) == f.Limit {
+			next = deliveries[len(deliveries)-1].ID
+		}
+
+		render.JSON(w, r, Response{
+			Response:     resp.OK(),
+			Deliveries:   deliveries,
+			NextBeforeID: next,
+		})
+	}
+}
+
+func parseFilter(r *http.Request) (storage.DeliveryFilter, error) {
+	q := r.URL.Query()
+
+	f := storage.DeliveryFilter{Status: q.Get("status")}
+	switch f.Status {
+	case "", storage.DeliveryPending, storage.DeliveryDelivered, storage.DeliveryDead:
+	default:
+		return f, errors.New("invalid status")
+	}
+
+	var err error
+	if v := q.Get("before_id"); v != "" {
+		if f.BeforeID, err = strconv.ParseInt(v, 10, 64); err != nil || f.BeforeID < 1 {
+			return f, errors.New("invalid before_id")
+		}
+	}
+
+	f.Limit = defaultLimit
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxLimit {
+			return f, errors.New("invalid limit")
+		}
+	}
+
+	return f, nil
+}
@@ -0,0 +1,127 @@
+package deliveries_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/webhook/deliveries"
+	"urlshortener/internal/http-server/handlers/webhook/deliveries/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestDeliveriesHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		id             string
+		query          string
+		wantStatus     int
+		wantError      string
+		wantFilter     storage.DeliveryFilter
+		mockDeliveries []storage.Delivery
+		mockError      error
+		mockCalled     bool
+		wantNext       int64
+	}{
+		{
+			name:       "Success",
+			id:         "3",
+			wantStatus: http.StatusOK,
+			wantFilter: storage.DeliveryFilter{Limit: 100},
+			mockDeliveries: []storage.Delivery{
+				{ID: 2, WebhookID: 3, Event: storage.EventLinkDeleted, Status: storage.DeliveryPending, Attempts: 1, LastStatusCode: 500},
+				{ID: 1, WebhookID: 3, Event: storage.EventLinkCreated, Status: storage.DeliveryDelivered, Attempts: 1},
+			},
+			mockCalled: true,
+		},
+		{
+			name:       "Dead letters page",
+			id:         "3",
+			query:      "?status=dead&before_id=10&limit=1",
+			wantStatus: http.StatusOK,
+			wantFilter: storage.DeliveryFilter{Status: storage.DeliveryDead, BeforeID: 10, Limit: 1},
+			mockDeliveries: []storage.Delivery{
+				{ID: 7, WebhookID: 3, Event: storage.EventLinkCreated, Status: storage.DeliveryDead, Attempts: 8},
+			},
+			mockCalled: true,
+			wantNext:   7,
+		},
+		{
+			name:       "Invalid status",
+			id:         "3",
+			query:      "?status=failed",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid status",
+		},
+		{
+			name:       "Invalid id",
+			id:         "x",
+			wantStatus: http.StatusBadRequest,
+			wantError:  "invalid webhook id",
+		},
+		{
+			name:       "Webhook of another user",
+			id:         "3",
+			wantStatus: http.StatusNotFound,
+			wantError:  "webhook not found",
+			wantFilter: storage.DeliveryFilter{Limit: 100},
+			mockError:  storage.ErrWebhookNotFound,
+			mockCalled: true,
+		},
+		{
+			name:       "Storage error",
+			id:         "3",
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to list deliveries",
+			wantFilter: storage.DeliveryFilter{Limit: 100},
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deliveryListerMock := mocks.NewDeliveryLister(t)
+
+			if tc.mockCalled {
+				deliveryListerMock.On("ListDeliveries", "alice", int64(3), tc.wantFilter).
+					Return(tc.mockDeliveries, tc.mockError).
+					Once()
+			}
+
+			handler := deliveries.New(slogdiscard.NewDiscardLogger(), deliveryListerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/webhook/"+tc.id+"/deliveries"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tc.id)
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+
+			var resp deliveries.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+			require.Equal(t, tc.wantNext, resp.NextBeforeID)
+			if tc.wantError == "" {
+				require.Len(t, resp.Deliveries, len(tc.mockDeliveries))
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package list
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+type Response struct {
+	resp.Response
+	Webhooks []storage.Webhook `json:"webhooks"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=WebhookLister
+type WebhookLister interface {
+	ListWebhooks(owner string) ([]storage.Webhook, error)
+}
+
+// New returns webhooks of the user without their secrets.
+func New(log *slog.Logger, webhookLister WebhookLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		owner := auth.Principal(r.Context())
+
+		webhooks, err := webhookLister.ListWebhooks(owner)
+		if err != nil {
+			log.Error("failed to list webhooks", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to list webhooks"))
+			return
+		}
+
+		if webhooks == nil {
+			webhooks = []storage.Webhook{}
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Webhooks: webhooks,
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/webhook/list"
+	"urlshortener/internal/http-server/handlers/webhook/list/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestListWebhooksHandler(t *testing.T) {
+	cases := []struct {
+		name         string
+		mockWebhooks []storage.Webhook
+		mockError    error
+		wantStatus   int
+		wantError    string
+	}{
+		{
+			name: "Success",
+			mockWebhooks: []storage.Webhook{
+				{ID: 1, Owner: "alice", URL: "https://cms.example.com/hooks", Secret: "top-secret", Events: []string{storage.EventLinkCreated}},
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "No webhooks",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Storage error",
+			mockError:  errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to list webhooks",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			webhookListerMock := mocks.NewWebhookLister(t)
+			webhookListerMock.On("ListWebhooks", "alice").
+				Return(tc.mockWebhooks, tc.mockError).
+				Once()
+
+			handler := list.New(slogdiscard.NewDiscardLogger(), webhookListerMock)
+
+			req, err := http.NewRequest(http.MethodGet, "/webhook", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			require.NotContains(t, rr.Body.String(), "top-secret")
+
+			var resp list.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+
+			if tc.wantError == "" {
+				require.Len(t, resp.Webhooks, len(tc.mockWebhooks))
+				require.True(t, strings.Contains(rr.Body.String(), `"webhooks":[`))
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package save
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+type Request struct {
+	// URL receives POST requests with the events
+	URL string `json:"url" validate:"required,url"`
+	// Events to subscribe to: link.created, link.deleted, link.expired, link.clicks_threshold
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=link.created link.deleted link.expired link.clicks_threshold"`
+	// Secret signs the deliveries, a random one is generated if empty
+	Secret string `json:"secret,omitempty" validate:"omitempty,min=16,max=256"`
+}
+
+type Response struct {
+	resp.Response
+	ID int64 `json:"id,omitempty"`
+	// Secret is only returned here, keep it to verify signatures
+	Secret string `json:"secret,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@latest --name=WebhookSaver
+type WebhookSaver interface {
+	SaveWebhook(w storage.Webhook, e storage.AuditEntry) (int64, error)
+}
+
+// New subscribes the user to events of their links.
+func New(log *slog.Logger, webhookSaver WebhookSaver) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.webhook.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+		)
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("empty request"))
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusBadRequest, resp.Error("failed to decode request"))
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+			log.Error("invalid request", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ValidationError(validateErr))
+			return
+		}
+
+		// deliveries are HTTP POST requests, only http(s) URLs with a host
+		// can receive them
+		if u, err := url.Parse(req.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			log.Info("invalid webhook url", slog.String("url", req.URL))
+			resp.RenderError(w, r, http.StatusUnprocessableEntity, resp.ErrorCode(resp.CodeInvalidURL, "invalid url"))
+			return
+		}
+
+		secret := req.Secret
+		if secret == "" {
+			b := make([]byte, 32)
+			if _, err := rand.Read(b); err != nil {
+				log.Error("failed to generate secret", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save webhook"))
+				return
+			}
+			secret = hex.EncodeToString(b)
+		}
+
+		events := slices.Clone(req.Events)
+		slices.Sort(events)
+		events = slices.Compact(events)
+
+		owner := auth.Principal(r.Context())
+
+		webhook := storage.Webhook{
+			Owner:  owner,
+			URL:    req.URL,
+			Secret: secret,
+			Events: events,
+		}
+
+		// the secret is not marshaled, it stays out of the audit log,
+		// the storage sets the ID of the new webhook as alias
+		id, err := webhookSaver.SaveWebhook(webhook, audit.NewEntry(r, storage.AuditCreate, storage.AuditEntityWebhook,
+			"", "", nil, webhook))
+		if err != nil {
+			log.Error("failed to save webhook", slog.Any("error", err))
+			resp.RenderError(w, r, http.StatusInternalServerError, resp.Error("failed to save webhook"))
+			return
+		}
+
+		log.Info("webhook saved", slog.Int64("id", id))
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			ID:       id,
+			Secret:   secret,
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package save_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/webhook/save"
+	"urlshortener/internal/http-server/handlers/webhook/save/mocks"
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestSaveWebhookHandler(t *testing.T) {
+	cases := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+		wantEvents []string
+		wantSecret string
+		mockError  error
+		mockCalled bool
+	}{
+		{
+			name:       "Success",
+			body:       `{"url": "https://cms.example.com/hooks", "events": ["link.deleted", "link.created", "link.deleted"]}`,
+			wantStatus: http.StatusCreated,
+			wantEvents: []string{storage.EventLinkCreated, storage.EventLinkDeleted},
+			mockCalled: true,
+		},
+		{
+			name:       "Own secret",
+			body:       `{"url": "https://cms.example.com/hooks", "events": ["link.expired"], "secret": "0123456789abcdef"}`,
+			wantStatus: http.StatusCreated,
+			wantEvents: []string{storage.EventLinkExpired},
+			wantSecret: "0123456789abcdef",
+			mockCalled: true,
+		},
+		{
+			name:       "Unknown event",
+			body:       `{"url": "https://cms.example.com/hooks", "events": ["link.clicked"]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  "field Events[0] must be one of: link.created link.deleted link.expired link.clicks_threshold",
+		},
+		{
+			name:       "No events",
+			body:       `{"url": "https://cms.example.com/hooks", "events": []}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  "field Events is not valid",
+		},
+		{
+			name:       "URL without host",
+			body:       `{"url": "javascript:alert(1)", "events": ["link.created"]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  "invalid url",
+		},
+		{
+			name:       "Not http",
+			body:       `{"url": "ftp://cms.example.com/hooks", "events": ["link.created"]}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantError:  "invalid url",
+		},
+		{
+			name:       "Storage error",
+			body:       `{"url": "https://cms.example.com/hooks", "events": ["link.created"]}`,
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "failed to save webhook",
+			wantEvents: []string{storage.EventLinkCreated},
+			mockError:  errors.New("unexpected error"),
+			mockCalled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			webhookSaverMock := mocks.NewWebhookSaver(t)
+
+			if tc.mockCalled {
+				webhookSaverMock.On("SaveWebhook", mock.MatchedBy(func(w storage.Webhook) bool {
+					return w.Owner == "alice" && w.URL == "https://cms.example.com/hooks" &&
+						len(w.Secret) >= 16 && (tc.wantSecret == "" || w.Secret == tc.wantSecret) &&
+						strings.Join(w.Events, ",") == strings.Join(tc.wantEvents, ",")
+				}), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Entity == storage.AuditEntityWebhook && e.Alias == "" &&
+						!strings.Contains(string(e.After), "secret")
+				})).
+					Return(int64(3), tc.mockError).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), webhookSaverMock)
+
+			req, err := http.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			// headers as sent, set after WriteHeader they would be lost
+			require.Contains(t, rr.Result().Header.Get("Content-Type"), "application/json")
+
+			var resp save.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			require.Equal(t, tc.wantError, resp.Error)
+
+			if tc.wantError == "" {
+				require.Equal(t, int64(3), resp.ID)
+				require.NotEmpty(t, resp.Secret)
+				if tc.wantSecret != "" {
+					require.Equal(t, tc.wantSecret, resp.Secret)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package auth authenticates API requests by BasicAuth or by an API key
+// and passes the authenticated caller to handlers in the request context.
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+	"urlshortener/lib/apikey"
+)
+
+// Header carries API keys, created with "url-shortener create-api-key".
+const Header = "X-API-Key"
+
+// KeyStore finds API keys by their hash.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=KeyStore
+type KeyStore interface {
+	GetAPIKey(hash string) (storage.APIKey, error)
+}
+
+type principalKey struct{}
+
+// Principal returns the caller authenticated by the middleware,
+// empty for requests that went around it.
+func Principal(ctx context.Context) string {
+	p, _ := ctx.Value(principalKey{}).(string)
+
+	return p
+}
+
+// WithPrincipal returns ctx with the authenticated caller.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// New returns the middleware. It accepts BasicAuth credentials of users and,
+// if keys is not nil, API keys in the X-API-Key header, which act as the
+// user they were created for. The caller is passed on as Principal.
+func New(log *slog.Logger, realm string, users map[string]string, keys KeyStore) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.auth"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if key := r.Header.Get(Header); key != "" && keys != nil {
+				k, err := keys.GetAPIKey(apikey.Hash(key))
+				if errors.Is(err, storage.ErrAPIKeyNotFound) {
+					log.Info("unknown api key")
+					unauthorized(w, realm)
+					return
+				}
+				if err != nil {
+					log.Error("failed to get api key", slog.Any("error", err))
+					resp.RenderError(w, r, http.StatusInternalServerError,
+						resp.ErrorCode(resp.CodeInternal, "internal error"))
+					return
+				}
+
+				log.Debug("authenticated by api key", slog.Int64("key_id", k.ID), slog.String("owner", k.Owner))
+				next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), k.Owner)))
+				return
+			}
+
+			user, password, ok := r.BasicAuth()
+			if !ok || !validPassword(users, user, password) {
+				unauthorized(w, realm)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), user)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+func validPassword(users map[string]string, user, password string) bool {
+	expected, ok := users[user]
+
+	return ok && subtle.ConstantTimeCompare([]byte(password), []byte(expected)) == 1
+}
+
+// unauthorized answers like middleware.BasicAuth does.
+func unauthorized(w http.ResponseWriter, realm string) {
+	w.Header().Add("WWW-Authenticate", fmt.Sprintf(`Basic realm="%s"`, realm))
+	w.WriteHeader(http.StatusUnauthorized)
+}
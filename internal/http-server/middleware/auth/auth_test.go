@@ -0,0 +1,139 @@
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/http-server/middleware/auth/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/apikey"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestAuth(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     string
+		password string
+		key      string
+		// stored is returned by GetAPIKey, nil for unknown keys
+		stored   *storage.APIKey
+		storeErr error
+		// noKeys passes a nil KeyStore
+		noKeys     bool
+		wantStatus int
+		// wantOwner is the Principal seen by the handler
+		wantOwner string
+	}{
+		{
+			name:       "BasicAuth",
+			user:       "alice",
+			password:   "secret",
+			wantStatus: http.StatusOK,
+			wantOwner:  "alice",
+		},
+		{
+			name:       "Wrong password",
+			user:       "alice",
+			password:   "wrong",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "No credentials",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "API key",
+			key:        "us_key",
+			stored:     &storage.APIKey{ID: 1, Owner: "bob"},
+			wantStatus: http.StatusOK,
+			wantOwner:  "bob",
+		},
+		{
+			name:       "API key wins over BasicAuth",
+			user:       "alice",
+			password:   "secret",
+			key:        "us_key",
+			stored:     &storage.APIKey{ID: 1, Owner: "bob"},
+			wantStatus: http.StatusOK,
+			wantOwner:  "bob",
+		},
+		{
+			name:       "Unknown API key",
+			user:       "alice",
+			password:   "secret",
+			key:        "us_unknown",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Keys disabled",
+			key:        "us_key",
+			noKeys:     true,
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "Store error",
+			key:        "us_key",
+			storeErr:   errors.New("unexpected error"),
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			keys := mocks.NewKeyStore(t)
+			if tc.key != "" && !tc.noKeys {
+				switch {
+				case tc.stored != nil:
+					keys.On("GetAPIKey", apikey.Hash(tc.key)).Return(*tc.stored, nil).Once()
+				case tc.storeErr != nil:
+					keys.On("GetAPIKey", apikey.Hash(tc.key)).Return(storage.APIKey{}, tc.storeErr).Once()
+				default:
+					keys.On("GetAPIKey", apikey.Hash(tc.key)).Return(storage.APIKey{}, storage.ErrAPIKeyNotFound).Once()
+				}
+			}
+
+			var owner, authorization string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				owner = auth.Principal(r.Context())
+				authorization = r.Header.Get("Authorization")
+			})
+
+			var store auth.KeyStore = keys
+			if tc.noKeys {
+				store = nil
+			}
+			handler := auth.New(slogdiscard.NewDiscardLogger(), "test",
+				map[string]string{"alice": "secret"}, store)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "/url", nil)
+			if tc.user != "" {
+				req.SetBasicAuth(tc.user, tc.password)
+			}
+			if tc.key != "" {
+				req.Header.Set(auth.Header, tc.key)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			assert.Equal(t, tc.wantOwner, owner)
+			if tc.wantStatus == http.StatusOK {
+				// the header of the client is passed on as it was
+				assert.Equal(t, req.Header.Get("Authorization"), authorization)
+			}
+			if tc.wantStatus == http.StatusUnauthorized {
+				assert.Equal(t, `Basic realm="test"`, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
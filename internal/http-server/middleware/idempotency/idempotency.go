@@ -0,0 +1,175 @@
+// Package idempotency makes retries of requests with an Idempotency-Key
+// header safe: the first response is stored and replayed to retries.
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/storage"
+	resp "urlshortener/lib/api/response"
+)
+
+const (
+	// Header carries the key chosen by the client, a UUID for example.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+	maxBodySize  = 1 << 20
+)
+
+// Store keeps keys and responses per owner.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=Store
+type Store interface {
+	ReserveIdempotencyKey(k storage.IdempotencyKey, expiredBefore time.Time) (storage.IdempotencyKey, error)
+	SaveIdempotentResponse(owner, key string, status int, contentType string, body []byte) error
+	DeleteIdempotencyKey(owner, key string) error
+}
+
+// Options configures the middleware.
+type Options struct {
+	// Window is how long responses are kept for retries.
+	Window time.Duration
+}
+
+// New returns the middleware. Keys belong to the authenticated caller, so it goes
+// after the auth middleware. A retry with the same body gets the stored
+// response, a different body 422, and a retry while the first request is
+// still running 409. Failed requests (5xx) are not stored and may be retried.
+func New(log *slog.Logger, store Store, opts Options) func(next http.Handler) http.Handler {
+	if opts.Window <= 0 {
+		opts.Window = 24 * time.Hour
+	}
+
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			const op = "middleware.idempotency"
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if len(key) > maxKeyLength {
+				resp.RenderError(w, r, http.StatusBadRequest,
+					resp.ErrorCode(resp.CodeInvalidIdempotencyKey, "idempotency key is too long"))
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				log.Info("failed to read request body", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusRequestEntityTooLarge,
+					resp.ErrorCode(resp.CodeRequestTooLarge, "request body is too large"))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			owner := auth.Principal(r.Context())
+			hash := requestHash(r, body)
+
+			existing, err := store.ReserveIdempotencyKey(storage.IdempotencyKey{
+				Owner:       owner,
+				Key:         key,
+				RequestHash: hash,
+			}, time.Now().Add(-opts.Window))
+			switch {
+			case errors.Is(err, storage.ErrIdempotencyKeyExists):
+				replay(w, r, log, existing, hash)
+				return
+			case err != nil:
+				log.Error("failed to reserve idempotency key", slog.Any("error", err))
+				resp.RenderError(w, r, http.StatusInternalServerError,
+					resp.ErrorCode(resp.CodeInternal, "internal error"))
+				return
+			}
+
+			release := func() {
+				if err := store.DeleteIdempotencyKey(owner, key); err != nil {
+					log.Error("failed to release idempotency key", slog.Any("error", err))
+				}
+			}
+
+			defer func() {
+				// a panic is a failure too, the key mustn't stay in progress
+				if p := recover(); p != nil {
+					release()
+					panic(p)
+				}
+			}()
+
+			var buf bytes.Buffer
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&buf)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				release()
+				return
+			}
+
+			err = store.SaveIdempotentResponse(owner, key, status, ww.Header().Get("Content-Type"), buf.Bytes())
+			if err != nil {
+				log.Error("failed to save idempotent response", slog.Any("error", err))
+				release()
+			}
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// replay answers a request whose key is already stored.
+func replay(w http.ResponseWriter, r *http.Request, log *slog.Logger, k storage.IdempotencyKey, hash string) {
+	switch {
+	case k.RequestHash != hash:
+		log.Info("idempotency key reused with a different request")
+		resp.RenderError(w, r, http.StatusUnprocessableEntity,
+			resp.ErrorCode(resp.CodeIdempotencyKeyReused, "idempotency key was used for a different request"))
+	case k.Status == 0:
+		log.Info("request with the idempotency key is in progress")
+		resp.RenderError(w, r, http.StatusConflict,
+			resp.ErrorCode(resp.CodeIdempotencyInProgress, "request with this idempotency key is in progress"))
+	default:
+		log.Info("replaying stored response", slog.Int("status", k.Status))
+		if k.ContentType != "" {
+			w.Header().Set("Content-Type", k.ContentType)
+		}
+		w.Header().Set(ReplayedHeader, "true")
+		w.WriteHeader(k.Status)
+		_, _ = w.Write(k.Body)
+	}
+}
+
+// requestHash identifies the request a key was used with.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
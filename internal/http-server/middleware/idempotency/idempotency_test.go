@@ -0,0 +1,152 @@
+package idempotency_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/middleware/auth"
+	"urlshortener/internal/http-server/middleware/idempotency"
+	"urlshortener/internal/http-server/middleware/idempotency/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+const body = `{"url":"https://example.com"}`
+
+func TestIdempotency(t *testing.T) {
+	cases := []struct {
+		name string
+		key  string
+		// stored is returned by ReserveIdempotencyKey with ErrIdempotencyKeyExists,
+		// RequestHash "same" is replaced with the hash of the request
+		stored        *storage.IdempotencyKey
+		handlerStatus int
+		wantStatus    int
+		wantBody      string
+		wantHandler   bool
+		wantSaved     bool
+		wantReleased  bool
+		wantReplayed  bool
+	}{
+		{
+			name:          "No key",
+			handlerStatus: http.StatusOK,
+			wantStatus:    http.StatusOK,
+			wantBody:      "created",
+			wantHandler:   true,
+		},
+		{
+			name:          "First request",
+			key:           "k1",
+			handlerStatus: http.StatusOK,
+			wantStatus:    http.StatusOK,
+			wantBody:      "created",
+			wantHandler:   true,
+			wantSaved:     true,
+		},
+		{
+			name:          "Failed request is released",
+			key:           "k1",
+			handlerStatus: http.StatusInternalServerError,
+			wantStatus:    http.StatusInternalServerError,
+			wantBody:      "created",
+			wantHandler:   true,
+			wantReleased:  true,
+		},
+		{
+			name:         "Replay",
+			key:          "k1",
+			stored:       &storage.IdempotencyKey{RequestHash: "same", Status: http.StatusOK, ContentType: "application/json", Body: []byte("first")},
+			wantStatus:   http.StatusOK,
+			wantBody:     "first",
+			wantReplayed: true,
+		},
+		{
+			name:       "Different body",
+			key:        "k1",
+			stored:     &storage.IdempotencyKey{RequestHash: "other", Status: http.StatusOK},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantBody:   "idempotency_key_reused",
+		},
+		{
+			name:       "In progress",
+			key:        "k1",
+			stored:     &storage.IdempotencyKey{RequestHash: "same"},
+			wantStatus: http.StatusConflict,
+			wantBody:   "idempotency_in_progress",
+		},
+		{
+			name:       "Key too long",
+			key:        strings.Repeat("k", 256),
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid_idempotency_key",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := mocks.NewStore(t)
+
+			if tc.key != "" && len(tc.key) <= 255 {
+				call := store.On("ReserveIdempotencyKey", mock.MatchedBy(func(k storage.IdempotencyKey) bool {
+					return k.Owner == "alice" && k.Key == tc.key && k.RequestHash != ""
+				}), mock.Anything).Once()
+				if tc.stored != nil {
+					call.Return(func(k storage.IdempotencyKey, _ time.Time) storage.IdempotencyKey {
+						stored := *tc.stored
+						if stored.RequestHash == "same" {
+							stored.RequestHash = k.RequestHash
+						}
+						return stored
+					}, storage.ErrIdempotencyKeyExists)
+				} else {
+					call.Return(storage.IdempotencyKey{}, nil)
+				}
+			}
+			if tc.wantSaved {
+				store.On("SaveIdempotentResponse", "alice", tc.key, tc.handlerStatus, "application/json", []byte("created")).
+					Return(nil).Once()
+			}
+			if tc.wantReleased {
+				store.On("DeleteIdempotencyKey", "alice", tc.key).Return(nil).Once()
+			}
+
+			var called bool
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tc.handlerStatus)
+				_, _ = w.Write([]byte("created"))
+			})
+
+			handler := idempotency.New(slogdiscard.NewDiscardLogger(), store, idempotency.Options{})(next)
+
+			req := httptest.NewRequest(http.MethodPost, "/url", bytes.NewReader([]byte(body)))
+			req = req.WithContext(auth.WithPrincipal(req.Context(), "alice"))
+			if tc.key != "" {
+				req.Header.Set(idempotency.Header, tc.key)
+			}
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.wantStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.wantBody)
+			assert.Equal(t, tc.wantHandler, called)
+			if tc.wantReplayed {
+				assert.Equal(t, "true", rr.Header().Get(idempotency.ReplayedHeader))
+				assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Package links creates links. The HTTP and gRPC APIs both go through
+// it, so custom domains, dedup and aliases follow the same rules.
+package links
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"urlshortener/internal/audit"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/hostname"
+	"urlshortener/lib/random"
+	"urlshortener/lib/urlnorm"
+)
+
+// TODO: move to config
+const aliasLength = 6
+
+var (
+	ErrInvalidURL          = errors.New("invalid url")
+	ErrReservedAlias       = errors.New("alias is reserved")
+	ErrInvalidVariant      = errors.New("invalid variant")
+	ErrDomainNotRegistered = errors.New("domain is not registered")
+	ErrDomainForbidden     = errors.New("domain belongs to another user")
+)
+
+// reserved are the first path segments of the API routes, mounted at the
+// root and under /api/v1. Links with these aliases would be shadowed.
+var reserved = map[string]bool{
+	"url":      true,
+	"api":      true,
+	"docs":     true,
+	"openapi":  true,
+	"domain":   true,
+	"webhook":  true,
+	"admin":    true,
+	"campaign": true,
+}
+
+// Reserved reports whether alias can't be used for a link, see reserved.
+func Reserved(alias string) bool {
+	return reserved[strings.ToLower(alias)]
+}
+
+// Namespace returns the domain links of name are stored under:
+// name normalized, empty for defaultDomain.
+func Namespace(name string, defaultDomain string) string {
+	name = hostname.Normalize(name)
+	if name == hostname.Normalize(defaultDomain) {
+		return ""
+	}
+
+	return name
+}
+
+// Storage is what creating links needs from the storage.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=Storage
+type Storage interface {
+	SaveURL(u storage.URL, e storage.AuditEntry) (int64, error)
+	GetAliasByNormalized(domain string, normalized string, owner string) (string, error)
+	GetDomain(name string) (storage.Domain, error)
+}
+
+// Options configures how links are created.
+type Options struct {
+	// Dedup returns the existing alias when the same owner has already
+	// shortened the same normalized destination.
+	// Only applies to plain links: a custom alias or any other setting
+	// of the link (password, limits, params, ...) creates a new one.
+	Dedup bool
+	// StripTracking ignores tracking parameters (utm_*, fbclid, ...)
+	// when normalizing the destination.
+	StripTracking bool
+	// DefaultDomain is the main short domain, links on it are kept
+	// in the default namespace like links without domain.
+	DefaultDomain string
+}
+
+// Service creates links in the storage.
+type Service struct {
+	log     *slog.Logger
+	storage Storage
+	opts    Options
+}
+
+// New returns a service creating links with the options.
+func New(log *slog.Logger, storage Storage, opts Options) *Service {
+	return &Service{log: log, storage: storage, opts: opts}
+}
+
+// Save creates the link of link.Owner and returns its alias. The fields
+// are expected to be validated by the API, Save checks what needs the
+// storage or the options:
+//   - aliases of API routes are rejected, see Reserved;
+//   - Domain is normalized, the main domain is the default namespace and
+//     custom domains have to be registered by the owner;
+//   - with Dedup the alias of the same destination is returned instead;
+//   - unnamed variants are named A, B, C... by position;
+//   - password is hashed, without Alias a random one is generated.
+//
+// e is the audit entry of the caller (actor, IP and request id), the rest
+// is filled in. Storage errors like storage.ErrURLExists are returned wrapped.
+func (s *Service) Save(link storage.URL, password string, e storage.AuditEntry) (string, error) {
+	const op = "links.Save"
+
+	log := s.log.With(slog.String("op", op), slog.String("request_id", e.RequestID))
+
+	if Reserved(link.Alias) {
+		return "", fmt.Errorf("%s: %w", op, ErrReservedAlias)
+	}
+
+	normalized, err := urlnorm.Normalize(link.URL, urlnorm.Options{StripTracking: s.opts.StripTracking})
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, ErrInvalidURL)
+	}
+	link.Normalized = normalized
+
+	link.Domain = Namespace(link.Domain, s.opts.DefaultDomain)
+	if link.Domain != "" {
+		// only the owner of a custom domain may create links on it
+		d, err := s.storage.GetDomain(link.Domain)
+		if errors.Is(err, storage.ErrDomainNotFound) {
+			return "", fmt.Errorf("%s: %w", op, ErrDomainNotRegistered)
+		}
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		if d.Owner != link.Owner {
+			return "", fmt.Errorf("%s: %w", op, ErrDomainForbidden)
+		}
+	}
+
+	if s.opts.Dedup && link.Alias == "" && password == "" && plain(link) {
+		existing, err := s.storage.GetAliasByNormalized(link.Domain, normalized, link.Owner)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, storage.ErrUrlNotFound) {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	link.Variants, err = nameVariants(link.Variants)
+	if err != nil {
+		return "", err
+	}
+
+	if password != "" {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+		link.PasswordHash = string(hash)
+	}
+
+	if link.Alias == "" {
+		link.Alias = random.NewRandomString(aliasLength)
+	}
+
+	e.Action = storage.AuditCreate
+	e.Entity = storage.AuditEntityURL
+	e.Domain = link.Domain
+	e.Alias = link.Alias
+	e.After = audit.Snapshot(audit.NewLink(link))
+
+	id, err := s.storage.SaveURL(link, e)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("url added", slog.Int64("id", id))
+
+	return link.Alias, nil
+}
+
+// plain reports whether the link has nothing but a destination. The
+// existing link of the destination may be set up differently, so only
+// plain links are deduplicated.
+func plain(link storage.URL) bool {
+	return link.RedirectType == 0 && !link.Passthrough && len(link.Params) == 0 && link.Campaign == "" &&
+		link.MaxClicks == 0 && !link.Burn && link.ActiveFrom.IsZero() && link.ActiveUntil.IsZero() &&
+		link.PendingURL == "" && link.FallbackURL == "" && len(link.Rules) == 0 && len(link.Variants) == 0 &&
+		link.Title == "" && link.Description == "" && link.Image == ""
+}
+
+// nameVariants names unnamed variants by position and checks their
+// destinations against the same policy as the link URL. The errors are
+// shown to the caller as is.
+func nameVariants(variants []storage.Variant) ([]storage.Variant, error) {
+	named := make([]storage.Variant, 0, len(variants))
+	names := make(map[string]bool, len(variants))
+
+	for i, v := range variants {
+		if v.Name == "" {
+			v.Name = string(rune('A' + i))
+		}
+		if names[v.Name] {
+			return nil, fmt.Errorf("%w: duplicate name %s", ErrInvalidVariant, v.Name)
+		}
+		names[v.Name] = true
+
+		if _, err := urlnorm.Normalize(v.URL, urlnorm.Options{}); err != nil {
+			return nil, fmt.Errorf("%w: url is not valid", ErrInvalidVariant)
+		}
+
+		named = append(named, v)
+	}
+
+	return named, nil
+}
@@ -0,0 +1,236 @@
+package links_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/links"
+	"urlshortener/internal/links/mocks"
+	"urlshortener/internal/storage"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+func TestSave(t *testing.T) {
+	cases := []struct {
+		name     string
+		link     storage.URL
+		password string
+		// domainOwner owns the custom domain, empty if it isn't registered
+		domainOwner string
+		dedup       string
+		wantSave    func(u storage.URL) bool
+		saveError   error
+		wantAlias   string
+		wantErr     error
+	}{
+		{
+			name:      "Custom alias",
+			link:      storage.URL{Alias: "ex", URL: "https://Example.com/", Owner: "alice"},
+			wantSave:  func(u storage.URL) bool { return u.Alias == "ex" && u.Normalized == "https://example.com/" },
+			wantAlias: "ex",
+		},
+		{
+			name:     "Random alias",
+			link:     storage.URL{URL: "https://example.com", Owner: "alice"},
+			wantSave: func(u storage.URL) bool { return len(u.Alias) == 6 },
+		},
+		{
+			name:      "Main domain",
+			link:      storage.URL{Domain: "SHO.RT", Alias: "ex", URL: "https://example.com", Owner: "alice"},
+			wantSave:  func(u storage.URL) bool { return u.Domain == "" },
+			wantAlias: "ex",
+		},
+		{
+			name:        "Custom domain",
+			link:        storage.URL{Domain: "Go.Example.com", Alias: "ex", URL: "https://example.com", Owner: "alice"},
+			domainOwner: "alice",
+			wantSave:    func(u storage.URL) bool { return u.Domain == "go.example.com" },
+			wantAlias:   "ex",
+		},
+		{
+			name:    "Domain not registered",
+			link:    storage.URL{Domain: "go.example.com", URL: "https://example.com", Owner: "alice"},
+			wantErr: links.ErrDomainNotRegistered,
+		},
+		{
+			name:        "Domain of another user",
+			link:        storage.URL{Domain: "go.example.com", URL: "https://example.com", Owner: "alice"},
+			domainOwner: "bob",
+			wantErr:     links.ErrDomainForbidden,
+		},
+		{
+			name:    "Reserved alias",
+			link:    storage.URL{Alias: "Admin", URL: "https://example.com", Owner: "alice"},
+			wantErr: links.ErrReservedAlias,
+		},
+		{
+			name:    "Invalid url",
+			link:    storage.URL{URL: "ftp://", Owner: "alice"},
+			wantErr: links.ErrInvalidURL,
+		},
+		{
+			name:      "Deduplicated",
+			link:      storage.URL{URL: "https://example.com", Owner: "alice"},
+			dedup:     "old",
+			wantAlias: "old",
+		},
+		{
+			name:     "Password",
+			link:     storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice"},
+			password: "secret",
+			wantSave: func(u storage.URL) bool {
+				return u.PasswordHash != "" && u.PasswordHash != "secret"
+			},
+			wantAlias: "ex",
+		},
+		{
+			name: "Variants are named",
+			link: storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice", Variants: []storage.Variant{
+				{URL: "https://a.example.com", Weight: 1},
+				{Name: "second", URL: "https://b.example.com", Weight: 1},
+			}},
+			wantSave: func(u storage.URL) bool {
+				return len(u.Variants) == 2 && u.Variants[0].Name == "A" && u.Variants[1].Name == "second"
+			},
+			wantAlias: "ex",
+		},
+		{
+			name: "Duplicate variant",
+			link: storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice", Variants: []storage.Variant{
+				{URL: "https://a.example.com", Weight: 1},
+				{Name: "A", URL: "https://b.example.com", Weight: 1},
+			}},
+			wantErr: links.ErrInvalidVariant,
+		},
+		{
+			name:      "Alias taken",
+			link:      storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice"},
+			wantSave:  func(u storage.URL) bool { return true },
+			saveError: storage.ErrURLExists,
+			wantErr:   storage.ErrURLExists,
+		},
+		{
+			name:      "Storage error",
+			link:      storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice"},
+			wantSave:  func(u storage.URL) bool { return true },
+			saveError: errors.New("unexpected error"),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := mocks.NewStorage(t)
+			if tc.link.Domain == "go.example.com" || tc.link.Domain == "Go.Example.com" {
+				if tc.domainOwner == "" {
+					st.On("GetDomain", "go.example.com").Return(storage.Domain{}, storage.ErrDomainNotFound).Once()
+				} else {
+					st.On("GetDomain", "go.example.com").Return(storage.Domain{Name: "go.example.com", Owner: tc.domainOwner}, nil).Once()
+				}
+			}
+			if tc.dedup != "" {
+				st.On("GetAliasByNormalized", "", "https://example.com/", "alice").Return(tc.dedup, nil).Once()
+			} else {
+				st.On("GetAliasByNormalized", mock.Anything, mock.Anything, "alice").
+					Return("", storage.ErrUrlNotFound).Maybe()
+			}
+			if tc.wantSave != nil {
+				st.On("SaveURL", mock.MatchedBy(tc.wantSave), mock.MatchedBy(func(e storage.AuditEntry) bool {
+					return e.Actor == "alice" && e.Action == storage.AuditCreate && e.Entity == storage.AuditEntityURL &&
+						e.RequestID == "req" && e.After != nil
+				})).Return(int64(1), tc.saveError).Once()
+			}
+
+			svc := links.New(slogdiscard.NewDiscardLogger(), st, links.Options{Dedup: true, DefaultDomain: "sho.rt"})
+
+			alias, err := svc.Save(tc.link, tc.password, storage.AuditEntry{Actor: "alice", RequestID: "req"})
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			if tc.saveError != nil {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			if tc.wantAlias != "" {
+				assert.Equal(t, tc.wantAlias, alias)
+			}
+		})
+	}
+}
+
+// TestDedupSkipsOptions keeps links with settings apart: the existing
+// link of the destination may redirect differently.
+func TestDedupSkipsOptions(t *testing.T) {
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		set      func(u *storage.URL)
+		password string
+	}{
+		{name: "Alias", set: func(u *storage.URL) { u.Alias = "ex" }},
+		{name: "Password", password: "secret"},
+		{name: "Redirect type", set: func(u *storage.URL) { u.RedirectType = 301 }},
+		{name: "Passthrough", set: func(u *storage.URL) { u.Passthrough = true }},
+		{name: "Params", set: func(u *storage.URL) { u.Params = map[string]string{"utm_source": "x"} }},
+		{name: "Campaign", set: func(u *storage.URL) { u.Campaign = "spring" }},
+		{name: "Max clicks", set: func(u *storage.URL) { u.MaxClicks = 5 }},
+		{name: "Burn", set: func(u *storage.URL) { u.Burn = true }},
+		{name: "Active from", set: func(u *storage.URL) { u.ActiveFrom = at }},
+		{name: "Active until", set: func(u *storage.URL) { u.ActiveUntil = at }},
+		{name: "Pending url", set: func(u *storage.URL) { u.PendingURL = "https://example.com/soon" }},
+		{name: "Fallback url", set: func(u *storage.URL) { u.FallbackURL = "https://example.com/over" }},
+		{name: "Rules", set: func(u *storage.URL) { u.Rules = []storage.Rule{{OS: "ios", URL: "https://apps.apple.com"}} }},
+		{name: "Variants", set: func(u *storage.URL) {
+			u.Variants = []storage.Variant{{URL: "https://a.example.com", Weight: 1}}
+		}},
+		{name: "Title", set: func(u *storage.URL) { u.Title = "Title" }},
+		{name: "Description", set: func(u *storage.URL) { u.Description = "Description" }},
+		{name: "Image", set: func(u *storage.URL) { u.Image = "https://example.com/a.png" }},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			link := storage.URL{URL: "https://example.com", Owner: "alice"}
+			if tc.set != nil {
+				tc.set(&link)
+			}
+
+			// GetAliasByNormalized is not expected
+			st := mocks.NewStorage(t)
+			st.On("SaveURL", mock.Anything, mock.Anything).Return(int64(1), nil).Once()
+
+			svc := links.New(slogdiscard.NewDiscardLogger(), st, links.Options{Dedup: true})
+
+			_, err := svc.Save(link, tc.password, storage.AuditEntry{Actor: "alice"})
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOwn(t *testing.T) {
+	getter := mocks.NewGetter(t)
+	getter.On("GetURL", "", "mine").Return(storage.URL{Alias: "mine", Owner: "alice"}, nil)
+	getter.On("GetURL", "", "theirs").Return(storage.URL{Alias: "theirs", Owner: "bob"}, nil)
+	getter.On("GetURL", "", "gone").Return(storage.URL{}, storage.ErrURLDeleted)
+
+	link, err := links.Own(getter, "alice", "", "mine")
+	require.NoError(t, err)
+	assert.Equal(t, "mine", link.Alias)
+
+	_, err = links.Own(getter, "alice", "", "theirs")
+	require.ErrorIs(t, err, storage.ErrUrlNotFound)
+
+	_, err = links.Own(getter, "alice", "", "gone")
+	require.ErrorIs(t, err, storage.ErrURLDeleted)
+}
@@ -0,0 +1,32 @@
+package links
+
+import (
+	"fmt"
+
+	"urlshortener/internal/storage"
+)
+
+// Getter finds links, the handlers of a single link have it.
+//
+//go:generate go run github.com/vektra/mockery/v2@latest --name=Getter
+type Getter interface {
+	GetURL(domain string, alias string) (storage.URL, error)
+}
+
+// Own returns the link if it belongs to caller. Links of other users
+// are not found, like in lists: storage.ErrUrlNotFound is returned for
+// them. Links created before links had owners belong to the configured
+// user, see sqlite.Options.LegacyOwner.
+func Own(getter Getter, caller string, domain string, alias string) (storage.URL, error) {
+	const op = "links.Own"
+
+	link, err := getter.GetURL(domain, alias)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if link.Owner != caller {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrUrlNotFound)
+	}
+
+	return link, nil
+}
@@ -0,0 +1,20 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrAPIKeyNotFound indicates no key has the hash.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey authenticates requests of Owner without a password.
+// Only the hash of the key is kept, the key is shown once when created.
+type APIKey struct {
+	ID    int64
+	Owner string
+	// Name tells keys of the same owner apart ("ci", "laptop").
+	Name      string
+	Hash      string
+	CreatedAt time.Time
+}
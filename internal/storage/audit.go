@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audit actions.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// Audited entities.
+const (
+	AuditEntityURL      = "url"
+	AuditEntityCampaign = "campaign"
+	AuditEntityDomain   = "domain"
+	AuditEntityWebhook  = "webhook"
+	// AuditEntityStorage is the whole storage, restored from a backup.
+	AuditEntityStorage = "storage"
+)
+
+// AuditEntry records a single mutation. Entries are append-only and
+// chained: every entry includes the hash of the previous one, so changing
+// or removing an entry breaks the hashes of all entries after it.
+type AuditEntry struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Actor is the authenticated user, empty for anonymous visitors.
+	Actor  string `json:"actor"`
+	Action string `json:"action"`
+	Entity string `json:"entity"`
+	Domain string `json:"domain,omitempty"`
+	// Alias is the link alias, or the name of a campaign or domain.
+	Alias string `json:"alias"`
+	// Before and After are JSON snapshots, empty if there is none
+	// (before a create, after a delete).
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip"`
+	RequestID string          `json:"request_id"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the entry: SHA-256 over the
+// previous hash and every field except ID and Hash.
+func (e AuditEntry) ComputeHash() string {
+	b, _ := json.Marshal([]string{
+		e.PrevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.Actor, e.Action, e.Entity, e.Domain, e.Alias,
+		string(e.Before), string(e.After),
+		e.IP, e.RequestID,
+	})
+
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter selects audit entries, zero fields match everything.
+type AuditFilter struct {
+	Actor  string
+	Action string
+	Entity string
+	Domain string
+	Alias  string
+	From   time.Time
+	To     time.Time
+	// AfterID pages through entries, they are ordered by ID.
+	AfterID int64
+	Limit   int
+}
+
+// AuditVerification is the result of checking the hash chain.
+type AuditVerification struct {
+	Entries int64 `json:"entries"`
+	Valid   bool  `json:"valid"`
+	// BrokenAt is the ID of the first entry whose hash doesn't match.
+	BrokenAt int64 `json:"broken_at,omitempty"`
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrIdempotencyKeyExists = errors.New("idempotency key exists")
+
+// IdempotencyKey is a request made with an Idempotency-Key header
+// and, once it has completed, its response.
+type IdempotencyKey struct {
+	// Owner is the authenticated principal, keys of different owners never clash.
+	Owner string
+	Key   string
+	// RequestHash tells a retry from a different request reusing the key.
+	RequestHash string
+	// Status is 0 while the request is in progress.
+	Status      int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
@@ -0,0 +1,24 @@
+package storage
+
+// Totals are row counts of the whole storage, for maintenance.
+type Totals struct {
+	Links int64 `json:"links"`
+	// DeletedLinks are tombstones holding their alias.
+	DeletedLinks      int64 `json:"deleted_links"`
+	Clicks            int64 `json:"clicks"`
+	Domains           int64 `json:"domains"`
+	Campaigns         int64 `json:"campaigns"`
+	Webhooks          int64 `json:"webhooks"`
+	PendingDeliveries int64 `json:"pending_deliveries"`
+	DeadDeliveries    int64 `json:"dead_deliveries"`
+	AuditEntries      int64 `json:"audit_entries"`
+	IdempotencyKeys   int64 `json:"idempotency_keys"`
+	APIKeys           int64 `json:"api_keys"`
+}
+
+// Purged are the numbers of rows removed by a purge of expired data.
+type Purged struct {
+	// Links are tombstones past the alias quarantine.
+	Links           int64 `json:"links"`
+	IdempotencyKeys int64 `json:"idempotency_keys"`
+}
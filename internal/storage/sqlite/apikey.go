@@ -0,0 +1,46 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// SaveAPIKey stores a key by its hash.
+func (s *Storage) SaveAPIKey(k storage.APIKey) (int64, error) {
+	const fn = "storage.sqlite.SaveAPIKey"
+
+	res, err := s.db.Exec("INSERT INTO api_key(owner, name, hash, created_at) VALUES(?, ?, ?, ?)",
+		k.Owner, k.Name, k.Hash, time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// GetAPIKey returns the key with the hash.
+func (s *Storage) GetAPIKey(hash string) (storage.APIKey, error) {
+	const fn = "storage.sqlite.GetAPIKey"
+
+	k := storage.APIKey{Hash: hash}
+
+	err := s.db.QueryRow("SELECT id, owner, name, created_at FROM api_key WHERE hash = ?", hash).
+		Scan(&k.ID, &k.Owner, &k.Name, &k.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.APIKey{}, storage.ErrAPIKeyNotFound
+		}
+		return storage.APIKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return k, nil
+}
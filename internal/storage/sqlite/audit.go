@@ -0,0 +1,208 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// auditTimeLayout is the layout of audit created_at: fixed-width UTC, so
+// that timestamps order as text. RFC3339Nano trims trailing zeros and
+// "05.1Z" would sort after "05.12Z".
+const auditTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+const auditColumns = "id, created_at, actor, action, entity, domain, alias, before, after, " +
+	"ip, request_id, prev_hash, hash"
+
+// defaultAuditLimit and maxAuditLimit bound ListAudit pages.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AppendAudit adds an entry of a change made outside the storage, like
+// restoring a backup. Mutations of the storage take their entry and
+// append it in their own transaction.
+func (s *Storage) AppendAudit(e storage.AuditEntry) error {
+	const fn = "storage.sqlite.AppendAudit"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// appendAudit adds the entry to the end of the audit chain within tx, so
+// it is committed together with the mutation it records, or not at all.
+// CreatedAt, PrevHash and Hash are set here. The caller holds auditMu
+// until tx is done: the entry is chained to the last one read here.
+func appendAudit(tx *sql.Tx, e storage.AuditEntry) error {
+	err := tx.QueryRow("SELECT hash FROM audit ORDER BY id DESC LIMIT 1").Scan(&e.PrevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("append audit: %w", err)
+	}
+
+	e.CreatedAt = time.Now().UTC()
+	e.Hash = e.ComputeHash()
+
+	_, err = tx.Exec(`INSERT INTO audit(created_at, actor, action, entity, domain, alias, before, after,
+		ip, request_id, prev_hash, hash) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.CreatedAt.Format(auditTimeLayout), e.Actor, e.Action, e.Entity, e.Domain, e.Alias,
+		string(e.Before), string(e.After), e.IP, e.RequestID, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("append audit: %w", err)
+	}
+
+	return nil
+}
+
+// ListAudit returns entries matching the filter ordered by ID.
+func (s *Storage) ListAudit(f storage.AuditFilter) ([]storage.AuditEntry, error) {
+	const fn = "storage.sqlite.ListAudit"
+
+	var (
+		where []string
+		args  []any
+	)
+	add := func(cond string, arg any) {
+		where = append(where, cond)
+		args = append(args, arg)
+	}
+
+	if f.Actor != "" {
+		add("actor = ?", f.Actor)
+	}
+	if f.Action != "" {
+		add("action = ?", f.Action)
+	}
+	if f.Entity != "" {
+		add("entity = ?", f.Entity)
+	}
+	if f.Domain != "" {
+		add("domain = ?", f.Domain)
+	}
+	if f.Alias != "" {
+		add("alias = ?", f.Alias)
+	}
+	// fixed-width UTC timestamps compare as text, see auditTimeLayout
+	if !f.From.IsZero() {
+		add("created_at >= ?", f.From.UTC().Format(auditTimeLayout))
+	}
+	if !f.To.IsZero() {
+		add("created_at < ?", f.To.UTC().Format(auditTimeLayout))
+	}
+	if f.AfterID > 0 {
+		add("id > ?", f.AfterID)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+	limit = min(limit, maxAuditLimit)
+
+	query := "SELECT " + auditColumns + " FROM audit"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	entries := []storage.AuditEntry{}
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return entries, nil
+}
+
+// VerifyAudit walks the whole chain and recomputes the hashes.
+func (s *Storage) VerifyAudit() (storage.AuditVerification, error) {
+	const fn = "storage.sqlite.VerifyAudit"
+
+	rows, err := s.db.Query("SELECT " + auditColumns + " FROM audit ORDER BY id")
+	if err != nil {
+		return storage.AuditVerification{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	v := storage.AuditVerification{Valid: true}
+	var prev string
+
+	for rows.Next() {
+		e, err := scanAudit(rows)
+		if err != nil {
+			return storage.AuditVerification{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		v.Entries++
+
+		if v.Valid && (e.PrevHash != prev || e.ComputeHash() != e.Hash) {
+			v.Valid = false
+			v.BrokenAt = e.ID
+		}
+		prev = e.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return storage.AuditVerification{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return v, nil
+}
+
+func scanAudit(row scanner) (storage.AuditEntry, error) {
+	var (
+		e             storage.AuditEntry
+		createdAt     string
+		before, after string
+	)
+
+	err := row.Scan(&e.ID, &createdAt, &e.Actor, &e.Action, &e.Entity, &e.Domain, &e.Alias,
+		&before, &after, &e.IP, &e.RequestID, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return e, err
+	}
+
+	e.CreatedAt, err = time.Parse(auditTimeLayout, createdAt)
+	if err != nil {
+		return e, fmt.Errorf("parse created_at: %w", err)
+	}
+	if before != "" {
+		e.Before = []byte(before)
+	}
+	if after != "" {
+		e.After = []byte(after)
+	}
+
+	return e, nil
+}
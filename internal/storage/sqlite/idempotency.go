@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"fmt"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// ReserveIdempotencyKey stores the key of a request about to be handled.
+// If the owner already has the key, it returns the stored one with
+// storage.ErrIdempotencyKeyExists. Keys created before expiredBefore
+// are dropped first.
+func (s *Storage) ReserveIdempotencyKey(k storage.IdempotencyKey, expiredBefore time.Time) (storage.IdempotencyKey, error) {
+	const fn = "storage.sqlite.ReserveIdempotencyKey"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("DELETE FROM idempotency_key WHERE created_at < ?", expiredBefore.UTC()); err != nil {
+		return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	res, err := tx.Exec(`INSERT INTO idempotency_key(owner, key, request_hash, created_at) VALUES(?, ?, ?, ?)
+		ON CONFLICT(owner, key) DO NOTHING`, k.Owner, k.Key, k.RequestHash, time.Now().UTC())
+	if err != nil {
+		return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		existing := storage.IdempotencyKey{Owner: k.Owner, Key: k.Key}
+		err := tx.QueryRow(`SELECT request_hash, status, content_type, body, created_at
+			FROM idempotency_key WHERE owner = ? AND key = ?`, k.Owner, k.Key).
+			Scan(&existing.RequestHash, &existing.Status, &existing.ContentType, &existing.Body, &existing.CreatedAt)
+		if err != nil {
+			return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+		}
+
+		return existing, storage.ErrIdempotencyKeyExists
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.IdempotencyKey{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return k, nil
+}
+
+// SaveIdempotentResponse stores the response of a reserved key,
+// it is returned to retries from now on.
+func (s *Storage) SaveIdempotentResponse(owner, key string, status int, contentType string, body []byte) error {
+	const fn = "storage.sqlite.SaveIdempotentResponse"
+
+	_, err := s.db.Exec("UPDATE idempotency_key SET status = ?, content_type = ?, body = ? WHERE owner = ? AND key = ?",
+		status, contentType, body, owner, key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// DeleteIdempotencyKey releases a reserved key, so the request can be retried.
+func (s *Storage) DeleteIdempotencyKey(owner, key string) error {
+	const fn = "storage.sqlite.DeleteIdempotencyKey"
+
+	if _, err := s.db.Exec("DELETE FROM idempotency_key WHERE owner = ? AND key = ?", owner, key); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,218 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// Close closes the database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Backup writes a consistent copy of the database to path,
+// which must not exist. Writers are not blocked meanwhile.
+func (s *Storage) Backup(path string) error {
+	const fn = "storage.sqlite.Backup"
+
+	if _, err := s.db.Exec("VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// Vacuum rebuilds the database file, returning the space of deleted rows.
+func (s *Storage) Vacuum() error {
+	const fn = "storage.sqlite.Vacuum"
+
+	if _, err := s.db.Exec("VACUUM"); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// PurgeExpired removes tombstones past the alias quarantine and
+// idempotency keys older than idempotencyWindow. Each removed tombstone
+// is recorded in the audit log as a purge by e, with its domain and alias.
+func (s *Storage) PurgeExpired(now time.Time, idempotencyWindow time.Duration, e storage.AuditEntry) (storage.Purged, error) {
+	const fn = "storage.sqlite.PurgeExpired"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var purged storage.Purged
+
+	rows, err := tx.Query("DELETE FROM url WHERE deleted_at IS NOT NULL AND deleted_at <= ? RETURNING domain, alias",
+		now.UTC().Add(-s.opts.AliasQuarantine))
+	if err != nil {
+		return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var tombstones [][2]string
+	for rows.Next() {
+		var domain, alias string
+		if err := rows.Scan(&domain, &alias); err != nil {
+			rows.Close()
+			return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		tombstones = append(tombstones, [2]string{domain, alias})
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	purged.Links = int64(len(tombstones))
+
+	e.Action = storage.AuditPurge
+	e.Entity = storage.AuditEntityURL
+	for _, t := range tombstones {
+		e.Domain, e.Alias = t[0], t[1]
+		if err := appendAudit(tx, e); err != nil {
+			return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	purged.IdempotencyKeys, err = deleteRows(tx, "DELETE FROM idempotency_key WHERE created_at < ?",
+		now.UTC().Add(-idempotencyWindow))
+	if err != nil {
+		return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.Purged{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return purged, nil
+}
+
+func deleteRows(tx *sql.Tx, query string, args ...any) (int64, error) {
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// Totals counts the rows of the whole storage.
+func (s *Storage) Totals() (storage.Totals, error) {
+	const fn = "storage.sqlite.Totals"
+
+	var t storage.Totals
+
+	err := s.db.QueryRow(`SELECT
+		(SELECT COUNT(*) FROM url WHERE deleted_at IS NULL),
+		(SELECT COUNT(*) FROM url WHERE deleted_at IS NOT NULL),
+		(SELECT COALESCE(SUM(clicks), 0) FROM url),
+		(SELECT COUNT(*) FROM domain),
+		(SELECT COUNT(*) FROM campaign),
+		(SELECT COUNT(*) FROM webhook),
+		(SELECT COUNT(*) FROM webhook_outbox WHERE status = ?),
+		(SELECT COUNT(*) FROM webhook_outbox WHERE status = ?),
+		(SELECT COUNT(*) FROM audit),
+		(SELECT COUNT(*) FROM idempotency_key),
+		(SELECT COUNT(*) FROM api_key)`,
+		storage.DeliveryPending, storage.DeliveryDead,
+	).Scan(&t.Links, &t.DeletedLinks, &t.Clicks, &t.Domains, &t.Campaigns, &t.Webhooks,
+		&t.PendingDeliveries, &t.DeadDeliveries, &t.AuditEntries, &t.IdempotencyKeys, &t.APIKeys)
+	if err != nil {
+		return storage.Totals{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return t, nil
+}
+
+// Restore replaces the database at storagePath with a copy of a backup
+// made by Backup. The backup is checked first, the database is left as it
+// was if the backup is broken. Nothing may use the database meanwhile.
+func Restore(backupPath string, storagePath string) error {
+	const fn = "storage.sqlite.Restore"
+
+	if err := checkBackup(backupPath); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	src, err := os.Open(backupPath)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer src.Close()
+
+	// copied next to the database and renamed over it, so the database
+	// is either the old one or the backup, never half of it
+	tmp, err := os.CreateTemp(filepath.Dir(storagePath), filepath.Base(storagePath)+".restore-*")
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, src)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// a journal left by a crash would be applied to the restored file
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if err := os.Remove(storagePath + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := os.Rename(tmp.Name(), storagePath); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// checkBackup makes sure path is an intact database of this service.
+func checkBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("check backup: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("backup is corrupt: %s", result)
+	}
+
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'url'").Scan(&n); err != nil {
+		return fmt.Errorf("check backup: %w", err)
+	}
+	if n == 0 {
+		return errors.New("backup has no url table")
+	}
+
+	return nil
+}
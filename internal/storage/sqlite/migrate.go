@@ -0,0 +1,412 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// schema creates the tables. The url table is kept as it was in the first
+// version, columns added later go to columns below so that old databases
+// are upgraded the same way new ones are created.
+var schema = []string{
+	`CREATE TABLE IF NOT EXISTS url(
+		id INTEGER PRIMARY KEY,
+		alias TEXT NOT NULL UNIQUE,
+		url TEXT NOT NULL)`,
+	`CREATE TABLE IF NOT EXISTS campaign(
+		name TEXT PRIMARY KEY,
+		params TEXT NOT NULL DEFAULT '')`,
+	`CREATE TABLE IF NOT EXISTS url_rule(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		os TEXT NOT NULL DEFAULT '',
+		device TEXT NOT NULL DEFAULT '',
+		visitor TEXT NOT NULL DEFAULT '',
+		language TEXT NOT NULL DEFAULT '',
+		url TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS idx_url_rule_url_id ON url_rule(url_id, position)`,
+	`CREATE TABLE IF NOT EXISTS url_variant(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		weight INTEGER NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS idx_url_variant_url_id ON url_variant(url_id, position)`,
+	// click analytics: one row per redirect
+	`CREATE TABLE IF NOT EXISTS click(
+		id INTEGER PRIMARY KEY,
+		url_id INTEGER NOT NULL,
+		variant TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)`,
+	`CREATE INDEX IF NOT EXISTS idx_click_url_id ON click(url_id, variant)`,
+	// custom short domains, links of the default domain have empty domain
+	`CREATE TABLE IF NOT EXISTS domain(
+		name TEXT PRIMARY KEY,
+		owner TEXT NOT NULL,
+		not_found_url TEXT NOT NULL DEFAULT '',
+		root_url TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP)`,
+	// append-only audit log, created_at is kept as text, see auditTimeLayout
+	// exactly as it was hashed
+	`CREATE TABLE IF NOT EXISTS audit(
+		id INTEGER PRIMARY KEY,
+		created_at TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		entity TEXT NOT NULL,
+		domain TEXT NOT NULL,
+		alias TEXT NOT NULL,
+		before TEXT NOT NULL,
+		after TEXT NOT NULL,
+		ip TEXT NOT NULL,
+		request_id TEXT NOT NULL,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_alias ON audit(domain, alias)`,
+	`CREATE INDEX IF NOT EXISTS idx_audit_actor ON audit(actor)`,
+	// events is a comma separated list
+	`CREATE TABLE IF NOT EXISTS webhook(
+		id INTEGER PRIMARY KEY,
+		owner TEXT NOT NULL,
+		url TEXT NOT NULL,
+		secret TEXT NOT NULL,
+		events TEXT NOT NULL,
+		created_at DATETIME NOT NULL)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_owner ON webhook(owner)`,
+	// outbox: events are queued in the transaction that changes the link
+	// and delivered in the background, rows are kept as the delivery log
+	`CREATE TABLE IF NOT EXISTS webhook_outbox(
+		id INTEGER PRIMARY KEY,
+		webhook_id INTEGER NOT NULL,
+		event TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		status TEXT NOT NULL,
+		attempts INTEGER NOT NULL DEFAULT 0,
+		next_attempt_at DATETIME,
+		last_status_code INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		delivered_at DATETIME)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_pending ON webhook_outbox(status, next_attempt_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_webhook_outbox_webhook ON webhook_outbox(webhook_id, id)`,
+	// responses to requests with an Idempotency-Key, status is 0 while
+	// the request is in progress
+	`CREATE TABLE IF NOT EXISTS idempotency_key(
+		owner TEXT NOT NULL,
+		key TEXT NOT NULL,
+		request_hash TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		content_type TEXT NOT NULL DEFAULT '',
+		body BLOB,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY(owner, key))`,
+	`CREATE INDEX IF NOT EXISTS idx_idempotency_key_created_at ON idempotency_key(created_at)`,
+	// keys are looked up by their sha256, the key itself isn't kept
+	`CREATE TABLE IF NOT EXISTS api_key(
+		id INTEGER PRIMARY KEY,
+		owner TEXT NOT NULL,
+		name TEXT NOT NULL,
+		hash TEXT NOT NULL UNIQUE,
+		created_at DATETIME NOT NULL)`,
+}
+
+type column struct {
+	table      string
+	name       string
+	definition string
+}
+
+// columns are added with ALTER TABLE if they are missing.
+var columns = []column{
+	{"url", "normalized", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "owner", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "redirect_type", "INTEGER NOT NULL DEFAULT 0"},
+	{"url", "passthrough", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"url", "params", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "campaign", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "password_hash", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "clicks", "INTEGER NOT NULL DEFAULT 0"},
+	{"url", "max_clicks", "INTEGER NOT NULL DEFAULT 0"},
+	{"url", "burn", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"url", "active_from", "DATETIME"},
+	{"url", "active_until", "DATETIME"},
+	{"url", "pending_url", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "fallback_url", "TEXT NOT NULL DEFAULT ''"},
+	// ADD COLUMN can't default to CURRENT_TIMESTAMP, SaveURL sets it
+	{"url", "created_at", "DATETIME"},
+	{"url", "og_title", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "og_description", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "og_image", "TEXT NOT NULL DEFAULT ''"},
+	{"url", "domain", "TEXT NOT NULL DEFAULT ''"},
+	// tombstone: deleted links keep their alias until purged
+	{"url", "deleted_at", "DATETIME"},
+	// link.expired has been queued
+	{"url", "expired_notified", "BOOLEAN NOT NULL DEFAULT 0"},
+}
+
+// rebuilds change constraints SQLite can't ALTER. They run once, in order,
+// after columns are added; PRAGMA user_version counts the applied ones.
+var rebuilds = []func(tx *sql.Tx) error{
+	// alias is unique per domain instead of globally
+	func(tx *sql.Tx) error { return rebuildTable(tx, "url") },
+	// audit created_at was RFC3339Nano, which doesn't order as text
+	padAuditTimes,
+	// active_from and active_until were kept in the zone of the request
+	utcActiveTimes,
+}
+
+// indexes (and triggers) run after columns, so they may refer to added columns.
+var indexes = []string{
+	`CREATE UNIQUE INDEX IF NOT EXISTS idx_domain_alias ON url(domain, alias)`,
+	`CREATE INDEX IF NOT EXISTS idx_owner_normalized ON url(domain, owner, normalized)`,
+	// rowids of deleted links may be reused, rules must not outlive their link
+	`CREATE TRIGGER IF NOT EXISTS trg_url_rule_cleanup AFTER DELETE ON url BEGIN
+		DELETE FROM url_rule WHERE url_id = OLD.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_audit_no_update BEFORE UPDATE ON audit BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_audit_no_delete BEFORE DELETE ON audit BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_webhook_cleanup AFTER DELETE ON webhook BEGIN
+		DELETE FROM webhook_outbox WHERE webhook_id = OLD.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS trg_url_variant_cleanup AFTER DELETE ON url BEGIN
+		DELETE FROM url_variant WHERE url_id = OLD.id;
+		DELETE FROM click WHERE url_id = OLD.id;
+	END`,
+}
+
+// migrate brings the database schema up to date.
+// Every step is idempotent, so it is safe to run on each start.
+func migrate(db *sql.DB) error {
+	const fn = "storage.sqlite.migrate"
+
+	for _, stmt := range schema {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	for _, c := range columns {
+		if err := addColumn(db, c); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := rebuild(db); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for _, stmt := range indexes {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	return nil
+}
+
+// addColumn adds column c unless the table already has it.
+// SQLite has no ADD COLUMN IF NOT EXISTS.
+func addColumn(db *sql.DB, c column) error {
+	var n int
+
+	err := db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", c.table, c.name,
+	).Scan(&n)
+	if err != nil {
+		return fmt.Errorf("check column %s.%s: %w", c.table, c.name, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", c.table, c.name, c.definition))
+	if err != nil {
+		return fmt.Errorf("add column %s.%s: %w", c.table, c.name, err)
+	}
+
+	return nil
+}
+
+// rebuild applies rebuilds newer than the database user_version.
+func rebuild(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("get user_version: %w", err)
+	}
+
+	for i := version; i < len(rebuilds); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if err := rebuilds[i](tx); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("rebuild %d: %w", i+1, err)
+		}
+
+		// PRAGMA doesn't take parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("set user_version: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildTable recreates table with its current columns but without
+// constraints other than NOT NULL, DEFAULT and the primary key, keeping
+// the rows and their ids. Indexes and triggers are dropped with the old
+// table and created again by indexes.
+func rebuildTable(tx *sql.Tx, table string) error {
+	rows, err := tx.Query(
+		"SELECT name, type, \"notnull\", dflt_value, pk FROM pragma_table_info(?) ORDER BY cid", table,
+	)
+	if err != nil {
+		return err
+	}
+
+	var defs, names []string
+	for rows.Next() {
+		var (
+			name, typ   string
+			notNull, pk bool
+			dflt        sql.NullString
+		)
+		if err := rows.Scan(&name, &typ, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+
+		def := name + " " + typ
+		if pk {
+			def += " PRIMARY KEY"
+		}
+		if notNull {
+			def += " NOT NULL"
+		}
+		if dflt.Valid {
+			def += " DEFAULT " + dflt.String
+		}
+
+		defs = append(defs, def)
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	tmp := table + "_rebuild"
+	cols := strings.Join(names, ", ")
+
+	stmts := []string{
+		fmt.Sprintf("CREATE TABLE %s(%s)", tmp, strings.Join(defs, ", ")),
+		fmt.Sprintf("INSERT INTO %s(%s) SELECT %s FROM %s", tmp, cols, cols, table),
+		fmt.Sprintf("DROP TABLE %s", table),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", tmp, table),
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// padAuditTimes rewrites audit created_at in auditTimeLayout. The instant
+// is the same, so the hashes still match. The append-only trigger is
+// created again by indexes.
+func padAuditTimes(tx *sql.Tx) error {
+	if _, err := tx.Exec("DROP TRIGGER IF EXISTS trg_audit_no_update"); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, created_at FROM audit")
+	if err != nil {
+		return err
+	}
+
+	padded := make(map[int64]string)
+	for rows.Next() {
+		var (
+			id        int64
+			createdAt string
+		)
+		if err := rows.Scan(&id, &createdAt); err != nil {
+			rows.Close()
+			return err
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, createdAt)
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("parse audit %d created_at: %w", id, err)
+		}
+		padded[id] = t.UTC().Format(auditTimeLayout)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, createdAt := range padded {
+		if _, err := tx.Exec("UPDATE audit SET created_at = ? WHERE id = ?", createdAt, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// utcActiveTimes converts active_from and active_until of the links to
+// UTC, see nullTime.
+func utcActiveTimes(tx *sql.Tx) error {
+	rows, err := tx.Query("SELECT id, active_from, active_until FROM url " +
+		"WHERE active_from IS NOT NULL OR active_until IS NOT NULL")
+	if err != nil {
+		return err
+	}
+
+	type window struct{ from, until sql.NullTime }
+	windows := make(map[int64]window)
+	for rows.Next() {
+		var (
+			id int64
+			w  window
+		)
+		if err := rows.Scan(&id, &w.from, &w.until); err != nil {
+			rows.Close()
+			return err
+		}
+		windows[id] = w
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, w := range windows {
+		_, err := tx.Exec("UPDATE url SET active_from = ?, active_until = ? WHERE id = ?",
+			nullTime(w.from.Time), nullTime(w.until.Time), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
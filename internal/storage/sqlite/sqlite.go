@@ -2,56 +2,166 @@ package sqlite
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 	"urlshortener/internal/storage"
 
 	"github.com/mattn/go-sqlite3"
 )
 
 type Storage struct {
-	db *sql.DB
+	db   *sql.DB
+	opts Options
+
+	// auditMu orders transactions appending to the audit log, each one
+	// reads the last hash
+	auditMu sync.Mutex
 }
 
-func New(storagePath string) (*Storage, error) {
-	const fn = "storage.sqlite.New"
+// Options configures the storage.
+type Options struct {
+	// AliasQuarantine is how long the alias of a deleted link can't be
+	// taken by a new one. The tombstone is purged when it is reused.
+	AliasQuarantine time.Duration
+	// ClickThresholds are click counts that queue link.clicks_threshold.
+	ClickThresholds []int64
+	// LegacyOwner is given the links created before links had owners,
+	// nobody could reach them over the API otherwise.
+	LegacyOwner string
+}
 
-	db, err := sql.Open("sqlite3", storagePath)
+// urlColumns are selected by every query returning storage.URL,
+// in the order scanURL reads them.
+const urlColumns = "id, domain, alias, url, normalized, owner, redirect_type, passthrough, params, campaign, password_hash, " +
+	"clicks, max_clicks, burn, active_from, active_until, pending_url, fallback_url, created_at, " +
+	"og_title, og_description, og_image"
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanURL reads urlColumns followed by extra columns, if any.
+func scanURL(row scanner, extra ...any) (storage.URL, error) {
+	var (
+		u                                  storage.URL
+		params                             string
+		activeFrom, activeUntil, createdAt sql.NullTime
+	)
+
+	dest := []any{&u.ID, &u.Domain, &u.Alias, &u.URL, &u.Normalized, &u.Owner, &u.RedirectType, &u.Passthrough,
+		&params, &u.Campaign, &u.PasswordHash, &u.Clicks, &u.MaxClicks, &u.Burn,
+		&activeFrom, &activeUntil, &u.PendingURL, &u.FallbackURL, &createdAt,
+		&u.Title, &u.Description, &u.Image}
+
+	err := row.Scan(append(dest, extra...)...)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", fn, err)
+		return u, err
 	}
 
-	stmt, err := db.Prepare(`
-	CREATE TABLE IF NOT EXISTS url(
-		id INTEGER PRIMARY KEY,
-		alias TEXT NOT NULL UNIQUE,
-		url TEXT NOT NULL);
-	CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
-	`)
+	u.ActiveFrom = activeFrom.Time
+	u.ActiveUntil = activeUntil.Time
+	u.CreatedAt = createdAt.Time
+
+	u.Params, err = decodeParams(params)
 
+	return u, err
+}
+
+// nullTime stores zero time as NULL. Other times are stored in UTC:
+// SQLite compares them as text, so they must all be in the same zone.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t.UTC(), Valid: !t.IsZero()}
+}
+
+// encodeParams stores query parameters as a JSON object, empty string if none.
+func encodeParams(params map[string]string) (string, error) {
+	if len(params) == 0 {
+		return "", nil
+	}
+
+	b, err := json.Marshal(params)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", fn, err)
+		return "", err
+	}
+
+	return string(b), nil
+}
+
+func decodeParams(s string) (map[string]string, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var params map[string]string
+	if err := json.Unmarshal([]byte(s), &params); err != nil {
+		return nil, fmt.Errorf("decode params: %w", err)
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec()
+	return params, nil
+}
+
+func New(storagePath string, opts Options) (*Storage, error) {
+	const fn = "storage.sqlite.New"
+
+	db, err := sql.Open("sqlite3", storagePath)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return &Storage{db: db}, nil
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if opts.LegacyOwner != "" {
+		if _, err := db.Exec("UPDATE url SET owner = ? WHERE owner = ''", opts.LegacyOwner); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	return &Storage{db: db, opts: opts}, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
+// SaveURL creates the link and appends e to the audit log.
+func (s *Storage) SaveURL(u storage.URL, e storage.AuditEntry) (int64, error) {
 	const fn = "storage.sqlite.saveURL"
 
-	stmt, err := s.db.Prepare("INSERT INTO url(url, alias) VALUES(?, ?)")
+	params, err := encodeParams(u.Params)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// a tombstone past its quarantine no longer holds the alias
+	_, err = tx.Exec(`DELETE FROM url
+		WHERE domain = ? AND alias = ? AND deleted_at IS NOT NULL AND deleted_at <= ?`,
+		u.Domain, u.Alias, time.Now().UTC().Add(-s.opts.AliasQuarantine))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO url(domain, url, alias, normalized, owner, redirect_type, passthrough, params, campaign,
+		password_hash, max_clicks, burn, active_from, active_until, pending_url, fallback_url, created_at,
+		og_title, og_description, og_image)
+		VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", fn, err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(urlToSave, alias)
+	res, err := stmt.Exec(u.Domain, u.URL, u.Alias, u.Normalized, u.Owner, u.RedirectType, u.Passthrough, params, u.Campaign,
+		u.PasswordHash, u.MaxClicks, u.Burn, nullTime(u.ActiveFrom), nullTime(u.ActiveUntil), u.PendingURL, u.FallbackURL,
+		time.Now().UTC(), u.Title, u.Description, u.Image)
 	if err != nil {
 		// Check if error is a UNIQUE constraint violation
 		// If true - return custom storage.ErrURLExists error
@@ -67,56 +177,723 @@ func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
 		return 0, fmt.Errorf("%s: failed to get last insert id %w", fn, err)
 	}
 
+	for i, v := range u.Variants {
+		_, err := tx.Exec(`INSERT INTO url_variant(url_id, position, name, url, weight) VALUES(?, ?, ?, ?, ?)`,
+			id, i, v.Name, v.URL, v.Weight)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	err = enqueue(tx, storage.EventLinkCreated, storage.EventLink{
+		Domain: u.Domain,
+		Alias:  u.Alias,
+		URL:    u.URL,
+		Owner:  u.Owner,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
 	return id, nil
 
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
-	const fn = "storage.sqlite.GetURL"
+// GetAliasByNormalized returns the alias of the oldest plain link of owner
+// on domain pointing to the normalized destination. Links with any setting
+// (password, limits, params, schedule, rules, variants, ...) and deleted
+// links are skipped, they can't stand in for a plain link.
+func (s *Storage) GetAliasByNormalized(domain string, normalized string, owner string) (string, error) {
+	const fn = "storage.sqlite.GetAliasByNormalized"
 
-	stmt, err := s.db.Prepare("SELECT url FROM url WHERE alias = ?")
+	stmt, err := s.db.Prepare(`SELECT alias FROM url
+		WHERE domain = ? AND normalized = ? AND owner = ? AND password_hash = '' AND max_clicks = 0 AND burn = 0
+			AND redirect_type = 0 AND passthrough = 0 AND params = '' AND campaign = ''
+			AND active_from IS NULL AND active_until IS NULL AND pending_url = '' AND fallback_url = ''
+			AND og_title = '' AND og_description = '' AND og_image = ''
+			AND NOT EXISTS (SELECT 1 FROM url_rule WHERE url_rule.url_id = url.id)
+			AND NOT EXISTS (SELECT 1 FROM url_variant WHERE url_variant.url_id = url.id)
+			AND deleted_at IS NULL
+		ORDER BY id LIMIT 1`)
 	if err != nil {
 		return "", fmt.Errorf("%s: %w", fn, err)
 	}
 	defer stmt.Close()
 
-	var resURL string
-
-	err = stmt.QueryRow(alias).Scan(&resURL)
+	var alias string
 
+	err = stmt.QueryRow(domain, normalized, owner).Scan(&alias)
 	if err != nil {
-		//проверка присутствует ли значение в базе, если нет, возвращаем кастомную ошибку
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", storage.ErrUrlNotFound
 		}
 		return "", fmt.Errorf("%s: %w", fn, err)
 	}
 
-	return resURL, nil
+	return alias, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
-	const fn = "storage.sqlite.DeleteURL"
+// GetURL returns the link of domain with its targeting rules and variants.
+// Returns storage.ErrURLDeleted for deleted links.
+func (s *Storage) GetURL(domain string, alias string) (storage.URL, error) {
+	const fn = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare("DELETE FROM url WHERE alias = ?")
+	stmt, err := s.db.Prepare("SELECT " + urlColumns + ", deleted_at IS NOT NULL FROM url WHERE domain = ? AND alias = ?")
 	if err != nil {
-		return fmt.Errorf("%s, %w", fn, err)
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
 	}
 	defer stmt.Close()
 
-	res, err := stmt.Exec(alias)
+	var deleted bool
+
+	u, err := scanURL(stmt.QueryRow(domain, alias), &deleted)
+
+	if err != nil {
+		//проверка присутствует ли значение в базе, если нет, возвращаем кастомную ошибку
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, storage.ErrUrlNotFound
+		}
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if deleted {
+		return storage.URL{}, storage.ErrURLDeleted
+	}
+
+	u.Rules, err = s.rules(u.ID)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	u.Variants, err = s.variants(u.ID)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return u, nil
+}
+
+func (s *Storage) rules(urlID int64) ([]storage.Rule, error) {
+	rows, err := s.db.Query(`SELECT os, device, visitor, language, url FROM url_rule
+		WHERE url_id = ? ORDER BY position`, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []storage.Rule
+	for rows.Next() {
+		var r storage.Rule
+		if err := rows.Scan(&r.OS, &r.Device, &r.Visitor, &r.Language, &r.URL); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	}
+
+	return rules, rows.Err()
+}
+
+func (s *Storage) variants(urlID int64) ([]storage.Variant, error) {
+	rows, err := s.db.Query(`SELECT name, url, weight FROM url_variant
+		WHERE url_id = ? ORDER BY position`, urlID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var variants []storage.Variant
+	for rows.Next() {
+		var v storage.Variant
+		if err := rows.Scan(&v.Name, &v.URL, &v.Weight); err != nil {
+			return nil, err
+		}
+		variants = append(variants, v)
+	}
+
+	return variants, rows.Err()
+}
+
+// SaveRules replaces targeting rules of alias, rules are kept in the given order.
+// e is appended to the audit log.
+func (s *Storage) SaveRules(domain string, alias string, rules []storage.Rule, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.SaveRules"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var urlID int64
+
+	err = tx.QueryRow("SELECT id FROM url WHERE domain = ? AND alias = ? AND deleted_at IS NULL",
+		domain, alias).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, storage.ErrUrlNotFound)
+		}
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM url_rule WHERE url_id = ?", urlID); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for i, r := range rules {
+		_, err := tx.Exec(`INSERT INTO url_rule(url_id, position, os, device, visitor, language, url)
+			VALUES(?, ?, ?, ?, ?, ?, ?)`, urlID, i, r.OS, r.Device, r.Visitor, r.Language, r.URL)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// DeleteURL marks the link deleted. The row stays as a tombstone, so the
+// alias can't be reused during the quarantine and the link can be restored.
+// e is appended to the audit log.
+func (s *Storage) DeleteURL(domain string, alias string, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.DeleteURL"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
 	if err != nil {
 		return fmt.Errorf("%s, %w", fn, err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
+	link := storage.EventLink{Domain: domain, Alias: alias}
+
+	err = tx.QueryRow("UPDATE url SET deleted_at = ? WHERE domain = ? AND alias = ? AND deleted_at IS NULL RETURNING url, owner, clicks",
+		time.Now().UTC(), domain, alias).Scan(&link.URL, &link.Owner, &link.Clicks)
 	//если удаление не произошло, то мы возвращаем кастомную ошибку
-	rowsAffected, err := res.RowsAffected()
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, storage.ErrUrlNotFound)
+	}
 	if err != nil {
 		return fmt.Errorf("%s, %w", fn, err)
 	}
-	if rowsAffected == 0 {
+
+	if err := enqueue(tx, storage.EventLinkDeleted, link); err != nil {
+		return fmt.Errorf("%s, %w", fn, err)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s, %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s, %w", fn, err)
+	}
+
+	return nil
+}
+
+// SaveCampaign creates the campaign or replaces its default params.
+// e is appended to the audit log.
+func (s *Storage) SaveCampaign(name string, params map[string]string, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.SaveCampaign"
+
+	encoded, err := encodeParams(params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`INSERT INTO campaign(name, params) VALUES(?, ?)
+		ON CONFLICT(name) DO UPDATE SET params = excluded.params`, name, encoded)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// GetCampaignParams returns default params of the campaign.
+func (s *Storage) GetCampaignParams(name string) (map[string]string, error) {
+	const fn = "storage.sqlite.GetCampaignParams"
+
+	stmt, err := s.db.Prepare("SELECT params FROM campaign WHERE name = ?")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer stmt.Close()
+
+	var encoded string
+
+	err = stmt.QueryRow(name).Scan(&encoded)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, storage.ErrCampaignNotFound
+		}
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	params, err := decodeParams(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return params, nil
+}
+
+// CountClick counts a redirect of alias to variant (empty if the link
+// has no variants) and records it in click analytics.
+// Returns storage.ErrClicksExhausted once max_clicks is reached,
+// storage.ErrUrlNotFound or storage.ErrURLDeleted if there is no link to
+// count. The check and the increment are a single statement, so
+// concurrent redirects can't overshoot the limit.
+func (s *Storage) CountClick(domain string, alias string, variant string) error {
+	const fn = "storage.sqlite.CountClick"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		urlID, maxClicks int64
+		expiredNotified  bool
+		link             = storage.EventLink{Domain: domain, Alias: alias}
+	)
+
+	err = tx.QueryRow(`UPDATE url SET clicks = clicks + 1
+		WHERE domain = ? AND alias = ? AND deleted_at IS NULL AND (max_clicks = 0 OR clicks < max_clicks)
+		RETURNING id, url, owner, clicks, max_clicks, expired_notified`, domain, alias).
+		Scan(&urlID, &link.URL, &link.Owner, &link.Clicks, &maxClicks, &expiredNotified)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, refusedClick(tx, domain, alias))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO click(url_id, variant) VALUES(?, ?)", urlID, variant); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for _, event := range s.clickEvents(link.Clicks, maxClicks, expiredNotified) {
+		if event == storage.EventLinkExpired {
+			if _, err := tx.Exec("UPDATE url SET expired_notified = 1 WHERE id = ?", urlID); err != nil {
+				return fmt.Errorf("%s: %w", fn, err)
+			}
+		}
+		if err := enqueue(tx, event, link); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// refusedClick tells why a click wasn't counted: the link is missing,
+// deleted, or has no clicks left. The link may also be gone since the
+// redirect has looked it up.
+func refusedClick(tx *sql.Tx, domain string, alias string) error {
+	var deleted bool
+	err := tx.QueryRow("SELECT deleted_at IS NOT NULL FROM url WHERE domain = ? AND alias = ?",
+		domain, alias).Scan(&deleted)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return storage.ErrUrlNotFound
+	case err != nil:
+		return err
+	case deleted:
+		return storage.ErrURLDeleted
+	default:
+		return storage.ErrClicksExhausted
+	}
+}
+
+// BurnURL counts the only click of a burn link and deletes the link in
+// one transaction, so only one of concurrent visitors gets through.
+// Returns the errors of CountClick. e is appended to the audit log.
+func (s *Storage) BurnURL(domain string, alias string, variant string, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.BurnURL"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		urlID int64
+		link  = storage.EventLink{Domain: domain, Alias: alias}
+	)
+
+	err = tx.QueryRow(`UPDATE url SET clicks = clicks + 1, deleted_at = ?
+		WHERE domain = ? AND alias = ? AND deleted_at IS NULL AND (max_clicks = 0 OR clicks < max_clicks)
+		RETURNING id, url, owner, clicks`, time.Now().UTC(), domain, alias).
+		Scan(&urlID, &link.URL, &link.Owner, &link.Clicks)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, refusedClick(tx, domain, alias))
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if _, err := tx.Exec("INSERT INTO click(url_id, variant) VALUES(?, ?)", urlID, variant); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := enqueue(tx, storage.EventLinkDeleted, link); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// SaveDomain registers the domain or updates its settings.
+// Returns storage.ErrDomainExists if it belongs to another owner.
+// e is appended to the audit log.
+func (s *Storage) SaveDomain(d storage.Domain, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.SaveDomain"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// the owner of an existing domain is never changed
+	res, err := tx.Exec(`INSERT INTO domain(name, owner, not_found_url, root_url) VALUES(?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET not_found_url = excluded.not_found_url, root_url = excluded.root_url
+		WHERE owner = excluded.owner`, d.Name, d.Owner, d.NotFoundURL, d.RootURL)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrDomainExists)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// GetDomain returns the registered domain.
+func (s *Storage) GetDomain(name string) (storage.Domain, error) {
+	const fn = "storage.sqlite.GetDomain"
+
+	d := storage.Domain{Name: name}
+
+	err := s.db.QueryRow("SELECT owner, not_found_url, root_url FROM domain WHERE name = ?", name).
+		Scan(&d.Owner, &d.NotFoundURL, &d.RootURL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Domain{}, storage.ErrDomainNotFound
+		}
+		return storage.Domain{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return d, nil
+}
+
+// RestoreURL brings back a deleted link and appends e to the audit log.
+// Without e.After the link is recorded as it was deleted: the Before of
+// its last delete entry.
+func (s *Storage) RestoreURL(domain string, alias string, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.RestoreURL"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec("UPDATE url SET deleted_at = NULL WHERE domain = ? AND alias = ? AND deleted_at IS NOT NULL",
+		domain, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
 		return fmt.Errorf("%s: %w", fn, storage.ErrUrlNotFound)
 	}
 
+	if e.After == nil {
+		var before string
+		err := tx.QueryRow(`SELECT before FROM audit WHERE action = ? AND entity = ? AND domain = ? AND alias = ?
+			ORDER BY id DESC LIMIT 1`, storage.AuditDelete, storage.AuditEntityURL, domain, alias).Scan(&before)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+		if before != "" {
+			e.After = []byte(before)
+		}
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// PurgeURL removes the link, deleted or not, with its rules, variants and
+// clicks. The alias is free right away. e is appended to the audit log.
+func (s *Storage) PurgeURL(domain string, alias string, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.PurgeURL"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var (
+		link = storage.EventLink{Domain: domain, Alias: alias}
+		live bool
+	)
+
+	err = tx.QueryRow("DELETE FROM url WHERE domain = ? AND alias = ? RETURNING url, owner, clicks, deleted_at IS NULL",
+		domain, alias).Scan(&link.URL, &link.Owner, &link.Clicks, &live)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", fn, storage.ErrUrlNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	// link.deleted has already been sent for soft deleted links
+	if live {
+		if err := enqueue(tx, storage.EventLinkDeleted, link); err != nil {
+			return fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListURLs returns live links of owner on domain with ID greater than
+// afterID, ordered by ID. Rules and variants are not loaded.
+func (s *Storage) ListURLs(owner string, domain string, afterID int64, limit int) ([]storage.URL, error) {
+	const fn = "storage.sqlite.ListURLs"
+
+	rows, err := s.db.Query("SELECT "+urlColumns+` FROM url
+		WHERE owner = ? AND domain = ? AND id > ? AND deleted_at IS NULL
+		ORDER BY id LIMIT ?`, owner, domain, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	var urls []storage.URL
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		urls = append(urls, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return urls, nil
+}
+
+// GetStats returns click statistics of the link.
+func (s *Storage) GetStats(domain string, alias string) (storage.Stats, error) {
+	const fn = "storage.sqlite.GetStats"
+
+	var (
+		urlID   int64
+		deleted bool
+		stats   = storage.Stats{Variants: map[string]int64{}}
+	)
+
+	err := s.db.QueryRow("SELECT id, clicks, deleted_at IS NOT NULL FROM url WHERE domain = ? AND alias = ?", domain, alias).
+		Scan(&urlID, &stats.Clicks, &deleted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.Stats{}, storage.ErrUrlNotFound
+		}
+		return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	if deleted {
+		return storage.Stats{}, storage.ErrURLDeleted
+	}
+
+	rows, err := s.db.Query("SELECT variant, COUNT(*), MIN(created_at), MAX(created_at) FROM click WHERE url_id = ? GROUP BY variant", urlID)
+	if err != nil {
+		return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			variant     string
+			n           int64
+			first, last string
+		)
+		if err := rows.Scan(&variant, &n, &first, &last); err != nil {
+			return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		if variant != "" {
+			stats.Variants[variant] = n
+		}
+
+		// aggregates lose the column type, CURRENT_TIMESTAMP is UTC text
+		firstAt, err := time.Parse(time.DateTime, first)
+		if err != nil {
+			return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		lastAt, err := time.Parse(time.DateTime, last)
+		if err != nil {
+			return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+		}
+		if stats.FirstClickAt.IsZero() || firstAt.Before(stats.FirstClickAt) {
+			stats.FirstClickAt = firstAt
+		}
+		if lastAt.After(stats.LastClickAt) {
+			stats.LastClickAt = lastAt
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return storage.Stats{}, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return stats, nil
+}
+
+// UpdateURL saves the editable fields of a live link: destination,
+// redirect options, password, limits, schedule and the unfurl card.
+// Rules and variants are kept, see SaveRules. e is appended to the audit log.
+func (s *Storage) UpdateURL(u storage.URL, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.UpdateURL"
+
+	params, err := encodeParams(u.Params)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(`UPDATE url SET url = ?, normalized = ?, redirect_type = ?, passthrough = ?, params = ?,
+		campaign = ?, password_hash = ?, max_clicks = ?, burn = ?, active_from = ?, active_until = ?,
+		pending_url = ?, fallback_url = ?, og_title = ?, og_description = ?, og_image = ?
+		WHERE domain = ? AND alias = ? AND deleted_at IS NULL`,
+		u.URL, u.Normalized, u.RedirectType, u.Passthrough, params,
+		u.Campaign, u.PasswordHash, u.MaxClicks, u.Burn, nullTime(u.ActiveFrom), nullTime(u.ActiveUntil),
+		u.PendingURL, u.FallbackURL, u.Title, u.Description, u.Image,
+		u.Domain, u.Alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrUrlNotFound)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
 	return nil
 }
@@ -0,0 +1,488 @@
+package sqlite_test
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/storage"
+	"urlshortener/internal/storage/sqlite"
+)
+
+// newStorage opens a new database in a temp dir.
+func newStorage(t *testing.T, opts sqlite.Options) *sqlite.Storage {
+	t.Helper()
+
+	st, err := sqlite.New(filepath.Join(t.TempDir(), "storage.db"), opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	return st
+}
+
+func TestGetAliasByNormalized(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		set   func(u *storage.URL)
+		rules []storage.Rule
+	}{
+		{name: "Password", set: func(u *storage.URL) { u.PasswordHash = "hash" }},
+		{name: "Max clicks", set: func(u *storage.URL) { u.MaxClicks = 5 }},
+		{name: "Burn", set: func(u *storage.URL) { u.Burn = true }},
+		{name: "Redirect type", set: func(u *storage.URL) { u.RedirectType = 301 }},
+		{name: "Passthrough", set: func(u *storage.URL) { u.Passthrough = true }},
+		{name: "Params", set: func(u *storage.URL) { u.Params = map[string]string{"utm_source": "x"} }},
+		{name: "Campaign", set: func(u *storage.URL) { u.Campaign = "spring" }},
+		{name: "Active from", set: func(u *storage.URL) { u.ActiveFrom = at }},
+		{name: "Active until", set: func(u *storage.URL) { u.ActiveUntil = at }},
+		{name: "Pending url", set: func(u *storage.URL) { u.PendingURL = "https://example.com/soon" }},
+		{name: "Fallback url", set: func(u *storage.URL) { u.FallbackURL = "https://example.com/over" }},
+		{name: "Title", set: func(u *storage.URL) { u.Title = "Title" }},
+		{name: "Rules", rules: []storage.Rule{{OS: "ios", URL: "https://apps.apple.com"}}},
+		{name: "Variants", set: func(u *storage.URL) {
+			u.Variants = []storage.Variant{{Name: "A", URL: "https://a.example.com", Weight: 1}}
+		}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			st := newStorage(t, sqlite.Options{})
+
+			link := storage.URL{Alias: "set", URL: "https://example.com", Normalized: "https://example.com/", Owner: "alice"}
+			if tc.set != nil {
+				tc.set(&link)
+			}
+			_, err := st.SaveURL(link, storage.AuditEntry{})
+			require.NoError(t, err)
+			if tc.rules != nil {
+				require.NoError(t, st.SaveRules("", "set", tc.rules, storage.AuditEntry{}))
+			}
+
+			// the link with a setting doesn't stand in for a plain one
+			_, err = st.GetAliasByNormalized("", "https://example.com/", "alice")
+			require.ErrorIs(t, err, storage.ErrUrlNotFound)
+
+			_, err = st.SaveURL(storage.URL{Alias: "plain", URL: "https://example.com", Normalized: "https://example.com/", Owner: "alice"}, storage.AuditEntry{})
+			require.NoError(t, err)
+
+			alias, err := st.GetAliasByNormalized("", "https://example.com/", "alice")
+			require.NoError(t, err)
+			assert.Equal(t, "plain", alias)
+		})
+	}
+}
+
+func TestAuditInTransaction(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	link := storage.URL{Alias: "ex", URL: "https://example.com", Owner: "alice"}
+	created := storage.AuditEntry{Actor: "alice", Action: storage.AuditCreate, Entity: storage.AuditEntityURL, Alias: "ex"}
+	_, err := st.SaveURL(link, created)
+	require.NoError(t, err)
+
+	// a failed mutation leaves no entry
+	_, err = st.SaveURL(link, created)
+	require.ErrorIs(t, err, storage.ErrURLExists)
+	require.ErrorIs(t, st.RestoreURL("", "ex", storage.AuditEntry{Action: storage.AuditRestore}), storage.ErrUrlNotFound)
+
+	require.NoError(t, st.DeleteURL("", "ex", storage.AuditEntry{
+		Actor: "alice", Action: storage.AuditDelete, Entity: storage.AuditEntityURL, Alias: "ex",
+		Before: []byte(`{"url":"https://example.com"}`),
+	}))
+	// the restored link is recorded as it was deleted
+	require.NoError(t, st.RestoreURL("", "ex", storage.AuditEntry{
+		Actor: "admin", Action: storage.AuditRestore, Entity: storage.AuditEntityURL, Alias: "ex",
+	}))
+
+	entries, err := st.ListAudit(storage.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, storage.AuditCreate, entries[0].Action)
+	assert.Equal(t, storage.AuditDelete, entries[1].Action)
+	assert.Equal(t, storage.AuditRestore, entries[2].Action)
+	assert.JSONEq(t, `{"url":"https://example.com"}`, string(entries[2].After))
+
+	verification, err := st.VerifyAudit()
+	require.NoError(t, err)
+	assert.True(t, verification.Valid)
+}
+
+func TestPurgeExpiredAudit(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	for _, alias := range []string{"gone", "kept"} {
+		_, err := st.SaveURL(storage.URL{Alias: alias, URL: "https://example.com"}, storage.AuditEntry{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, st.DeleteURL("", "gone", storage.AuditEntry{}))
+
+	purged, err := st.PurgeExpired(time.Now().Add(time.Hour), time.Hour, storage.AuditEntry{Actor: "cli"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged.Links)
+
+	entries, err := st.ListAudit(storage.AuditFilter{Action: storage.AuditPurge})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "cli", entries[0].Actor)
+	assert.Equal(t, storage.AuditEntityURL, entries[0].Entity)
+	assert.Equal(t, "gone", entries[0].Alias)
+}
+
+func TestAuditTimesOrderAsText(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "storage.db")
+	st, err := sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	for range 2 {
+		require.NoError(t, st.AppendAudit(storage.AuditEntry{Actor: "alice", Action: storage.AuditCreate}))
+	}
+	require.NoError(t, st.Close())
+
+	// entries written before the fixed-width layout: RFC3339Nano trims
+	// trailing zeros, so ".1Z" sorts after ".12Z" as text
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		"DROP TRIGGER trg_audit_no_update",
+		"UPDATE audit SET created_at = '2025-01-02T03:04:05.1Z' WHERE id = 1",
+		"UPDATE audit SET created_at = '2025-01-02T03:04:05.12Z' WHERE id = 2",
+		"PRAGMA user_version = 1",
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	st, err = sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	entries, err := st.ListAudit(storage.AuditFilter{From: time.Date(2025, 1, 2, 3, 4, 5, 110_000_000, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 2, entries[0].ID)
+
+	entries, err = st.ListAudit(storage.AuditFilter{To: time.Date(2025, 1, 2, 3, 4, 5, 110_000_000, time.UTC)})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.EqualValues(t, 1, entries[0].ID)
+}
+
+func TestActiveTimesInUTC(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	// 05:00 at UTC+3 is 02:00 UTC, before now, but sorts after it as text
+	// in its own zone
+	zone := time.FixedZone("UTC+3", 3*60*60)
+	until := time.Date(2025, 1, 2, 5, 0, 0, 0, zone)
+	now := time.Date(2025, 1, 2, 3, 0, 0, 0, time.UTC)
+
+	_, err := st.SaveURL(storage.URL{Alias: "saved", URL: "https://example.com", ActiveUntil: until}, storage.AuditEntry{})
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "updated", URL: "https://example.com"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.UpdateURL(storage.URL{Alias: "updated", URL: "https://example.com", ActiveUntil: until},
+		storage.AuditEntry{}))
+
+	n, err := st.EnqueueExpired(now)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+
+	link, err := st.GetURL("", "saved")
+	require.NoError(t, err)
+	assert.True(t, link.ActiveUntil.Equal(until))
+}
+
+func TestCountClickErrors(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	_, err := st.SaveURL(storage.URL{Alias: "limited", URL: "https://example.com", MaxClicks: 1}, storage.AuditEntry{})
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "deleted", URL: "https://example.com"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.DeleteURL("", "deleted", storage.AuditEntry{}))
+
+	require.NoError(t, st.CountClick("", "limited", ""))
+	require.ErrorIs(t, st.CountClick("", "limited", ""), storage.ErrClicksExhausted)
+	require.ErrorIs(t, st.CountClick("", "deleted", ""), storage.ErrURLDeleted)
+	require.ErrorIs(t, st.CountClick("", "missing", ""), storage.ErrUrlNotFound)
+
+	stats, err := st.GetStats("", "limited")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Clicks)
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	t.Parallel()
+
+	// the url table of the first version, alias unique across domains
+	path := filepath.Join(t.TempDir(), "storage.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE url(
+			id INTEGER PRIMARY KEY,
+			alias TEXT NOT NULL UNIQUE,
+			url TEXT NOT NULL)`,
+		`INSERT INTO url(id, alias, url) VALUES(7, 'ex', 'https://example.com')`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	// migrating twice must be a no-op the second time
+	for range 2 {
+		st, err := sqlite.New(path, sqlite.Options{})
+		require.NoError(t, err)
+		require.NoError(t, st.Close())
+	}
+
+	st, err := sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	link, err := st.GetURL("", "ex")
+	require.NoError(t, err)
+	assert.EqualValues(t, 7, link.ID)
+	assert.Equal(t, "", link.Owner)
+	assert.Equal(t, "https://example.com", link.URL)
+	assert.Equal(t, "", link.Domain)
+
+	// after the rebuild the alias is unique per domain only
+	_, err = st.SaveURL(storage.URL{Domain: "go.example.com", Alias: "ex", URL: "https://example.org"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "ex", URL: "https://example.org"}, storage.AuditEntry{})
+	require.ErrorIs(t, err, storage.ErrURLExists)
+
+	// rules of the rebuilt table are still cleaned up with their link
+	require.NoError(t, st.SaveRules("", "ex", []storage.Rule{{OS: "ios", URL: "https://apps.apple.com"}}, storage.AuditEntry{}))
+	require.NoError(t, st.PurgeURL("", "ex", storage.AuditEntry{}))
+	_, err = st.SaveURL(storage.URL{Alias: "next", URL: "https://example.net"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	next, err := st.GetURL("", "next")
+	require.NoError(t, err)
+	assert.Empty(t, next.Rules)
+}
+
+func TestCountClickAtLimit(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	_, err := st.SaveURL(storage.URL{Alias: "limited", URL: "https://example.com", MaxClicks: 2}, storage.AuditEntry{})
+	require.NoError(t, err)
+
+	require.NoError(t, st.CountClick("", "limited", ""))
+	require.NoError(t, st.CountClick("", "limited", ""))
+	for range 2 {
+		require.ErrorIs(t, st.CountClick("", "limited", ""), storage.ErrClicksExhausted)
+	}
+
+	// refused clicks are neither counted nor recorded
+	link, err := st.GetURL("", "limited")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, link.Clicks)
+
+	stats, err := st.GetStats("", "limited")
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stats.Clicks)
+}
+
+func TestAliasQuarantine(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{AliasQuarantine: time.Hour})
+
+	link := storage.URL{Alias: "ex", URL: "https://example.com"}
+	_, err := st.SaveURL(link, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.DeleteURL("", "ex", storage.AuditEntry{}))
+
+	// the tombstone holds the alias during the quarantine
+	_, err = st.SaveURL(link, storage.AuditEntry{})
+	require.ErrorIs(t, err, storage.ErrURLExists)
+
+	purged, err := st.PurgeExpired(time.Now(), time.Hour, storage.AuditEntry{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, purged.Links)
+
+	_, err = st.SaveURL(link, storage.AuditEntry{})
+	require.ErrorIs(t, err, storage.ErrURLExists)
+
+	purged, err = st.PurgeExpired(time.Now().Add(2*time.Hour), time.Hour, storage.AuditEntry{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged.Links)
+
+	_, err = st.SaveURL(link, storage.AuditEntry{})
+	require.NoError(t, err)
+	_, err = st.GetURL("", "ex")
+	require.NoError(t, err)
+}
+
+func TestPurgeExpired(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	for _, alias := range []string{"deleted", "live"} {
+		_, err := st.SaveURL(storage.URL{Alias: alias, URL: "https://example.com"}, storage.AuditEntry{})
+		require.NoError(t, err)
+	}
+	require.NoError(t, st.DeleteURL("", "deleted", storage.AuditEntry{}))
+
+	_, err := st.ReserveIdempotencyKey(storage.IdempotencyKey{Owner: "alice", Key: "k1", RequestHash: "h"}, time.Time{})
+	require.NoError(t, err)
+
+	// keys younger than the window are kept
+	purged, err := st.PurgeExpired(time.Now(), time.Hour, storage.AuditEntry{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, purged.Links)
+	assert.EqualValues(t, 0, purged.IdempotencyKeys)
+
+	purged, err = st.PurgeExpired(time.Now().Add(2*time.Hour), time.Hour, storage.AuditEntry{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, purged.Links)
+	assert.EqualValues(t, 1, purged.IdempotencyKeys)
+
+	totals, err := st.Totals()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, totals.Links)
+	assert.EqualValues(t, 0, totals.DeletedLinks)
+	assert.EqualValues(t, 0, totals.IdempotencyKeys)
+
+	_, err = st.GetURL("", "live")
+	require.NoError(t, err)
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "storage.db")
+	backup := filepath.Join(dir, "backup.db")
+
+	st, err := sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	_, err = st.SaveURL(storage.URL{Alias: "before", URL: "https://example.com"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.Backup(backup))
+	_, err = st.SaveURL(storage.URL{Alias: "after", URL: "https://example.com"}, storage.AuditEntry{})
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	// a broken backup leaves the database as it was
+	broken := filepath.Join(dir, "broken.db")
+	require.NoError(t, os.WriteFile(broken, []byte("not a database"), 0o600))
+	require.Error(t, sqlite.Restore(broken, path))
+	require.Error(t, sqlite.Restore(filepath.Join(dir, "missing.db"), path))
+
+	st, err = sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	_, err = st.GetURL("", "after")
+	require.NoError(t, err)
+	require.NoError(t, st.Close())
+
+	require.NoError(t, sqlite.Restore(backup, path))
+
+	st, err = sqlite.New(path, sqlite.Options{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	_, err = st.GetURL("", "before")
+	require.NoError(t, err)
+	_, err = st.GetURL("", "after")
+	require.ErrorIs(t, err, storage.ErrUrlNotFound)
+
+	// no temp files are left next to the database
+	matches, err := filepath.Glob(path + ".restore-*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestLegacyOwner(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "storage.db")
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	for _, stmt := range []string{
+		`CREATE TABLE url(
+			id INTEGER PRIMARY KEY,
+			alias TEXT NOT NULL UNIQUE,
+			url TEXT NOT NULL)`,
+		`INSERT INTO url(alias, url) VALUES('ex', 'https://example.com')`,
+	} {
+		_, err := db.Exec(stmt)
+		require.NoError(t, err)
+	}
+	require.NoError(t, db.Close())
+
+	st, err := sqlite.New(path, sqlite.Options{LegacyOwner: "alice"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = st.Close() })
+
+	_, err = st.SaveURL(storage.URL{Alias: "bob", URL: "https://example.com", Owner: "bob"}, storage.AuditEntry{})
+	require.NoError(t, err)
+
+	// the old link shows up in the list of the configured user
+	links, err := st.ListURLs("alice", "", 0, 10)
+	require.NoError(t, err)
+	require.Len(t, links, 1)
+	assert.Equal(t, "ex", links[0].Alias)
+
+	link, err := st.GetURL("", "bob")
+	require.NoError(t, err)
+	assert.Equal(t, "bob", link.Owner)
+}
+
+func TestBurnURL(t *testing.T) {
+	t.Parallel()
+
+	st := newStorage(t, sqlite.Options{})
+
+	_, err := st.SaveURL(storage.URL{Alias: "once", URL: "https://example.com", Burn: true}, storage.AuditEntry{})
+	require.NoError(t, err)
+
+	require.NoError(t, st.BurnURL("", "once", "B", storage.AuditEntry{
+		Action: storage.AuditDelete, Entity: storage.AuditEntityURL, Alias: "once",
+	}))
+	require.ErrorIs(t, st.BurnURL("", "once", "", storage.AuditEntry{}), storage.ErrURLDeleted)
+	require.ErrorIs(t, st.BurnURL("", "missing", "", storage.AuditEntry{}), storage.ErrUrlNotFound)
+
+	_, err = st.GetURL("", "once")
+	require.ErrorIs(t, err, storage.ErrURLDeleted)
+
+	// the click that burnt the link is in the stats of the restored link
+	require.NoError(t, st.RestoreURL("", "once", storage.AuditEntry{}))
+	stats, err := st.GetStats("", "once")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, stats.Clicks)
+	assert.EqualValues(t, map[string]int64{"B": 1}, stats.Variants)
+
+	entries, err := st.ListAudit(storage.AuditFilter{Action: storage.AuditDelete})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "once", entries[0].Alias)
+}
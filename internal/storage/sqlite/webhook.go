@@ -0,0 +1,328 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+const deliveryColumns = "o.id, o.webhook_id, o.event, o.payload, o.status, o.attempts, o.next_attempt_at, " +
+	"o.last_status_code, o.last_error, o.created_at, o.delivered_at"
+
+// defaultDeliveryLimit and maxDeliveryLimit bound ListDeliveries pages.
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+// SaveWebhook subscribes the owner to events of their links and appends
+// e to the audit log, with the ID of the new webhook as e.Alias.
+func (s *Storage) SaveWebhook(w storage.Webhook, e storage.AuditEntry) (int64, error) {
+	const fn = "storage.sqlite.SaveWebhook"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec("INSERT INTO webhook(owner, url, secret, events, created_at) VALUES(?, ?, ?, ?, ?)",
+		w.Owner, w.URL, w.Secret, strings.Join(w.Events, ","), time.Now().UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	e.Alias = strconv.FormatInt(id, 10)
+	if err := appendAudit(tx, e); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return id, nil
+}
+
+// ListWebhooks returns webhooks of the owner, secrets included.
+func (s *Storage) ListWebhooks(owner string) ([]storage.Webhook, error) {
+	const fn = "storage.sqlite.ListWebhooks"
+
+	rows, err := s.db.Query("SELECT id, owner, url, secret, events, created_at FROM webhook WHERE owner = ? ORDER BY id", owner)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	webhooks := []storage.Webhook{}
+	for rows.Next() {
+		var (
+			w      storage.Webhook
+			events string
+		)
+		if err := rows.Scan(&w.ID, &w.Owner, &w.URL, &w.Secret, &events, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		w.Events = strings.Split(events, ",")
+		webhooks = append(webhooks, w)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return webhooks, nil
+}
+
+// DeleteWebhook removes the webhook of the owner with its deliveries.
+// e is appended to the audit log.
+func (s *Storage) DeleteWebhook(owner string, id int64, e storage.AuditEntry) error {
+	const fn = "storage.sqlite.DeleteWebhook"
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec("DELETE FROM webhook WHERE id = ? AND owner = ?", id, owner)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+
+	if err := appendAudit(tx, e); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a webhook of the owner, newest first.
+func (s *Storage) ListDeliveries(owner string, webhookID int64, f storage.DeliveryFilter) ([]storage.Delivery, error) {
+	const fn = "storage.sqlite.ListDeliveries"
+
+	var n int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM webhook WHERE id = ? AND owner = ?", webhookID, owner).Scan(&n)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("%s: %w", fn, storage.ErrWebhookNotFound)
+	}
+
+	query := "SELECT " + deliveryColumns + " FROM webhook_outbox o WHERE o.webhook_id = ?"
+	args := []any{webhookID}
+	if f.Status != "" {
+		query += " AND o.status = ?"
+		args = append(args, f.Status)
+	}
+	if f.BeforeID > 0 {
+		query += " AND o.id < ?"
+		args = append(args, f.BeforeID)
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = defaultDeliveryLimit
+	}
+	query += " ORDER BY o.id DESC LIMIT ?"
+	args = append(args, min(limit, maxDeliveryLimit))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	deliveries := []storage.Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return deliveries, nil
+}
+
+// PendingDeliveries returns up to limit deliveries due at now, oldest first,
+// with the URL and secret of their webhook.
+func (s *Storage) PendingDeliveries(now time.Time, limit int) ([]storage.Delivery, error) {
+	const fn = "storage.sqlite.PendingDeliveries"
+
+	rows, err := s.db.Query("SELECT "+deliveryColumns+", w.url, w.secret FROM webhook_outbox o "+
+		"JOIN webhook w ON w.id = o.webhook_id "+
+		"WHERE o.status = ? AND o.next_attempt_at <= ? ORDER BY o.next_attempt_at, o.id LIMIT ?",
+		storage.DeliveryPending, now.UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer rows.Close()
+
+	var deliveries []storage.Delivery
+	for rows.Next() {
+		var url, secret string
+		d, err := scanDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", fn, err)
+		}
+		d.URL, d.Secret = url, secret
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt.
+func (s *Storage) UpdateDelivery(d storage.Delivery) error {
+	const fn = "storage.sqlite.UpdateDelivery"
+
+	_, err := s.db.Exec(`UPDATE webhook_outbox SET status = ?, attempts = ?, next_attempt_at = ?,
+		last_status_code = ?, last_error = ?, delivered_at = ? WHERE id = ?`,
+		d.Status, d.Attempts, nullTime(d.NextAttemptAt), d.LastStatusCode, d.LastError,
+		nullTime(d.DeliveredAt), d.ID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// EnqueueExpired queues link.expired for links whose active_until has
+// passed by now, once per link. Returns the number of expired links.
+func (s *Storage) EnqueueExpired(now time.Time) (int64, error) {
+	const fn = "storage.sqlite.EnqueueExpired"
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.Query(`UPDATE url SET expired_notified = 1
+		WHERE active_until IS NOT NULL AND active_until <= ? AND expired_notified = 0 AND deleted_at IS NULL
+		RETURNING domain, alias, url, owner, clicks`, now.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	var links []storage.EventLink
+	for rows.Next() {
+		var l storage.EventLink
+		if err := rows.Scan(&l.Domain, &l.Alias, &l.URL, &l.Owner, &l.Clicks); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("%s: %w", fn, err)
+		}
+		links = append(links, l)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	for _, l := range links {
+		if err := enqueue(tx, storage.EventLinkExpired, l); err != nil {
+			return 0, fmt.Errorf("%s: %w", fn, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("%s: %w", fn, err)
+	}
+
+	return int64(len(links)), nil
+}
+
+// enqueue queues the event for every webhook of the link owner subscribed to it.
+// It runs in the transaction changing the link, so events are never lost
+// or sent for changes that were rolled back.
+func enqueue(tx *sql.Tx, event string, link storage.EventLink) error {
+	now := time.Now().UTC()
+
+	payload, err := json.Marshal(storage.Event{
+		Type:      event,
+		CreatedAt: now,
+		Link:      link,
+	})
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO webhook_outbox(webhook_id, event, payload, status, next_attempt_at, created_at)
+		SELECT id, ?, ?, ?, ?, ? FROM webhook
+		WHERE owner = ? AND instr(',' || events || ',', ',' || ? || ',') > 0`,
+		event, string(payload), storage.DeliveryPending, now, now, link.Owner, event)
+	if err != nil {
+		return fmt.Errorf("enqueue %s: %w", event, err)
+	}
+
+	return nil
+}
+
+// clickEvents returns the events a redirect bringing the link to clicks triggers.
+func (s *Storage) clickEvents(clicks, maxClicks int64, expiredNotified bool) []string {
+	var events []string
+	if slices.Contains(s.opts.ClickThresholds, clicks) {
+		events = append(events, storage.EventLinkClicks)
+	}
+	if maxClicks > 0 && clicks >= maxClicks && !expiredNotified {
+		events = append(events, storage.EventLinkExpired)
+	}
+
+	return events
+}
+
+func scanDelivery(row scanner, extra ...any) (storage.Delivery, error) {
+	var (
+		d                        storage.Delivery
+		payload                  string
+		nextAttempt, deliveredAt sql.NullTime
+	)
+
+	dest := []any{&d.ID, &d.WebhookID, &d.Event, &payload, &d.Status, &d.Attempts, &nextAttempt,
+		&d.LastStatusCode, &d.LastError, &d.CreatedAt, &deliveredAt}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return d, err
+	}
+
+	d.Payload = json.RawMessage(payload)
+	d.NextAttemptAt = nextAttempt.Time
+	d.DeliveredAt = deliveredAt.Time
+
+	return d, nil
+}
@@ -1,6 +1,9 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 // Storage package error definitions.
 var (
@@ -10,4 +13,125 @@ var (
 
 	// ErrURLExists indicates a duplicate URL/alias violation.
 	ErrURLExists = errors.New("url exists")
+
+	// ErrClicksExhausted indicates the link has reached its click limit.
+	// Should typically result in HTTP 410 (Gone) response.
+	ErrClicksExhausted = errors.New("click limit reached")
+
+	// ErrURLDeleted indicates the link was deleted, its alias stays
+	// taken until the tombstone is purged.
+	// Should typically result in HTTP 410 (Gone) response.
+	ErrURLDeleted = errors.New("url deleted")
+
+	// ErrCampaignNotFound indicates the campaign has no stored defaults.
+	ErrCampaignNotFound = errors.New("campaign not found")
+
+	// ErrDomainNotFound indicates the domain is not registered.
+	ErrDomainNotFound = errors.New("domain not found")
+
+	// ErrDomainExists indicates the domain is registered by another user.
+	ErrDomainExists = errors.New("domain exists")
 )
+
+// Domain is a custom short domain registered by a user.
+// Aliases are unique per domain.
+type Domain struct {
+	// Name is the lowercase host name without port.
+	Name string `json:"name"`
+	// Owner is the only user allowed to create links on the domain
+	// and change its settings.
+	Owner string `json:"owner"`
+	// NotFoundURL is where unknown aliases redirect, 404 if empty.
+	NotFoundURL string `json:"not_found_url,omitempty"`
+	// RootURL is where the domain root redirects, 404 if empty.
+	RootURL string `json:"root_url,omitempty"`
+}
+
+// URL is a short link as it is kept in storage.
+type URL struct {
+	ID int64
+	// Domain is empty for links of the default domain.
+	Domain string
+	Alias  string
+	// URL is the destination exactly as submitted by the user.
+	URL string
+	// Normalized is the canonical form of URL (see lib/urlnorm),
+	// used to find duplicates of the same destination.
+	Normalized string
+	// Owner is the user that created the link.
+	Owner string
+	// RedirectType is the HTTP status used to redirect (301, 302, 307, 308).
+	// Zero means the default from config.
+	RedirectType int
+	// Passthrough appends the rest of the request path and query
+	// (/alias/rest?x=1) to the destination.
+	Passthrough bool
+	// Params are query parameters (utm_source, ...) added to the
+	// destination on redirect. They override the defaults of Campaign.
+	Params map[string]string
+	// Campaign groups links sharing default Params.
+	Campaign string
+	// PasswordHash is the bcrypt hash of the link password.
+	// Empty if the link is not protected.
+	PasswordHash string
+	// Clicks is the number of redirects made so far.
+	Clicks int64
+	// MaxClicks limits the number of redirects, zero means unlimited.
+	MaxClicks int64
+	// Burn deletes the link after the first redirect.
+	Burn bool
+	// ActiveFrom and ActiveUntil limit when the link redirects to URL.
+	// Zero value means no limit.
+	ActiveFrom  time.Time
+	ActiveUntil time.Time
+	// PendingURL is used instead of URL before ActiveFrom.
+	PendingURL string
+	// FallbackURL is used instead of URL after ActiveUntil.
+	FallbackURL string
+	// Rules send matching visitors to other destinations,
+	// the first matching rule wins, URL is used if none match.
+	Rules []Rule
+	// Variants split traffic between destinations by weight.
+	// URL is used when there are none.
+	Variants []Variant
+	// CreatedAt is zero for links created before it was recorded.
+	CreatedAt time.Time
+	// Title, Description and Image are shown by chat apps and social
+	// networks unfurling the link (Open Graph, Twitter cards).
+	Title       string
+	Description string
+	Image       string
+}
+
+// Stats are click statistics of a link.
+type Stats struct {
+	Clicks int64
+	// Variants are clicks by A/B variant, empty for links without variants.
+	Variants map[string]int64
+	// FirstClickAt and LastClickAt are zero if there are no clicks.
+	FirstClickAt time.Time
+	LastClickAt  time.Time
+}
+
+// Variant is one of weighted destinations of an A/B split.
+type Variant struct {
+	// Name identifies the variant in the sticky cookie and click analytics.
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Rule targets visitors by their client. Empty conditions match anyone.
+type Rule struct {
+	// OS is one of ios, android, windows, macos, linux, chromeos.
+	OS string `json:"os,omitempty"`
+	// Device is one of mobile, tablet, desktop.
+	Device string `json:"device,omitempty"`
+	// Visitor is bot or human.
+	Visitor string `json:"visitor,omitempty"`
+	// Language matches the preferred Accept-Language: "pt" matches
+	// any region, "pt-BR" only itself.
+	Language string `json:"language,omitempty"`
+	// URL is the destination for matching visitors.
+	URL string `json:"url"`
+}
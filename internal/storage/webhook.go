@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Link lifecycle events sent to webhooks.
+const (
+	EventLinkCreated = "link.created"
+	EventLinkDeleted = "link.deleted"
+	// EventLinkExpired is sent once, when the link reaches its click limit
+	// or its active_until passes.
+	EventLinkExpired = "link.expired"
+	// EventLinkClicks is sent when the click count reaches one of the
+	// configured thresholds.
+	EventLinkClicks = "link.clicks_threshold"
+)
+
+// Events lists the events webhooks may subscribe to.
+var Events = []string{EventLinkCreated, EventLinkDeleted, EventLinkExpired, EventLinkClicks}
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is a dead letter: all attempts have failed.
+	DeliveryDead = "dead"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// Webhook is a subscription of an owner to events of their links.
+type Webhook struct {
+	ID    int64  `json:"id"`
+	Owner string `json:"owner"`
+	URL   string `json:"url"`
+	// Secret signs the deliveries, it is only shown when the webhook is created.
+	Secret    string    `json:"-"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Event is the body of a delivery.
+type Event struct {
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Link      EventLink `json:"link"`
+}
+
+// EventLink is the link an event is about.
+type EventLink struct {
+	Domain string `json:"domain,omitempty"`
+	Alias  string `json:"alias"`
+	URL    string `json:"url"`
+	Owner  string `json:"owner"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Delivery is an event queued in the outbox for a webhook, it is kept
+// after the last attempt as the delivery log.
+type Delivery struct {
+	ID        int64 `json:"id"`
+	WebhookID int64 `json:"webhook_id"`
+	// URL and Secret are the webhook ones, set for pending deliveries only.
+	URL           string          `json:"-"`
+	Secret        string          `json:"-"`
+	Event         string          `json:"event"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"next_attempt_at,omitzero"`
+	// LastStatusCode and LastError describe the last failed attempt.
+	LastStatusCode int       `json:"last_status_code,omitempty"`
+	LastError      string    `json:"last_error,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	DeliveredAt    time.Time `json:"delivered_at,omitzero"`
+}
+
+// DeliveryFilter selects deliveries of a webhook, zero fields match everything.
+type DeliveryFilter struct {
+	Status string
+	// BeforeID pages through deliveries, they are ordered newest first.
+	BeforeID int64
+	Limit    int
+}
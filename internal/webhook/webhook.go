@@ -0,0 +1,266 @@
+// Package webhook delivers link events queued in the storage outbox.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"urlshortener/internal/storage"
+)
+
+// Headers of a delivery. The signature is hex HMAC-SHA256 of
+// "<timestamp>.<body>" with the webhook secret, prefixed with "sha256=".
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Outbox is the storage side of deliveries.
+type Outbox interface {
+	PendingDeliveries(now time.Time, limit int) ([]storage.Delivery, error)
+	UpdateDelivery(d storage.Delivery) error
+	EnqueueExpired(now time.Time) (int64, error)
+}
+
+// Options configures the dispatcher.
+type Options struct {
+	// PollInterval is how often the outbox is checked.
+	PollInterval time.Duration
+	// Timeout limits a single delivery attempt.
+	Timeout time.Duration
+	// MaxAttempts is the number of attempts before the delivery is dead.
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt, it doubles
+	// with every attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BatchSize deliveries are sent concurrently.
+	BatchSize int
+	// AllowPrivate lets webhooks reach loopback, link-local and private
+	// addresses. Otherwise such deliveries fail with ErrForbiddenAddress.
+	AllowPrivate bool
+}
+
+// Dispatcher sends pending deliveries in the background. Deliveries are
+// at least once: an attempt interrupted by a restart is made again.
+type Dispatcher struct {
+	log    *slog.Logger
+	outbox Outbox
+	client *http.Client
+	opts   Options
+	now    func() time.Time
+}
+
+func New(log *slog.Logger, outbox Outbox, opts Options) *Dispatcher {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 5 * time.Second
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 30 * time.Second
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = max(6*time.Hour, opts.Backoff)
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 16
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if !opts.AllowPrivate {
+		dialer.Control = forbidAddress
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// through a proxy the dialer would check the proxy, not the webhook
+	transport.Proxy = nil
+
+	return &Dispatcher{
+		log:    log.With(slog.String("op", "webhook.Dispatcher")),
+		outbox: outbox,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			// a redirect is a failed delivery, the webhook URL has to be fixed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		opts: opts,
+		now:  time.Now,
+	}
+}
+
+// Run polls the outbox until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.Poll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll queues events of links that have expired since the last poll and
+// sends the deliveries that are due, a batch at a time.
+func (d *Dispatcher) Poll(ctx context.Context) {
+	if n, err := d.outbox.EnqueueExpired(d.now()); err != nil {
+		d.log.Error("failed to queue expired links", slog.Any("error", err))
+	} else if n > 0 {
+		d.log.Info("expired links queued", slog.Int64("count", n))
+	}
+
+	for ctx.Err() == nil {
+		deliveries, err := d.outbox.PendingDeliveries(d.now(), d.opts.BatchSize)
+		if err != nil {
+			d.log.Error("failed to get pending deliveries", slog.Any("error", err))
+			return
+		}
+
+		var (
+			wg     sync.WaitGroup
+			failed atomic.Bool
+		)
+		for _, del := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if !d.deliver(ctx, del) {
+					failed.Store(true)
+				}
+			}()
+		}
+		wg.Wait()
+
+		// a delivery that couldn't be saved would be picked again right away
+		if len(deliveries) < d.opts.BatchSize || failed.Load() {
+			return
+		}
+	}
+}
+
+// deliver makes an attempt and saves its outcome, false if it
+// couldn't be saved.
+func (d *Dispatcher) deliver(ctx context.Context, del storage.Delivery) bool {
+	log := d.log.With(
+		slog.Int64("delivery", del.ID),
+		slog.Int64("webhook", del.WebhookID),
+		slog.String("event", del.Event),
+	)
+
+	code, err := d.send(ctx, del)
+	if ctx.Err() != nil {
+		// shutting down, the attempt is made again on the next start
+		return false
+	}
+
+	del.Attempts++
+	del.LastStatusCode = code
+
+	switch {
+	case err == nil:
+		del.Status = storage.DeliveryDelivered
+		del.DeliveredAt = d.now()
+		del.NextAttemptAt = time.Time{}
+		del.LastError = ""
+		log.Info("event delivered", slog.Int("attempt", del.Attempts))
+	case del.Attempts >= d.opts.MaxAttempts:
+		del.Status = storage.DeliveryDead
+		del.NextAttemptAt = time.Time{}
+		del.LastError = err.Error()
+		log.Warn("delivery is dead", slog.Int("attempt", del.Attempts), slog.Any("error", err))
+	default:
+		del.NextAttemptAt = d.now().Add(Backoff(del.Attempts, d.opts.Backoff, d.opts.MaxBackoff))
+		del.LastError = err.Error()
+		log.Info("delivery failed", slog.Int("attempt", del.Attempts),
+			slog.Time("next_attempt_at", del.NextAttemptAt), slog.Any("error", err))
+	}
+
+	if err := d.outbox.UpdateDelivery(del); err != nil {
+		log.Error("failed to save delivery", slog.Any("error", err))
+		return false
+	}
+
+	return true
+}
+
+// send posts the payload, any status but 2xx is an error.
+func (d *Dispatcher) send(ctx context.Context, del storage.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, del.URL, bytes.NewReader(del.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	ts := d.now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "urlshortener-webhook")
+	req.Header.Set(HeaderEvent, del.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(del.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(del.Secret, ts, del.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// drain, so the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature header value of body sent at timestamp.
+// Receivers should compare it in constant time and reject old timestamps.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay after the given failed attempt (1-based):
+// base doubled with every attempt, capped at maxDelay.
+func Backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxDelay {
+			return maxDelay
+		}
+	}
+
+	return min(delay, maxDelay)
+}
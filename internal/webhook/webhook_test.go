@@ -0,0 +1,194 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/storage"
+	"urlshortener/internal/webhook"
+	"urlshortener/lib/logger/handlers/slogdiscard"
+)
+
+// outbox keeps deliveries in memory.
+type outbox struct {
+	mu         sync.Mutex
+	deliveries map[int64]storage.Delivery
+}
+
+func (o *outbox) PendingDeliveries(now time.Time, limit int) ([]storage.Delivery, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var due []storage.Delivery
+	for _, d := range o.deliveries {
+		if d.Status == storage.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, d)
+		}
+	}
+
+	return due, nil
+}
+
+func (o *outbox) UpdateDelivery(d storage.Delivery) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.deliveries[d.ID] = d
+
+	return nil
+}
+
+func (o *outbox) EnqueueExpired(time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (o *outbox) get(id int64) storage.Delivery {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.deliveries[id]
+}
+
+func TestDispatcher(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		status = http.StatusInternalServerError
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, webhook.Sign("secret", ts, body), r.Header.Get(webhook.HeaderSignature))
+		assert.Equal(t, storage.EventLinkCreated, r.Header.Get(webhook.HeaderEvent))
+		assert.Equal(t, "1", r.Header.Get(webhook.HeaderDelivery))
+
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	o := &outbox{deliveries: map[int64]storage.Delivery{
+		1: {
+			ID:        1,
+			WebhookID: 7,
+			URL:       srv.URL,
+			Secret:    "secret",
+			Event:     storage.EventLinkCreated,
+			Payload:   []byte(`{"type":"link.created"}`),
+			Status:    storage.DeliveryPending,
+		},
+	}}
+
+	d := webhook.New(slogdiscard.NewDiscardLogger(), o, webhook.Options{
+		MaxAttempts: 3,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		// the test server listens on loopback
+		AllowPrivate: true,
+	})
+
+	d.Poll(context.Background())
+
+	del := o.get(1)
+	require.Equal(t, storage.DeliveryPending, del.Status)
+	require.Equal(t, 1, del.Attempts)
+	require.Equal(t, http.StatusInternalServerError, del.LastStatusCode)
+	require.NotEmpty(t, del.LastError)
+
+	mu.Lock()
+	status = http.StatusNoContent
+	mu.Unlock()
+
+	time.Sleep(2 * time.Millisecond)
+	d.Poll(context.Background())
+
+	del = o.get(1)
+	require.Equal(t, storage.DeliveryDelivered, del.Status)
+	require.Equal(t, 2, del.Attempts)
+	require.False(t, del.DeliveredAt.IsZero())
+	require.Empty(t, del.LastError)
+}
+
+func TestDispatcher_DeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	o := &outbox{deliveries: map[int64]storage.Delivery{
+		1: {ID: 1, URL: srv.URL, Status: storage.DeliveryPending},
+	}}
+
+	d := webhook.New(slogdiscard.NewDiscardLogger(), o, webhook.Options{
+		MaxAttempts: 2,
+		Backoff:     time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+		// the test server listens on loopback
+		AllowPrivate: true,
+	})
+
+	for range 3 {
+		d.Poll(context.Background())
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	del := o.get(1)
+	require.Equal(t, storage.DeliveryDead, del.Status)
+	require.Equal(t, 2, del.Attempts)
+	require.Equal(t, http.StatusBadGateway, del.LastStatusCode)
+}
+
+func TestDispatcher_PrivateAddress(t *testing.T) {
+	var called atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer srv.Close()
+
+	o := &outbox{deliveries: map[int64]storage.Delivery{
+		1: {ID: 1, URL: srv.URL, Status: storage.DeliveryPending},
+	}}
+
+	d := webhook.New(slogdiscard.NewDiscardLogger(), o, webhook.Options{MaxAttempts: 1})
+	d.Poll(context.Background())
+
+	del := o.get(1)
+	require.Equal(t, storage.DeliveryDead, del.Status)
+	require.Contains(t, del.LastError, webhook.ErrForbiddenAddress.Error())
+	require.False(t, called.Load())
+}
+
+func TestBackoff(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tc := range cases {
+		require.Equal(t, tc.want, webhook.Backoff(tc.attempt, 30*time.Second, time.Hour), "attempt %d", tc.attempt)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{}' | openssl dgst -sha256 -hmac secret
+	require.Equal(t,
+		"sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163",
+		webhook.Sign("secret", 1700000000, []byte("{}")))
+}
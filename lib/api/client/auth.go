@@ -0,0 +1,40 @@
+package client
+
+import "net/http"
+
+// APIKeyHeader carries the key of APIKey.
+const APIKeyHeader = "X-API-Key"
+
+// Auth adds credentials to a request.
+type Auth interface {
+	Apply(r *http.Request)
+}
+
+// AuthFunc adapts a function to Auth.
+type AuthFunc func(r *http.Request)
+
+func (f AuthFunc) Apply(r *http.Request) { f(r) }
+
+// BasicAuth authenticates with the user and password from config
+// (http_server.user and http_server.password).
+func BasicAuth(user, password string) Auth {
+	return AuthFunc(func(r *http.Request) {
+		r.SetBasicAuth(user, password)
+	})
+}
+
+// BearerToken sends "Authorization: Bearer <token>". The server itself
+// accepts only BasicAuth and API keys, the token is for a gateway in front
+// of it that checks tokens and authenticates to the server on its own.
+func BearerToken(token string) Auth {
+	return AuthFunc(func(r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+token)
+	})
+}
+
+// APIKey sends the key in the X-API-Key header.
+func APIKey(key string) Auth {
+	return AuthFunc(func(r *http.Request) {
+		r.Header.Set(APIKeyHeader, key)
+	})
+}
@@ -0,0 +1,256 @@
+// Package client is the Go client of the shortener HTTP API (/api/v1).
+//
+// Failed calls return *Error, compare it with errors.Is to the Err*
+// values of this package:
+//
+//	alias, err := c.Save(ctx, client.SaveRequest{URL: "https://example.com", Alias: "docs"})
+//	if errors.Is(err, client.ErrURLExists) {
+//		// pick another alias
+//	}
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	resp "urlshortener/lib/api/response"
+)
+
+// maxResponseSize limits bodies read from the server.
+const maxResponseSize = 10 << 20
+
+// Options configures the client. Zero values are replaced with defaults.
+type Options struct {
+	// HTTPClient sends the requests, http.DefaultClient if nil.
+	// Its Timeout limits every attempt, use the context to limit
+	// a call with its retries.
+	HTTPClient *http.Client
+	// Auth adds credentials to requests, see BasicAuth, APIKey and BearerToken.
+	Auth Auth
+	// MaxAttempts is the number of attempts of a call, 1 disables retries.
+	// Calls are retried on 429, 5xx and network errors.
+	MaxAttempts int
+	// Backoff is the delay after the first failed attempt, it doubles
+	// with every attempt up to MaxBackoff. A Retry-After longer than
+	// MaxBackoff ends the retries.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// BatchConcurrency is the number of links Batch creates at once.
+	BatchConcurrency int
+	// UserAgent is sent with every request.
+	UserAgent string
+}
+
+// Client calls the API. It is safe for concurrent use.
+type Client struct {
+	baseURL *url.URL
+	http    *http.Client
+	opts    Options
+}
+
+// New returns a client of the server at baseURL, e.g. "https://sho.rt".
+func New(baseURL string, opts Options) (*Client, error) {
+	const op = "client.New"
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%s: base url must be absolute http(s): %q", op, baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v1"
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 3
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = 200 * time.Millisecond
+	}
+	if opts.MaxBackoff < opts.Backoff {
+		opts.MaxBackoff = max(5*time.Second, opts.Backoff)
+	}
+	if opts.BatchConcurrency <= 0 {
+		opts.BatchConcurrency = 4
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = "urlshortener-go-client"
+	}
+
+	return &Client{
+		baseURL: u,
+		http:    opts.HTTPClient,
+		opts:    opts,
+	}, nil
+}
+
+// call is a request of a client method.
+type call struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	body   any
+	// out receives the JSON body of a 2xx response, if not nil.
+	out any
+	// raw receives the body of a 2xx response as is, if not nil.
+	raw *[]byte
+}
+
+// do sends the call, retrying it on 429, 5xx and network errors
+// until it succeeds, the attempts run out or ctx is done.
+func (c *Client) do(ctx context.Context, cl call) error {
+	var body []byte
+	if cl.body != nil {
+		var err error
+		if body, err = json.Marshal(cl.body); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+	}
+
+	u := c.baseURL.JoinPath(cl.path)
+	u.RawQuery = cl.query.Encode()
+
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, cl, u.String(), body)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if attempt >= c.opts.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		delay := c.backoff(attempt)
+		if retryAfter > 0 {
+			if retryAfter > c.opts.MaxBackoff {
+				return err
+			}
+			delay = retryAfter
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends the request once. It returns the Retry-After
+// of the response, if any.
+func (c *Client) attempt(ctx context.Context, cl call, u string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, cl.method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	for k, v := range cl.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	// problem details carry the failed fields
+	req.Header.Set("Accept", "application/problem+json, application/json")
+	req.Header.Set("User-Agent", c.opts.UserAgent)
+	if c.opts.Auth != nil {
+		c.opts.Auth.Apply(req)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return 0, &networkError{err: err}
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return 0, &networkError{err: err}
+	}
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return parseRetryAfter(res.Header.Get("Retry-After")), parseError(res, data)
+	}
+
+	if cl.raw != nil {
+		*cl.raw = data
+	}
+	if cl.out != nil {
+		if err := json.Unmarshal(data, cl.out); err != nil {
+			return 0, fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	return 0, nil
+}
+
+// backoff returns the delay after the given failed attempt (1-based),
+// jittered so that clients failing together don't retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.opts.Backoff
+	for i := 1; i < attempt && delay < c.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, c.opts.MaxBackoff)
+
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// networkError is a failed round trip, the request may not have
+// reached the server.
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
+
+func retryable(err error) bool {
+	var netErr *networkError
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests ||
+		apiErr.StatusCode >= 500 ||
+		// the first attempt of a Save is still running on the server
+		apiErr.Code == resp.CodeIdempotencyInProgress
+}
+
+// parseRetryAfter reads seconds or an HTTP date, 0 if absent or invalid.
+func parseRetryAfter(v string) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,361 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/internal/http-server/handlers/url/get"
+	"urlshortener/internal/http-server/handlers/url/list"
+	"urlshortener/internal/http-server/handlers/url/save"
+	"urlshortener/internal/http-server/handlers/url/stats"
+	"urlshortener/internal/http-server/handlers/url/update"
+	"urlshortener/lib/api/client"
+	resp "urlshortener/lib/api/response"
+)
+
+func newClient(t *testing.T, h http.HandlerFunc, opts client.Options) *client.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	if opts.Backoff == 0 {
+		opts.Backoff = time.Millisecond
+	}
+	c, err := client.New(srv.URL, opts)
+	require.NoError(t, err)
+
+	return c
+}
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	for _, base := range []string{"", "sho.rt", "ftp://sho.rt", "http://"} {
+		_, err := client.New(base, client.Options{})
+		assert.Error(t, err, base)
+	}
+
+	_, err := client.New("https://sho.rt/prefix/", client.Options{})
+	assert.NoError(t, err)
+}
+
+func TestRetries(t *testing.T) {
+	cases := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		maxAttempts  int
+		wantAttempts int32
+		wantErr      error
+		wantStatus   int
+	}{
+		{
+			name:         "Recovers after 5xx",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantAttempts: 3,
+		},
+		{
+			name:         "Recovers after 429",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "0",
+			wantAttempts: 2,
+		},
+		{
+			name:         "Gives up",
+			statuses:     []int{http.StatusInternalServerError, http.StatusInternalServerError, http.StatusInternalServerError},
+			wantAttempts: 3,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "Retry-After too long",
+			statuses:     []int{http.StatusTooManyRequests, http.StatusOK},
+			retryAfter:   "3600",
+			wantAttempts: 1,
+			wantStatus:   http.StatusTooManyRequests,
+		},
+		{
+			name:         "Disabled",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusOK},
+			maxAttempts:  1,
+			wantAttempts: 1,
+			wantStatus:   http.StatusServiceUnavailable,
+		},
+		{
+			name:         "Client errors are final",
+			statuses:     []int{http.StatusConflict, http.StatusOK},
+			wantAttempts: 1,
+			wantErr:      client.ErrURLExists,
+			wantStatus:   http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				attempts atomic.Int32
+				keys     = make(chan string, len(tc.statuses))
+			)
+			c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				keys <- r.Header.Get(client.IdempotencyKeyHeader)
+
+				status := tc.statuses[n-1]
+				if status == http.StatusOK {
+					w.Write([]byte(`{"status":"OK","alias":"abc"}`))
+					return
+				}
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				res := resp.Error("failed")
+				if status == http.StatusConflict {
+					res = resp.ErrorCode(resp.CodeAliasExists, "url already exists")
+				}
+				resp.RenderError(w, r, status, res)
+			}, client.Options{MaxAttempts: tc.maxAttempts})
+
+			alias, err := c.Save(context.Background(), client.SaveRequest{URL: "https://example.com"})
+			require.Equal(t, tc.wantAttempts, attempts.Load())
+
+			close(keys)
+			key := <-keys
+			assert.NotEmpty(t, key)
+			for k := range keys {
+				assert.Equal(t, key, k, "retries must reuse the idempotency key")
+			}
+
+			if tc.wantStatus == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, "abc", alias)
+				return
+			}
+
+			var apiErr *client.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tc.wantStatus, apiErr.StatusCode)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRetriesStopWithContext(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, client.Options{MaxAttempts: 100, Backoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := c.Get(ctx, "", "abc")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		name        string
+		handler     http.HandlerFunc
+		wantErr     error
+		wantCode    string
+		wantMessage string
+		wantFields  int
+	}{
+		{
+			name: "Problem details",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", resp.ContentTypeProblem)
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"type":"urn:urlshortener:problem:validation_failed","title":"Unprocessable Entity",` +
+					`"status":422,"detail":"field URL is not a valid URL","instance":"req-1","code":"validation_failed",` +
+					`"errors":[{"field":"url","rule":"url","message":"field URL is not a valid URL"}]}`))
+			},
+			wantErr:     client.ErrValidation,
+			wantCode:    resp.CodeValidation,
+			wantMessage: "field URL is not a valid URL",
+			wantFields:  1,
+		},
+		{
+			name: "Legacy JSON",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"status":"Error","error":"url not found","code":"not_found"}`))
+			},
+			wantErr:     client.ErrURLNotFound,
+			wantCode:    resp.CodeNotFound,
+			wantMessage: "url not found",
+		},
+		{
+			name: "Plain text",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			},
+			wantErr:     client.ErrUnauthorized,
+			wantMessage: "Unauthorized",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newClient(t, tc.handler, client.Options{})
+
+			_, err := c.Get(context.Background(), "", "abc")
+
+			var apiErr *client.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.ErrorIs(t, err, tc.wantErr)
+			assert.Equal(t, tc.wantCode, apiErr.Code)
+			assert.Equal(t, tc.wantMessage, apiErr.Message)
+			assert.Len(t, apiErr.Fields, tc.wantFields)
+		})
+	}
+
+	assert.False(t, errors.Is(&client.Error{StatusCode: http.StatusBadGateway}, client.ErrURLNotFound))
+}
+
+func TestAuth(t *testing.T) {
+	cases := []struct {
+		name   string
+		auth   client.Auth
+		header string
+		want   string
+	}{
+		{name: "Basic", auth: client.BasicAuth("user", "pass"), header: "Authorization", want: "Basic dXNlcjpwYXNz"},
+		{name: "Bearer", auth: client.BearerToken("token"), header: "Authorization", want: "Bearer token"},
+		{name: "API key", auth: client.APIKey("key"), header: client.APIKeyHeader, want: "key"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tc.want, r.Header.Get(tc.header))
+				w.Write([]byte(`{"status":"OK"}`))
+			}, client.Options{Auth: tc.auth})
+
+			require.NoError(t, c.Delete(context.Background(), "", "abc"))
+		})
+	}
+}
+
+func TestRequests(t *testing.T) {
+	t.Parallel()
+
+	var got []string
+	c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.RequestURI())
+		w.Write([]byte(`{"status":"OK"}`))
+	}, client.Options{})
+
+	ctx := context.Background()
+	_, err := c.Get(ctx, "go.example.com", "a/b")
+	require.NoError(t, err)
+	_, err = c.Update(ctx, "", "abc", client.UpdateRequest{Title: client.Ptr("")})
+	require.NoError(t, err)
+	_, err = c.List(ctx, client.ListOptions{After: 10, Limit: 5})
+	require.NoError(t, err)
+	_, err = c.Stats(ctx, "", "abc")
+	require.NoError(t, err)
+	image, err := c.QR(ctx, "", "abc", client.QROptions{Format: "svg", Size: 64, Margin: client.NoMargin})
+	require.NoError(t, err)
+	assert.Equal(t, `{"status":"OK"}`, string(image))
+
+	assert.Equal(t, []string{
+		"GET /api/v1/url/a%2Fb?domain=go.example.com",
+		"PATCH /api/v1/url/abc",
+		"GET /api/v1/url?after=10&limit=5",
+		"GET /api/v1/url/abc/stats",
+		"GET /api/v1/url/abc/qr?format=svg&margin=0&size=64",
+	}, got)
+}
+
+func TestUpdateBody(t *testing.T) {
+	cases := []struct {
+		name string
+		req  client.UpdateRequest
+		want string
+	}{
+		{name: "Params not set", req: client.UpdateRequest{Title: client.Ptr("x")}, want: `{"title":"x"}`},
+		{name: "Remove params", req: client.UpdateRequest{Params: &map[string]string{}}, want: `{"params":{}}`},
+		{name: "Replace params", req: client.UpdateRequest{Params: &map[string]string{"a": "1"}}, want: `{"params":{"a":"1"}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+				assert.JSONEq(t, tc.want, string(body))
+				w.Write([]byte(`{"status":"OK"}`))
+			}, client.Options{})
+
+			_, err := c.Update(context.Background(), "", "abc", tc.req)
+			require.NoError(t, err)
+		})
+	}
+}
+
+// TestTypes keeps the types of the client in sync with the handlers.
+func TestTypes(t *testing.T) {
+	cases := []struct {
+		name   string
+		client any
+		server any
+	}{
+		{name: "SaveRequest", client: client.SaveRequest{}, server: save.Request{}},
+		{name: "Variant", client: client.Variant{}, server: save.Variant{}},
+		{name: "UpdateRequest", client: client.UpdateRequest{}, server: update.Request{}},
+		{name: "Link", client: client.Link{}, server: get.Link{}},
+		{name: "ListPage", client: client.ListPage{}, server: list.Response{}},
+		{name: "Stats", client: client.Stats{}, server: stats.Response{}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ElementsMatch(t, jsonNames(reflect.TypeOf(tc.client)), jsonNames(reflect.TypeOf(tc.server)))
+		})
+	}
+}
+
+// jsonNames returns the JSON names of the fields of typ, leaving out
+// the fields of resp.Response every server response embeds.
+func jsonNames(typ reflect.Type) []string {
+	var names []string
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || f.Type == reflect.TypeFor[resp.Response]() {
+			continue
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
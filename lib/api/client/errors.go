@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	resp "urlshortener/lib/api/response"
+)
+
+// Errors matched by *Error with errors.Is. They mirror the errors of
+// the storage (ErrURLExists, ErrUrlNotFound) and the codes of the API.
+var (
+	ErrURLExists            = errors.New("url already exists")
+	ErrURLNotFound          = errors.New("url not found")
+	ErrValidation           = errors.New("invalid fields")
+	ErrInvalidRequest       = errors.New("invalid request")
+	ErrDomainNotRegistered  = errors.New("domain is not registered")
+	ErrDomainForbidden      = errors.New("domain belongs to another user")
+	ErrIdempotencyKeyReused = errors.New("idempotency key used with another request")
+	ErrUnauthorized         = errors.New("unauthorized")
+)
+
+// codeErrors maps codes of the API to errors.
+var codeErrors = map[string]error{
+	resp.CodeAliasExists:           ErrURLExists,
+	resp.CodeNotFound:              ErrURLNotFound,
+	resp.CodeValidation:            ErrValidation,
+	resp.CodeInvalidURL:            ErrValidation,
+	resp.CodeAliasReserved:         ErrValidation,
+	resp.CodeEmptyRequest:          ErrInvalidRequest,
+	resp.CodeInvalidJSON:           ErrInvalidRequest,
+	resp.CodeInvalidIdempotencyKey: ErrInvalidRequest,
+	resp.CodeRequestTooLarge:       ErrInvalidRequest,
+	resp.CodeDomainNotRegistered:   ErrDomainNotRegistered,
+	resp.CodeDomainForbidden:       ErrDomainForbidden,
+	resp.CodeIdempotencyKeyReused:  ErrIdempotencyKeyReused,
+}
+
+// Error is an error response of the API.
+type Error struct {
+	// StatusCode is the HTTP status of the response.
+	StatusCode int
+	// Code is the resp.Code* of the error, empty for errors without one.
+	Code string
+	// Message is the error text of the server.
+	Message string
+	// Fields are the failed validation rules, set with ErrValidation.
+	Fields []resp.FieldError
+	// RequestID identifies the request in the server logs.
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("urlshortener: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+
+	return msg
+}
+
+// Unwrap returns the Err* value of the error, nil if none matches.
+func (e *Error) Unwrap() error {
+	if err, ok := codeErrors[e.Code]; ok {
+		return err
+	}
+
+	// errors without a code: BasicAuth, and lookups of older servers
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrURLNotFound
+	}
+
+	return nil
+}
+
+// parseError reads an error response, as problem details,
+// the usual JSON or plain text.
+func parseError(res *http.Response, body []byte) *Error {
+	apiErr := &Error{StatusCode: res.StatusCode}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType == resp.ContentTypeProblem {
+		var p resp.Problem
+		if json.Unmarshal(body, &p) == nil {
+			apiErr.Code = p.Code
+			apiErr.Message = p.Detail
+			apiErr.Fields = p.Errors
+			apiErr.RequestID = p.Instance
+			return apiErr
+		}
+	}
+
+	// the usual JSON errors are sent before their Content-Type is set,
+	// so they arrive as text/plain
+	var r resp.Response
+	if json.Unmarshal(body, &r) == nil && r.Status == resp.StatusError {
+		apiErr.Code = r.Code
+		apiErr.Message = r.Error
+		return apiErr
+	}
+
+	apiErr.Message = strings.TrimSpace(string(body))
+	if len(apiErr.Message) > 200 {
+		apiErr.Message = apiErr.Message[:200]
+	}
+
+	return apiErr
+}
@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader makes retries of Save return the first response.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// SaveRequest is a new link, see POST /api/v1/url.
+type SaveRequest struct {
+	URL string `json:"url"`
+	// Alias is random if empty.
+	Alias string `json:"alias,omitempty"`
+	// Domain is a custom short domain of the caller, the default one if empty.
+	Domain string `json:"domain,omitempty"`
+	// RedirectType is 301, 302, 307 or 308, the default from config if zero.
+	RedirectType int               `json:"redirect_type,omitempty"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Campaign     string            `json:"campaign,omitempty"`
+	Password     string            `json:"password,omitempty"`
+	MaxClicks    int64             `json:"max_clicks,omitempty"`
+	Burn         bool              `json:"burn,omitempty"`
+	ActiveFrom   time.Time         `json:"active_from,omitzero"`
+	ActiveUntil  time.Time         `json:"active_until,omitzero"`
+	PendingURL   string            `json:"pending_url,omitempty"`
+	FallbackURL  string            `json:"fallback_url,omitempty"`
+	Variants     []Variant         `json:"variants,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Image        string            `json:"image,omitempty"`
+
+	// IdempotencyKey identifies the link across retries, random if empty.
+	// Set it to retry safely after a restart of the caller.
+	IdempotencyKey string `json:"-"`
+}
+
+// Variant is a weighted destination of an A/B split.
+type Variant struct {
+	// Name is A, B, C... by position if empty.
+	Name   string `json:"name,omitempty"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// Rule sends matching visitors to another destination.
+type Rule struct {
+	OS       string `json:"os,omitempty"`
+	Device   string `json:"device,omitempty"`
+	Visitor  string `json:"visitor,omitempty"`
+	Language string `json:"language,omitempty"`
+	URL      string `json:"url"`
+}
+
+// Link is a short link. Rules and variants are only set by Get and Update.
+type Link struct {
+	ID int64 `json:"id"`
+	// Domain is empty for links of the default domain.
+	Domain       string            `json:"domain,omitempty"`
+	Alias        string            `json:"alias"`
+	URL          string            `json:"url"`
+	Owner        string            `json:"owner,omitempty"`
+	RedirectType int               `json:"redirect_type,omitempty"`
+	Passthrough  bool              `json:"passthrough,omitempty"`
+	Params       map[string]string `json:"params,omitempty"`
+	Campaign     string            `json:"campaign,omitempty"`
+	// Protected links ask visitors for a password.
+	Protected   bool      `json:"protected,omitempty"`
+	Clicks      int64     `json:"clicks"`
+	MaxClicks   int64     `json:"max_clicks,omitempty"`
+	Burn        bool      `json:"burn,omitempty"`
+	ActiveFrom  time.Time `json:"active_from,omitzero"`
+	ActiveUntil time.Time `json:"active_until,omitzero"`
+	PendingURL  string    `json:"pending_url,omitempty"`
+	FallbackURL string    `json:"fallback_url,omitempty"`
+	Rules       []Rule    `json:"rules,omitempty"`
+	Variants    []Variant `json:"variants,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Image       string    `json:"image,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+}
+
+// UpdateRequest changes the fields that are set, see Ptr. Empty strings,
+// zero numbers and zero times remove optional settings.
+type UpdateRequest struct {
+	URL          *string `json:"url,omitempty"`
+	RedirectType *int    `json:"redirect_type,omitempty"`
+	Passthrough  *bool   `json:"passthrough,omitempty"`
+	// Params replace the params of the link, an empty map removes them.
+	Params   *map[string]string `json:"params,omitempty"`
+	Campaign *string            `json:"campaign,omitempty"`
+	// Password protects the link, empty removes the protection.
+	Password    *string    `json:"password,omitempty"`
+	MaxClicks   *int64     `json:"max_clicks,omitempty"`
+	Burn        *bool      `json:"burn,omitempty"`
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	PendingURL  *string    `json:"pending_url,omitempty"`
+	FallbackURL *string    `json:"fallback_url,omitempty"`
+	Title       *string    `json:"title,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	Image       *string    `json:"image,omitempty"`
+}
+
+// Ptr returns a pointer to v, for the fields of UpdateRequest.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// ListOptions selects a page of List.
+type ListOptions struct {
+	// Domain is a custom short domain, the default one if empty.
+	Domain string
+	// After is NextAfter of the previous page, zero for the first one.
+	After int64
+	// Limit is the page size, 100 if zero, up to 1000.
+	Limit int
+}
+
+// ListPage is a page of links, oldest first.
+type ListPage struct {
+	Links []Link `json:"links"`
+	// NextAfter is zero on the last page.
+	NextAfter int64 `json:"next_after,omitempty"`
+}
+
+// Stats are click statistics of a link.
+type Stats struct {
+	Clicks int64 `json:"clicks"`
+	// Variants are clicks by A/B variant.
+	Variants map[string]int64 `json:"variants,omitempty"`
+	// FirstClickAt and LastClickAt are zero if there are no clicks.
+	FirstClickAt time.Time `json:"first_click_at,omitzero"`
+	LastClickAt  time.Time `json:"last_click_at,omitzero"`
+}
+
+// BatchResult is the outcome of a link of Batch.
+type BatchResult struct {
+	Alias string
+	Err   error
+}
+
+// Save creates a link and returns its alias. Retries send the same
+// Idempotency-Key, so a link is never created twice.
+func (c *Client) Save(ctx context.Context, req SaveRequest) (string, error) {
+	key := req.IdempotencyKey
+	if key == "" {
+		key = newIdempotencyKey()
+	}
+
+	var out struct {
+		Alias string `json:"alias"`
+	}
+	err := c.do(ctx, call{
+		method: http.MethodPost,
+		path:   "/url",
+		header: http.Header{IdempotencyKeyHeader: {key}},
+		body:   req,
+		out:    &out,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return out.Alias, nil
+}
+
+// Get returns the link with its rules and variants.
+// domain is empty for links of the default domain.
+func (c *Client) Get(ctx context.Context, domain, alias string) (Link, error) {
+	var out struct {
+		Link Link `json:"link"`
+	}
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		path:   "/url/" + url.PathEscape(alias),
+		query:  domainQuery(domain),
+		out:    &out,
+	})
+
+	return out.Link, err
+}
+
+// Update changes the link and returns it.
+func (c *Client) Update(ctx context.Context, domain, alias string, req UpdateRequest) (Link, error) {
+	var out struct {
+		Link Link `json:"link"`
+	}
+	err := c.do(ctx, call{
+		method: http.MethodPatch,
+		path:   "/url/" + url.PathEscape(alias),
+		query:  domainQuery(domain),
+		body:   req,
+		out:    &out,
+	})
+
+	return out.Link, err
+}
+
+// Delete deletes the link, its alias stays reserved during the quarantine.
+func (c *Client) Delete(ctx context.Context, domain, alias string) error {
+	return c.do(ctx, call{
+		method: http.MethodDelete,
+		path:   "/url/" + url.PathEscape(alias),
+		query:  domainQuery(domain),
+	})
+}
+
+// List returns a page of links of the caller on a domain.
+func (c *Client) List(ctx context.Context, opts ListOptions) (ListPage, error) {
+	q := domainQuery(opts.Domain)
+	if opts.After > 0 {
+		q.Set("after", strconv.FormatInt(opts.After, 10))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	var out ListPage
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		path:   "/url",
+		query:  q,
+		out:    &out,
+	})
+
+	return out, err
+}
+
+// Stats returns click statistics of the link.
+func (c *Client) Stats(ctx context.Context, domain, alias string) (Stats, error) {
+	var out Stats
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		path:   "/url/" + url.PathEscape(alias) + "/stats",
+		query:  domainQuery(domain),
+		out:    &out,
+	})
+
+	return out, err
+}
+
+// Batch creates the links, Options.BatchConcurrency at a time.
+// Results are in the order of reqs, a failed link doesn't stop the others.
+func (c *Client) Batch(ctx context.Context, reqs []SaveRequest) []BatchResult {
+	results := make([]BatchResult, len(reqs))
+	sem := make(chan struct{}, c.opts.BatchConcurrency)
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			results[i].Alias, results[i].Err = c.Save(ctx, req)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func domainQuery(domain string) url.Values {
+	q := url.Values{}
+	if domain != "" {
+		q.Set("domain", domain)
+	}
+
+	return q
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// QROptions styles a QR code. Zero values are the defaults of the server.
+type QROptions struct {
+	// Format is png or svg.
+	Format string
+	// Size is the width and height in pixels, 32-2048.
+	Size int
+	// Level is the error correction level: L, M, Q or H.
+	Level string
+	// Margin is the quiet zone in modules, 0-32. Zero is the default of 4,
+	// use NoMargin for none.
+	Margin int
+	// FG and BG are hex colors, e.g. "000000".
+	FG string
+	BG string
+}
+
+// NoMargin is QROptions.Margin of a QR code without quiet zone.
+const NoMargin = -1
+
+// QR returns the QR code image of the short link.
+func (c *Client) QR(ctx context.Context, domain, alias string, opts QROptions) ([]byte, error) {
+	q := domainQuery(domain)
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("format", opts.Format)
+	set("level", opts.Level)
+	set("fg", opts.FG)
+	set("bg", opts.BG)
+	if opts.Size > 0 {
+		q.Set("size", strconv.Itoa(opts.Size))
+	}
+	switch {
+	case opts.Margin == NoMargin:
+		q.Set("margin", "0")
+	case opts.Margin > 0:
+		q.Set("margin", strconv.Itoa(opts.Margin))
+	}
+
+	var image []byte
+	err := c.do(ctx, call{
+		method: http.MethodGet,
+		path:   "/url/" + url.PathEscape(alias) + "/qr",
+		query:  q,
+		raw:    &image,
+	})
+
+	return image, err
+}
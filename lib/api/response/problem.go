@@ -0,0 +1,148 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// ContentTypeProblem is the media type of RFC 7807 problem details.
+const ContentTypeProblem = "application/problem+json"
+
+// ProblemTypePrefix prefixes error codes in the type of problem details,
+// problems without a code are "about:blank".
+const ProblemTypePrefix = "urn:urlshortener:problem:"
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Instance is the request ID, it is in the server logs too
+	Instance string `json:"instance,omitempty"`
+	// Code and Errors are extension members
+	Code   string       `json:"code,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError is a failed validation rule of a request field.
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. variants[0].url
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type problemKey struct{}
+
+// Negotiate picks the error format of the request: problem details if the
+// client accepts application/problem+json, the Response format if it only
+// accepts application/json, and the configured default otherwise.
+func Negotiate(problemByDefault bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			problem := problemByDefault
+			switch accept := acceptedTypes(r); {
+			case accept[ContentTypeProblem]:
+				problem = true
+			case accept["application/json"]:
+				problem = false
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), problemKey{}, problem)))
+		}
+
+		return http.HandlerFunc(fn)
+	}
+}
+
+// RenderError writes the error response with status, as problem details
+// if the request negotiated them.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, res Response) {
+	if problem, _ := r.Context().Value(problemKey{}).(bool); !problem {
+		render.Status(r, status)
+		render.JSON(w, r, res)
+		return
+	}
+
+	p := NewProblem(status, res)
+	p.Instance = middleware.GetReqID(r.Context())
+
+	w.Header().Set("Content-Type", ContentTypeProblem)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// NewProblem converts an error response to problem details.
+func NewProblem(status int, res Response) Problem {
+	p := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: res.Error,
+		Code:   res.Code,
+		Errors: res.fields,
+	}
+	if res.Code != "" {
+		p.Type = ProblemTypePrefix + res.Code
+	}
+
+	return p
+}
+
+// acceptedTypes returns the media types of the Accept header.
+func acceptedTypes(r *http.Request) map[string]bool {
+	types := map[string]bool{}
+	for _, v := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(v, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err == nil {
+				types[mediaType] = true
+			}
+		}
+	}
+
+	return types
+}
+
+// fieldPath converts a validator namespace (Request.Variants[0].URL) to the
+// JSON path of the field (variants[0].url). JSON names of this API are
+// snake case of the Go names.
+func fieldPath(namespace string) string {
+	parts := strings.Split(namespace, ".")
+	if len(parts) > 1 {
+		parts = parts[1:]
+	}
+	for i, part := range parts {
+		parts[i] = snakeCase(part)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// snakeCase converts a Go name, acronyms included: PendingURL -> pending_url.
+func snakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || acronymEnd {
+				b.WriteByte('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
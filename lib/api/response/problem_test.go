@@ -0,0 +1,97 @@
+package response_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	resp "urlshortener/lib/api/response"
+)
+
+func TestRenderError(t *testing.T) {
+	cases := []struct {
+		name        string
+		accept      string
+		byDefault   bool
+		wantProblem bool
+	}{
+		{name: "Default", accept: "*/*"},
+		{name: "Problem by default", accept: "*/*", byDefault: true, wantProblem: true},
+		{name: "Problem requested", accept: "application/problem+json", wantProblem: true},
+		{name: "Problem among others", accept: "application/json;q=0.5, application/problem+json", wantProblem: true},
+		{name: "JSON requested", accept: "application/json", byDefault: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := middleware.RequestID(resp.Negotiate(tc.byDefault)(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					resp.RenderError(w, r, http.StatusConflict, resp.ErrorCode(resp.CodeAliasExists, "url already exists"))
+				})))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/url", nil)
+			req.Header.Set("Accept", tc.accept)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusConflict, rr.Code)
+
+			if !tc.wantProblem {
+				// headers as sent, set after WriteHeader they would be lost
+				assert.Contains(t, rr.Result().Header.Get("Content-Type"), "application/json")
+
+				var res resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &res))
+				assert.Equal(t, resp.StatusError, res.Status)
+				assert.Equal(t, resp.CodeAliasExists, res.Code)
+				return
+			}
+
+			assert.Equal(t, resp.ContentTypeProblem, rr.Result().Header.Get("Content-Type"))
+
+			var p resp.Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &p))
+			assert.Equal(t, resp.ProblemTypePrefix+resp.CodeAliasExists, p.Type)
+			assert.Equal(t, "Conflict", p.Title)
+			assert.Equal(t, http.StatusConflict, p.Status)
+			assert.Equal(t, "url already exists", p.Detail)
+			assert.NotEmpty(t, p.Instance)
+		})
+	}
+}
+
+func TestNewProblem_Validation(t *testing.T) {
+	type variant struct {
+		URL string `validate:"required,url"`
+	}
+	type request struct {
+		PendingURL   string    `validate:"omitempty,url"`
+		RedirectType int       `validate:"omitempty,oneof=301 302"`
+		Variants     []variant `validate:"dive"`
+	}
+
+	err := validator.New().Struct(request{
+		PendingURL:   "not a url",
+		RedirectType: 200,
+		Variants:     []variant{{URL: "https://example.com"}, {}},
+	})
+	require.Error(t, err)
+
+	p := resp.NewProblem(http.StatusUnprocessableEntity, resp.ValidationError(err.(validator.ValidationErrors)))
+
+	assert.Equal(t, "about:blank", resp.NewProblem(http.StatusNotFound, resp.Error("not found")).Type)
+	assert.Equal(t, resp.ProblemTypePrefix+resp.CodeValidation, p.Type)
+	assert.Equal(t, []resp.FieldError{
+		{Field: "pending_url", Rule: "url", Message: "field PendingURL is not a valid URL"},
+		{Field: "redirect_type", Rule: "oneof", Message: "field RedirectType must be one of: 301 302"},
+		{Field: "variants[1].url", Rule: "required", Message: "field URL is a required field"},
+	}, p.Errors)
+}
@@ -10,7 +10,12 @@ import (
 type Response struct {
 	Status string `json:"status"`
 	Error  string `json:"error,omitempty"`
-	Alias  string `json:"alias,omitempty"`
+	// Code identifies the error for clients, unlike Error it never changes
+	Code  string `json:"code,omitempty"`
+	Alias string `json:"alias,omitempty"`
+
+	// fields are the validation errors shown in problem details
+	fields []FieldError
 }
 
 const (
@@ -18,6 +23,26 @@ const (
 	StatusError = "Error"
 )
 
+// Error codes. They are part of the API: add new ones, don't rename.
+const (
+	CodeEmptyRequest        = "empty_request"
+	CodeInvalidJSON         = "invalid_json"
+	CodeValidation          = "validation_failed"
+	CodeInvalidURL          = "invalid_url"
+	CodeAliasExists         = "alias_exists"
+	CodeDomainNotRegistered = "domain_not_registered"
+	CodeDomainForbidden     = "domain_forbidden"
+	CodeNotFound            = "not_found"
+	CodeInternal            = "internal_error"
+
+	CodeInvalidIdempotencyKey = "invalid_idempotency_key"
+	CodeIdempotencyKeyReused  = "idempotency_key_reused"
+	CodeIdempotencyInProgress = "idempotency_in_progress"
+	CodeRequestTooLarge       = "request_too_large"
+
+	CodeAliasReserved = "alias_reserved"
+)
+
 func OK() Response {
 	return Response{
 		Status: StatusOK,
@@ -32,12 +57,22 @@ func Error(msg string) Response {
 
 }
 
+// ErrorCode is Error with a machine-readable code.
+func ErrorCode(code string, msg string) Response {
+	return Response{
+		Status: StatusError,
+		Error:  msg,
+		Code:   code,
+	}
+}
+
 /*
 	ValidationError converts validator.ValidationErrors into a client-friendly format.
 
 For each validation error, it generates specific messages based on the validation tag:
   - "required": indicates missing required field
   - "url": indicates invalid URL format
+  - "oneof": lists the allowed values
   - default: generic invalid field message
 
 Example output:
@@ -46,21 +81,36 @@ Example output:
 */
 
 func ValidationError(errs validator.ValidationErrors) Response {
-	var errMsgs []string
+	var (
+		errMsgs []string
+		fields  []FieldError
+	)
 
 	for _, err := range errs {
+		var msg string
 		switch err.ActualTag() {
 		case "required":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
+			msg = fmt.Sprintf("field %s is a required field", err.Field())
 		case "url":
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+			msg = fmt.Sprintf("field %s is not a valid URL", err.Field())
+		case "oneof":
+			msg = fmt.Sprintf("field %s must be one of: %s", err.Field(), err.Param())
 		default:
-			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
+			msg = fmt.Sprintf("field %s is not valid", err.Field())
 		}
+
+		errMsgs = append(errMsgs, msg)
+		fields = append(fields, FieldError{
+			Field:   fieldPath(err.Namespace()),
+			Rule:    err.ActualTag(),
+			Message: msg,
+		})
 	}
 
 	return Response{
 		Status: StatusError,
 		Error:  strings.Join(errMsgs, ", "),
+		Code:   CodeValidation,
+		fields: fields,
 	}
 }
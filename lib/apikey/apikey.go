@@ -0,0 +1,34 @@
+// Package apikey generates API keys and the hashes they are stored by.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"strings"
+)
+
+// Prefix makes keys recognizable, by secret scanners for example.
+const Prefix = "us_"
+
+// keyBytes of randomness, 256 bits.
+const keyBytes = 32
+
+// Generate returns a new random key.
+func Generate() (string, error) {
+	b := make([]byte, keyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return Prefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b)), nil
+}
+
+// Hash returns the hash a key is stored and looked up by. Keys are random,
+// so a fast hash is enough, unlike for passwords.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,28 @@
+package apikey_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/apikey"
+)
+
+func TestGenerate(t *testing.T) {
+	k1, err := apikey.Generate()
+	require.NoError(t, err)
+	k2, err := apikey.Generate()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(k1, apikey.Prefix))
+	assert.Len(t, k1, len(apikey.Prefix)+52)
+	assert.NotEqual(t, k1, k2)
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, apikey.Hash("us_key"), apikey.Hash("us_key"))
+	assert.NotEqual(t, apikey.Hash("us_key"), apikey.Hash("us_other"))
+	assert.Len(t, apikey.Hash("us_key"), 64)
+}
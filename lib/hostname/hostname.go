@@ -0,0 +1,45 @@
+// Package hostname brings request hosts and domain names to the form
+// custom short domains are stored in.
+package hostname
+
+import (
+	"net"
+	"strings"
+)
+
+// Normalize lowercases host and drops the port and the trailing dot
+// ("Sho.RT.:8080" -> "sho.rt").
+func Normalize(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// Valid reports whether name is a normalized DNS name with at least
+// two labels, like "go.example.com". IP addresses are not valid.
+func Valid(name string) bool {
+	if len(name) > 253 || name != Normalize(name) || net.ParseIP(name) != nil {
+		return false
+	}
+
+	labels := strings.Split(name, ".")
+	if len(labels) < 2 {
+		return false
+	}
+
+	for _, label := range labels {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,38 @@
+package hostname_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/hostname"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"sho.rt":          "sho.rt",
+		"Sho.RT":          "sho.rt",
+		"sho.rt:8080":     "sho.rt",
+		"sho.rt.":         "sho.rt",
+		" go.example.com": "go.example.com",
+		"[::1]:8080":      "::1",
+		"":                "",
+	}
+
+	for in, want := range cases {
+		require.Equal(t, want, hostname.Normalize(in), in)
+	}
+}
+
+func TestValid(t *testing.T) {
+	valid := []string{"sho.rt", "go.example.com", "my-brand.co.uk", "x1.io"}
+	for _, name := range valid {
+		require.True(t, hostname.Valid(name), name)
+	}
+
+	invalid := []string{"", "localhost", "Sho.rt", "sho.rt:8080", "-a.com", "a-.com", "a..com",
+		"127.0.0.1", "under_score.com", "sho.rt."}
+	for _, name := range invalid {
+		require.False(t, hostname.Valid(name), name)
+	}
+}
@@ -0,0 +1,196 @@
+// Package qr renders QR codes as PNG or SVG with a custom size,
+// error correction level, quiet zone and colours.
+package qr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+var (
+	ErrInvalidLevel = errors.New("invalid error correction level")
+	ErrInvalidColor = errors.New("invalid color")
+)
+
+// Level is the error correction level: the share of the code that may be
+// damaged and still be read.
+type Level string
+
+const (
+	LevelLow      Level = "L" // ~7%
+	LevelMedium   Level = "M" // ~15%
+	LevelQuartile Level = "Q" // ~25%
+	LevelHigh     Level = "H" // ~30%
+)
+
+var recoveryLevels = map[Level]qrcode.RecoveryLevel{
+	LevelLow:      qrcode.Low,
+	LevelMedium:   qrcode.Medium,
+	LevelQuartile: qrcode.High,
+	LevelHigh:     qrcode.Highest,
+}
+
+// ParseLevel parses L, M, Q or H (case-insensitive).
+func ParseLevel(s string) (Level, error) {
+	l := Level(strings.ToUpper(s))
+	if _, ok := recoveryLevels[l]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLevel, s)
+	}
+
+	return l, nil
+}
+
+// ParseColor parses a hex colour: "rgb" or "rrggbb", with or without "#".
+func ParseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// Options configures rendering.
+type Options struct {
+	// Size is the width and height of the image in pixels. It is raised
+	// to one pixel per module if the code does not fit.
+	Size int
+	// Level defaults to LevelMedium.
+	Level Level
+	// Margin is the quiet zone around the code in modules.
+	Margin int
+	// Foreground and Background default to black and white.
+	Foreground color.Color
+	Background color.Color
+}
+
+// PNG renders content as a PNG image.
+func PNG(content string, opts Options) ([]byte, error) {
+	const op = "qr.PNG"
+
+	bitmap, err := encode(content, opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fg, bg := colors(opts)
+	size, scale, offset := layout(len(bitmap), opts)
+
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+	for y, row := range bitmap {
+		for x, set := range row {
+			if !set {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetColorIndex(offset+x*scale+dx, offset+y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SVG renders content as an SVG image. Modules are drawn as a single path,
+// so the image stays sharp at any scale.
+func SVG(content string, opts Options) ([]byte, error) {
+	const op = "qr.SVG"
+
+	bitmap, err := encode(content, opts.Level)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fg, bg := colors(opts)
+	margin := max(opts.Margin, 0)
+	total := len(bitmap) + 2*margin
+	size, _, _ := layout(len(bitmap), opts)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, total, total)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="%s"/>`, hex(bg))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hex(fg))
+	for y, row := range bitmap {
+		for x, set := range row {
+			if set {
+				fmt.Fprintf(&buf, "M%d %dh1v1h-1z", x+margin, y+margin)
+			}
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+
+	return buf.Bytes(), nil
+}
+
+// encode returns the modules of the code without the quiet zone.
+func encode(content string, level Level) ([][]bool, error) {
+	if level == "" {
+		level = LevelMedium
+	}
+	recovery, ok := recoveryLevels[level]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLevel, level)
+	}
+
+	q, err := qrcode.New(content, recovery)
+	if err != nil {
+		return nil, err
+	}
+	q.DisableBorder = true
+
+	return q.Bitmap(), nil
+}
+
+// layout returns the image size, the pixels per module and the offset
+// of the first module that centers the code with its margin.
+func layout(modules int, opts Options) (size, scale, offset int) {
+	total := modules + 2*max(opts.Margin, 0)
+
+	size = max(opts.Size, total)
+	scale = size / total
+	offset = (size - modules*scale) / 2
+
+	return size, scale, offset
+}
+
+func colors(opts Options) (fg, bg color.Color) {
+	fg, bg = opts.Foreground, opts.Background
+	if fg == nil {
+		fg = color.Black
+	}
+	if bg == nil {
+		bg = color.White
+	}
+
+	return fg, bg
+}
+
+func hex(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
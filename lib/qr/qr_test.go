@@ -0,0 +1,101 @@
+package qr_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/qr"
+)
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"L", "m", "Q", "h"} {
+		l, err := qr.ParseLevel(s)
+		require.NoError(t, err)
+		require.Equal(t, qr.Level(strings.ToUpper(s)), l)
+	}
+
+	_, err := qr.ParseLevel("X")
+	require.ErrorIs(t, err, qr.ErrInvalidLevel)
+}
+
+func TestParseColor(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{in: "000000", want: color.RGBA{A: 0xff}},
+		{in: "#ff8000", want: color.RGBA{R: 0xff, G: 0x80, A: 0xff}},
+		{in: "fff", want: color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{in: "#12", wantErr: true},
+		{in: "zzzzzz", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := qr.ParseColor(tc.in)
+			if tc.wantErr {
+				require.ErrorIs(t, err, qr.ErrInvalidColor)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	fg := color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+	bg := color.RGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+
+	data, err := qr.PNG("https://sho.rt/abc", qr.Options{
+		Size: 300, Level: qr.LevelHigh, Margin: 2, Foreground: fg, Background: bg,
+	})
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	require.Equal(t, 300, img.Bounds().Dx())
+	require.Equal(t, 300, img.Bounds().Dy())
+
+	// corner is the quiet zone
+	require.Equal(t, bg, color.RGBAModel.Convert(img.At(0, 0)))
+
+	seen := map[color.Color]bool{}
+	for y := 0; y < 300; y++ {
+		for x := 0; x < 300; x++ {
+			seen[color.RGBAModel.Convert(img.At(x, y))] = true
+		}
+	}
+	require.Equal(t, map[color.Color]bool{fg: true, bg: true}, seen)
+}
+
+func TestPNG_TooSmall(t *testing.T) {
+	data, err := qr.PNG("https://sho.rt/abc", qr.Options{Size: 1, Margin: 4})
+	require.NoError(t, err)
+
+	img, err := png.Decode(bytes.NewReader(data))
+	require.NoError(t, err)
+	// one pixel per module at least
+	require.Greater(t, img.Bounds().Dx(), 1)
+}
+
+func TestSVG(t *testing.T) {
+	data, err := qr.SVG("https://sho.rt/abc", qr.Options{
+		Size: 256, Margin: 0, Foreground: color.RGBA{R: 0xff, A: 0xff},
+	})
+	require.NoError(t, err)
+
+	svg := string(data)
+	require.True(t, strings.HasPrefix(svg, "<svg "))
+	require.Contains(t, svg, `width="256"`)
+	require.Contains(t, svg, `fill="#ff0000"`)
+	require.Contains(t, svg, `fill="#ffffff"`)
+	// top left module of the finder pattern
+	require.Contains(t, svg, "M0 0h1v1h-1z")
+}
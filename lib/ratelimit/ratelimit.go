@@ -0,0 +1,84 @@
+// Package ratelimit counts events per key within a fixed time window,
+// e.g. failed password attempts per client.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepSize is the number of tracked keys after which expired ones are dropped.
+const sweepSize = 10000
+
+type counter struct {
+	count int
+	start time.Time
+}
+
+// Limiter allows at most max events per key within window.
+// It is safe for concurrent use.
+type Limiter struct {
+	mu       sync.Mutex
+	max      int
+	window   time.Duration
+	counters map[string]*counter
+}
+
+func New(max int, window time.Duration) *Limiter {
+	return &Limiter{
+		max:      max,
+		window:   window,
+		counters: make(map[string]*counter),
+	}
+}
+
+// Allow reports whether key is still under the limit.
+func (l *Limiter) Allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counters[key]
+	if !ok || l.expired(c) {
+		return true
+	}
+
+	return c.count < l.max
+}
+
+// Hit counts an event for key.
+func (l *Limiter) Hit(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c, ok := l.counters[key]
+	if !ok || l.expired(c) {
+		if len(l.counters) >= sweepSize {
+			l.sweep()
+		}
+
+		c = &counter{start: time.Now()}
+		l.counters[key] = c
+	}
+
+	c.count++
+}
+
+// Reset forgets all events of key.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.counters, key)
+}
+
+func (l *Limiter) expired(c *counter) bool {
+	return time.Now().Sub(c.start) >= l.window
+}
+
+func (l *Limiter) sweep() {
+	for key, c := range l.counters {
+		if l.expired(c) {
+			delete(l.counters, key)
+		}
+	}
+}
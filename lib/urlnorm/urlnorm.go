@@ -0,0 +1,124 @@
+// Package urlnorm brings URLs to a canonical form so that equivalent
+// destinations (different host case, default port, trailing slash,
+// query order, fragment) compare equal.
+package urlnorm
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidURL = errors.New("invalid url")
+
+// trackingPrefixes and trackingParams are query keys dropped when
+// StripTracking is set.
+var trackingPrefixes = []string{
+	"utm_",
+}
+
+var trackingParams = []string{
+	"fbclid",
+	"gclid",
+	"dclid",
+	"yclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"_openstat",
+}
+
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+}
+
+// Options configures Normalize.
+type Options struct {
+	// StripTracking removes well-known tracking parameters from the query.
+	StripTracking bool
+}
+
+// Normalize returns the canonical form of rawURL:
+//   - scheme and host are lowercased
+//   - default port (80 for http, 443 for https) is removed
+//   - path is cleaned ("/a/./b/../c/" -> "/a/c"), empty path becomes "/"
+//   - query parameters are sorted by key
+//   - fragment is dropped
+func Normalize(rawURL string, opts Options) (string, error) {
+	const op = "urlnorm.Normalize"
+
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w: %w", op, ErrInvalidURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("%s: %w: scheme and host are required", op, ErrInvalidURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = normalizeHost(u.Scheme, u.Host)
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	u.Path = cleanPath(u.Path)
+	u.RawPath = ""
+
+	query := u.Query()
+	if opts.StripTracking {
+		for key := range query {
+			if isTrackingParam(key) {
+				query.Del(key)
+			}
+		}
+	}
+	// Encode sorts by key
+	u.RawQuery = query.Encode()
+	u.ForceQuery = false
+
+	return u.String(), nil
+}
+
+func normalizeHost(scheme, host string) string {
+	host = strings.ToLower(host)
+
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		// no port in host
+		return host
+	}
+
+	if port == defaultPorts[scheme] || port == "" {
+		if strings.Contains(hostname, ":") {
+			// IPv6 literal
+			return "[" + hostname + "]"
+		}
+		return hostname
+	}
+
+	return host
+}
+
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	return path.Clean("/" + p)
+}
+
+func isTrackingParam(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, p := range trackingPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+
+	return slices.Contains(trackingParams, key)
+}
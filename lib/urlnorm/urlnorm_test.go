@@ -0,0 +1,84 @@
+package urlnorm_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/urlnorm"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		name    string
+		url     string
+		opts    urlnorm.Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Host case, trailing slash and fragment",
+			url:  "https://Example.com/a/?utm=1#x",
+			want: "https://example.com/a?utm=1",
+		},
+		{
+			name: "Already normalized",
+			url:  "https://example.com/a?utm=1",
+			want: "https://example.com/a?utm=1",
+		},
+		{
+			name: "Default ports",
+			url:  "HTTP://example.com:80/",
+			want: "http://example.com/",
+		},
+		{
+			name: "Non default port is kept",
+			url:  "https://example.com:8443",
+			want: "https://example.com:8443/",
+		},
+		{
+			name: "IPv6 default port",
+			url:  "http://[::1]:80/x",
+			want: "http://[::1]/x",
+		},
+		{
+			name: "Path cleaning",
+			url:  "https://example.com/a/./b/../c//d/",
+			want: "https://example.com/a/c/d",
+		},
+		{
+			name: "Sorted query",
+			url:  "https://example.com/?b=2&a=1&a=0",
+			want: "https://example.com/?a=1&a=0&b=2",
+		},
+		{
+			name: "Tracking params kept by default",
+			url:  "https://example.com/?utm_source=x&id=1",
+			want: "https://example.com/?id=1&utm_source=x",
+		},
+		{
+			name: "Tracking params stripped",
+			url:  "https://example.com/?UTM_Source=x&fbclid=y&id=1",
+			opts: urlnorm.Options{StripTracking: true},
+			want: "https://example.com/?id=1",
+		},
+		{
+			name:    "No host",
+			url:     "javascript:alert(1)",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := urlnorm.Normalize(tc.url, tc.opts)
+			if tc.wantErr {
+				require.ErrorIs(t, err, urlnorm.ErrInvalidURL)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// Package useragent extracts coarse client information (OS, device class,
+// bot or human) from the User-Agent header and the preferred language from
+// Accept-Language. It is a heuristic meant for redirect targeting, not
+// a full user agent database.
+package useragent
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	OSiOS      = "ios"
+	OSAndroid  = "android"
+	OSWindows  = "windows"
+	OSMacOS    = "macos"
+	OSLinux    = "linux"
+	OSChromeOS = "chromeos"
+
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// botMarkers are lowercase substrings found in crawler and tool user agents.
+var botMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "crawling",
+	"facebookexternalhit", "facebookcatalog", "embedly", "quora link preview",
+	"outbrain", "pinterest", "vkshare", "w3c_validator", "whatsapp",
+	"skypeuripreview", "preview", "headlesschrome", "lighthouse",
+	"curl/", "wget/", "python-requests", "go-http-client", "okhttp", "httpclient",
+}
+
+// previewMarkers are lowercase substrings found in user agents of services
+// fetching a page to unfurl a pasted link (chats, social networks, search).
+var previewMarkers = []string{
+	"facebookexternalhit", "facebookcatalog", "twitterbot", "slackbot", "slack-imgproxy",
+	"discordbot", "telegrambot", "whatsapp", "linkedinbot", "skypeuripreview",
+	"vkshare", "pinterest", "redditbot", "embedly", "iframely", "quora link preview",
+	"mastodon", "viber", "googlebot", "bingbot", "applebot", "yandexbot",
+}
+
+// Info is what Parse learns from a User-Agent.
+type Info struct {
+	// OS is one of the OS* constants, empty if unknown.
+	OS string
+	// Device is one of the Device* constants.
+	Device string
+	// Bot is set for crawlers, link preview fetchers and HTTP tools.
+	Bot bool
+}
+
+// Parse classifies the User-Agent header value.
+// Empty user agents are treated as bots.
+func Parse(ua string) Info {
+	s := strings.ToLower(ua)
+
+	info := Info{
+		OS:     parseOS(s),
+		Device: DeviceDesktop,
+		Bot:    IsBot(ua),
+	}
+
+	switch {
+	case strings.Contains(s, "ipad") || strings.Contains(s, "tablet") ||
+		(strings.Contains(s, "android") && !strings.Contains(s, "mobile")):
+		info.Device = DeviceTablet
+	case strings.Contains(s, "mobi") || strings.Contains(s, "iphone") || strings.Contains(s, "ipod"):
+		info.Device = DeviceMobile
+	}
+
+	return info
+}
+
+// IsBot reports whether ua belongs to a crawler, link preview fetcher
+// or an HTTP tool rather than a human with a browser.
+func IsBot(ua string) bool {
+	s := strings.ToLower(strings.TrimSpace(ua))
+	if s == "" {
+		return true
+	}
+
+	for _, marker := range botMarkers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsLinkPreview reports whether ua belongs to a known crawler that renders
+// link previews. Unlike IsBot it does not match HTTP tools like curl.
+func IsLinkPreview(ua string) bool {
+	s := strings.ToLower(ua)
+
+	for _, marker := range previewMarkers {
+		if strings.Contains(s, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseOS(s string) string {
+	// order matters: iOS and Android user agents also mention "like Mac OS X" and "Linux"
+	switch {
+	case strings.Contains(s, "iphone") || strings.Contains(s, "ipad") || strings.Contains(s, "ipod"):
+		return OSiOS
+	case strings.Contains(s, "android"):
+		return OSAndroid
+	case strings.Contains(s, "cros"):
+		return OSChromeOS
+	case strings.Contains(s, "windows"):
+		return OSWindows
+	case strings.Contains(s, "mac os x") || strings.Contains(s, "macintosh"):
+		return OSMacOS
+	case strings.Contains(s, "linux"):
+		return OSLinux
+	}
+
+	return ""
+}
+
+// PreferredLanguage returns the language tag with the highest quality
+// in an Accept-Language header ("ru-RU,ru;q=0.9,en;q=0.8" -> "ru-RU").
+// Returns empty string if there is none.
+func PreferredLanguage(header string) string {
+	type lang struct {
+		tag string
+		q   float64
+	}
+
+	var langs []lang
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+
+		langs = append(langs, lang{tag: tag, q: q})
+	}
+
+	if len(langs) == 0 {
+		return ""
+	}
+
+	// stable: equal quality keeps header order
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	return langs[0].tag
+}
+
+// MatchLanguage reports whether the language tag matches the wanted one.
+// A wanted primary language ("pt") matches all its regions ("pt-BR"),
+// a wanted region ("pt-BR") only matches itself. Case insensitive.
+func MatchLanguage(wanted, tag string) bool {
+	if wanted == "" || tag == "" {
+		return false
+	}
+
+	if strings.EqualFold(wanted, tag) {
+		return true
+	}
+
+	primary, _, _ := strings.Cut(tag, "-")
+
+	return !strings.Contains(wanted, "-") && strings.EqualFold(wanted, primary)
+}
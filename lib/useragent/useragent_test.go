@@ -0,0 +1,108 @@
+package useragent_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"urlshortener/lib/useragent"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		name string
+		ua   string
+		want useragent.Info
+	}{
+		{
+			name: "iPhone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			want: useragent.Info{OS: useragent.OSiOS, Device: useragent.DeviceMobile},
+		},
+		{
+			name: "iPad",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			want: useragent.Info{OS: useragent.OSiOS, Device: useragent.DeviceTablet},
+		},
+		{
+			name: "Android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36",
+			want: useragent.Info{OS: useragent.OSAndroid, Device: useragent.DeviceMobile},
+		},
+		{
+			name: "Android tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			want: useragent.Info{OS: useragent.OSAndroid, Device: useragent.DeviceTablet},
+		},
+		{
+			name: "Windows desktop",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			want: useragent.Info{OS: useragent.OSWindows, Device: useragent.DeviceDesktop},
+		},
+		{
+			name: "Mac desktop",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Safari/605.1.15",
+			want: useragent.Info{OS: useragent.OSMacOS, Device: useragent.DeviceDesktop},
+		},
+		{
+			name: "Googlebot",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: useragent.Info{Device: useragent.DeviceDesktop, Bot: true},
+		},
+		{
+			name: "curl",
+			ua:   "curl/8.4.0",
+			want: useragent.Info{Device: useragent.DeviceDesktop, Bot: true},
+		},
+		{
+			name: "Empty",
+			ua:   "",
+			want: useragent.Info{Device: useragent.DeviceDesktop, Bot: true},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, useragent.Parse(tc.ua))
+		})
+	}
+}
+
+func TestIsLinkPreview(t *testing.T) {
+	previews := []string{
+		"facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)",
+		"Twitterbot/1.0",
+		"Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)",
+		"Mozilla/5.0 (compatible; Discordbot/2.0; +https://discordapp.com)",
+		"TelegramBot (like TwitterBot)",
+		"WhatsApp/2.23.20.0",
+	}
+	for _, ua := range previews {
+		require.True(t, useragent.IsLinkPreview(ua), ua)
+	}
+
+	others := []string{
+		"",
+		"curl/8.4.0",
+		"Go-http-client/1.1",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+	}
+	for _, ua := range others {
+		require.False(t, useragent.IsLinkPreview(ua), ua)
+	}
+}
+
+func TestPreferredLanguage(t *testing.T) {
+	require.Equal(t, "ru-RU", useragent.PreferredLanguage("ru-RU,ru;q=0.9,en;q=0.8"))
+	require.Equal(t, "en", useragent.PreferredLanguage("de;q=0.5, en;q=0.9, *;q=1"))
+	require.Equal(t, "", useragent.PreferredLanguage("fr;q=0"))
+	require.Equal(t, "", useragent.PreferredLanguage(""))
+}
+
+func TestMatchLanguage(t *testing.T) {
+	require.True(t, useragent.MatchLanguage("ru", "ru-RU"))
+	require.True(t, useragent.MatchLanguage("pt-BR", "PT-br"))
+	require.False(t, useragent.MatchLanguage("pt-BR", "pt-PT"))
+	require.False(t, useragent.MatchLanguage("pt-BR", "pt"))
+	require.False(t, useragent.MatchLanguage("en", ""))
+}
@@ -47,10 +47,11 @@ func TestURLShortener_HappyPath(t *testing.T) {
 // nolint:funlen
 func TestURLShortener_SaveRedirect(t *testing.T) {
 	testCases := []struct {
-		name  string
-		url   string
-		alias string
-		error string
+		name   string
+		url    string
+		alias  string
+		error  string
+		status int
 	}{
 		{
 			name:  "Valid URL with custom alias",
@@ -58,10 +59,11 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 			alias: gofakeit.Word() + gofakeit.Word(),
 		},
 		{
-			name:  "Invalid URL format",
-			url:   "invalid_url",
-			alias: gofakeit.Word(),
-			error: "field URL is not a valid URL",
+			name:   "Invalid URL format",
+			url:    "invalid_url",
+			alias:  gofakeit.Word(),
+			error:  "field URL is not a valid URL",
+			status: http.StatusUnprocessableEntity,
 		},
 		{
 			name:  "Empty alias (should generate random)",
@@ -83,14 +85,19 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 
 			e := httpexpect.Default(t, u.String())
 
+			status := tc.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+
 			// --- Save URL Test ---
-			resp := e.POST("/url").
+			resp := e.POST("/api/v1/url").
 				WithJSON(save.Request{
 					URL:   tc.url,
 					Alias: tc.alias,
 				}).
 				WithBasicAuth("myuser", "mypass").
-				Expect().Status(http.StatusOK).
+				Expect().Status(status).
 				JSON().Object()
 
 			if tc.error != "" {
@@ -122,7 +129,7 @@ func TestURLShortener_SaveRedirect(t *testing.T) {
 			require.Equal(t, tc.url, redirectedToURL)
 
 			// --- Cleanup: Delete URL ---
-			resp = e.DELETE("/url/"+alias).
+			resp = e.DELETE("/api/v1/url/"+alias).
 				WithBasicAuth("myuser", "mypass").
 				Expect().Status(http.StatusOK).
 				JSON().Object()